@@ -13,13 +13,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// DefaultTTL is how long a cached SHA is trusted. Tags like v4 move to new
+// commits on every release, so mappings must be refreshed now and then.
+const DefaultTTL = 7 * 24 * time.Hour
+
+// TTLEnv overrides DefaultTTL, Ex: 48h or 14d
+const TTLEnv = "SCHARF_CACHE_TTL"
+
 // hashEntry is the JSON shape for each action in cache.json.
 type hashEntry struct {
 	SHA       string `json:"sha"`
 	UpdatedAt string `json:"updated_at"`
+	// MovedTo is the new owner/repo of an action whose repository was renamed or
+	// transferred, so moves are still reported when the SHA comes from the cache
+	MovedTo string `json:"moved_to,omitempty"`
 }
 
 func NewHashEntry() *hashEntry {
@@ -66,8 +78,71 @@ func GetCache(dir string) (map[string]hashEntry, error) {
 	return loadCache(dir)
 }
 
+// ParseTTL parses a cache TTL given as a Go duration (Ex: 36h) or a number of
+// days (Ex: 7d). A TTL of 0 disables expiry.
+func ParseTTL(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var ttl time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cache TTL %q: %w", s, err)
+		}
+		ttl = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cache TTL %q: %w", s, err)
+		}
+		ttl = d
+	}
+
+	if ttl < 0 {
+		return 0, fmt.Errorf("invalid cache TTL %q: must not be negative", s)
+	}
+	return ttl, nil
+}
+
+// fresh reports whether the entry was updated within ttl before now. An entry
+// with a missing or malformed timestamp is of unknown age and counts as expired.
+func (e hashEntry) fresh(ttl time.Duration, now time.Time) bool {
+	if ttl == 0 {
+		return true
+	}
+
+	updated, err := time.Parse(time.RFC3339Nano, e.UpdatedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(updated) < ttl
+}
+
+// GetFreshCache returns the entries of the cache updated within ttl. Expired
+// entries are left out, so callers treat them as a cache miss. A ttl of 0 keeps
+// every entry.
+func GetFreshCache(dir string, ttl time.Duration) (map[string]hashEntry, error) {
+	m, err := loadCache(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for action, e := range m {
+		if !e.fresh(ttl, now) {
+			delete(m, action)
+		}
+	}
+	return m, nil
+}
+
 // UpdateCacheEntry sets m[action] = { newSHA, now } and persists it.
 func UpdateCacheEntry(dir, action, newSHA string) error {
+	return UpdateMovedCacheEntry(dir, action, newSHA, "")
+}
+
+// UpdateMovedCacheEntry is UpdateCacheEntry for an action whose repository moved
+// to movedTo, Ex: new-owner/repo. An empty movedTo records no move.
+func UpdateMovedCacheEntry(dir, action, newSHA, movedTo string) error {
 	m, err := loadCache(dir)
 	if err != nil {
 		return err
@@ -75,6 +150,7 @@ func UpdateCacheEntry(dir, action, newSHA string) error {
 	m[action] = hashEntry{
 		SHA:       newSHA,
 		UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		MovedTo:   movedTo,
 	}
 	return saveCache(dir, m)
 }
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -132,3 +133,197 @@ func TestCacheExists(t *testing.T) {
 		t.Error("expected true when file exists")
 	}
 }
+
+// TestGetFreshCache drops expired and malformed entries.
+func TestGetFreshCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	init := map[string]hashEntry{
+		"fresh":     {SHA: "a", UpdatedAt: now.Add(-time.Hour).Format(time.RFC3339Nano)},
+		"expired":   {SHA: "b", UpdatedAt: now.Add(-8 * 24 * time.Hour).Format(time.RFC3339Nano)},
+		"malformed": {SHA: "c", UpdatedAt: "yesterday"},
+		"missing":   {SHA: "d"},
+	}
+	b, _ := json.Marshal(init)
+	os.WriteFile(filepath.Join(dir, "cache.json"), b, 0o644)
+
+	m, err := GetFreshCache(dir, DefaultTTL)
+	if err != nil {
+		t.Fatalf("GetFreshCache failed: %v", err)
+	}
+	if len(m) != 1 || m["fresh"].SHA != "a" {
+		t.Errorf("expected only the fresh entry, got %v", m)
+	}
+
+	// A TTL of 0 disables expiry
+	m, err = GetFreshCache(dir, 0)
+	if err != nil {
+		t.Fatalf("GetFreshCache failed: %v", err)
+	}
+	if len(m) != 4 {
+		t.Errorf("expected all 4 entries without expiry, got %v", m)
+	}
+}
+
+// TestParseTTL accepts durations and days and rejects the rest.
+func TestParseTTL(t *testing.T) {
+	cases := map[string]time.Duration{
+		"36h": 36 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"0":   0,
+	}
+	for in, want := range cases {
+		got, err := ParseTTL(in)
+		if err != nil || got != want {
+			t.Errorf("ParseTTL(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "week", "xd", "-1h"} {
+		if _, err := ParseTTL(in); err == nil {
+			t.Errorf("ParseTTL(%q): expected an error", in)
+		}
+	}
+}
+
+// TestExportCache writes only the entries whose action matches the glob.
+func TestExportCache(t *testing.T) {
+	dir := t.TempDir()
+	init := map[string]hashEntry{
+		"actions/checkout@v4":      {SHA: "a", UpdatedAt: "t"},
+		"actions/setup-go@v5":      {SHA: "b", UpdatedAt: "t"},
+		"my-org/actions-deploy@v1": {SHA: "c", UpdatedAt: "t"},
+	}
+	if err := saveCache(dir, init); err != nil {
+		t.Fatalf("saveCache failed: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "export.json")
+	n, err := ExportCache(dir, file, "actions/*")
+	if err != nil || n != 2 {
+		t.Fatalf("ExportCache = %d, %v; want 2 entries", n, err)
+	}
+	b, _ := os.ReadFile(file)
+	var out map[string]hashEntry
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("invalid json written: %v", err)
+	}
+	if len(out) != 2 || out["actions/checkout@v4"].SHA != "a" || out["actions/setup-go@v5"].SHA != "b" {
+		t.Errorf("expected only the actions/* entries, got %v", out)
+	}
+
+	// An empty glob exports everything
+	if n, err := ExportCache(dir, file, ""); err != nil || n != 3 {
+		t.Errorf("ExportCache without glob = %d, %v; want 3 entries", n, err)
+	}
+	if _, err := ExportCache(dir, file, "["); err == nil {
+		t.Error("expected an error for an invalid glob")
+	}
+}
+
+// TestImportCache merges entries, keeping whichever side was updated last.
+func TestImportCache(t *testing.T) {
+	sha := func(c string) string { return strings.Repeat(c, 40) }
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	older := now.Add(-time.Hour).Format(time.RFC3339Nano)
+	newer := now.Format(time.RFC3339Nano)
+
+	local := map[string]hashEntry{
+		"stale@v1":     {SHA: sha("1"), UpdatedAt: older},
+		"fresh@v1":     {SHA: sha("2"), UpdatedAt: newer},
+		"undated@v1":   {SHA: sha("3")},
+		"untouched@v1": {SHA: sha("4"), UpdatedAt: older},
+	}
+	if err := saveCache(dir, local); err != nil {
+		t.Fatalf("saveCache failed: %v", err)
+	}
+
+	export := map[string]hashEntry{
+		"stale@v1":   {SHA: sha("a"), UpdatedAt: newer},
+		"fresh@v1":   {SHA: sha("b"), UpdatedAt: older},
+		"undated@v1": {SHA: sha("c"), UpdatedAt: older},
+		"new@v1":     {SHA: sha("d"), UpdatedAt: older},
+		"empty@v1":   {UpdatedAt: newer},
+	}
+	file := filepath.Join(t.TempDir(), "export.json")
+	b, _ := json.Marshal(export)
+	os.WriteFile(file, b, 0o644)
+
+	n, err := ImportCache(dir, file)
+	if err != nil || n != 3 {
+		t.Fatalf("ImportCache = %d, %v; want 3 entries merged", n, err)
+	}
+
+	m, err := loadCache(dir)
+	if err != nil {
+		t.Fatalf("loadCache failed: %v", err)
+	}
+	want := map[string]string{
+		"stale@v1":     sha("a"),
+		"fresh@v1":     sha("2"),
+		"undated@v1":   sha("c"),
+		"untouched@v1": sha("4"),
+		"new@v1":       sha("d"),
+	}
+	if len(m) != len(want) {
+		t.Fatalf("expected %d entries, got %v", len(want), m)
+	}
+	for key, sha := range want {
+		if m[key].SHA != sha {
+			t.Errorf("%s: expected sha %q, got %q", key, sha, m[key].SHA)
+		}
+	}
+
+	os.WriteFile(file, []byte("not-json"), 0o644)
+	if _, err := ImportCache(dir, file); err == nil {
+		t.Error("expected error from invalid json, got nil")
+	}
+}
+
+// TestImportCacheRejectsInvalidSHA refuses an export pinning something other than a commit SHA
+func TestImportCacheRejectsInvalidSHA(t *testing.T) {
+	dir := t.TempDir()
+	export := map[string]hashEntry{
+		"good@v1":  {SHA: strings.Repeat("a", 40), UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano)},
+		"bogus@v1": {SHA: "main", UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano)},
+	}
+	file := filepath.Join(t.TempDir(), "export.json")
+	b, _ := json.Marshal(export)
+	os.WriteFile(file, b, 0o644)
+
+	if n, err := ImportCache(dir, file); err == nil || !strings.Contains(err.Error(), "bogus@v1") {
+		t.Fatalf("ImportCache = %d, %v; want an error naming bogus@v1", n, err)
+	}
+	if CacheExists(dir) {
+		t.Fatal("a rejected export must not be merged")
+	}
+}
+
+// TestImportCacheClampsFutureTimestamps keeps entries from the future expiring like any other
+func TestImportCacheClampsFutureTimestamps(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	local := map[string]hashEntry{"a@v1": {SHA: strings.Repeat("1", 40), UpdatedAt: now.Format(time.RFC3339Nano)}}
+	if err := saveCache(dir, local); err != nil {
+		t.Fatalf("saveCache failed: %v", err)
+	}
+
+	export := map[string]hashEntry{"a@v1": {SHA: strings.Repeat("2", 40), UpdatedAt: now.AddDate(10, 0, 0).Format(time.RFC3339Nano)}}
+	file := filepath.Join(t.TempDir(), "export.json")
+	b, _ := json.Marshal(export)
+	os.WriteFile(file, b, 0o644)
+
+	if _, err := ImportCache(dir, file); err != nil {
+		t.Fatalf("ImportCache returned error: %v", err)
+	}
+	m, _ := loadCache(dir)
+	updated, err := time.Parse(time.RFC3339Nano, m["a@v1"].UpdatedAt)
+	if err != nil || updated.After(time.Now()) {
+		t.Fatalf("updated_at = %q; want it clamped to the import time", m["a@v1"].UpdatedAt)
+	}
+	// Clamped to the import time, the entry expires with the TTL
+	if m["a@v1"].fresh(time.Hour, time.Now().Add(2*time.Hour)) {
+		t.Fatal("expected the imported entry to expire")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package actcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// matchesAction reports whether the action of a cache key, Ex: actions/checkout
+// of actions/checkout@v4, matches the glob. An empty glob matches every key.
+func matchesAction(glob, key string) bool {
+	if glob == "" {
+		return true
+	}
+	action := key
+	if i := strings.LastIndex(key, "@"); i >= 0 {
+		action = key[:i]
+	}
+	ok, _ := path.Match(glob, action)
+	return ok
+}
+
+// ExportCache writes the entries of the cache in dir whose action matches glob,
+// Ex: actions/*, to file in the cache.json format, so another environment can
+// seed its cache with ImportCache. An empty glob exports every entry. It
+// returns the number of entries written.
+func ExportCache(dir, file, glob string) (int, error) {
+	if _, err := path.Match(glob, ""); err != nil {
+		return 0, fmt.Errorf("invalid action pattern %q: %w", glob, err)
+	}
+
+	m, err := loadCache(dir)
+	if err != nil {
+		return 0, err
+	}
+	for key := range m {
+		if !matchesAction(glob, key) {
+			delete(m, key)
+		}
+	}
+
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("encoding JSON: %w", err)
+	}
+	if err := os.WriteFile(file, buf, 0o644); err != nil {
+		return 0, fmt.Errorf("writing %s: %w", file, err)
+	}
+	return len(m), nil
+}
+
+// newer reports whether e was updated after other. A missing or malformed
+// timestamp counts as the oldest possible.
+func (e hashEntry) newer(other hashEntry) bool {
+	updated, err := time.Parse(time.RFC3339Nano, e.UpdatedAt)
+	if err != nil {
+		return false
+	}
+	otherUpdated, err := time.Parse(time.RFC3339Nano, other.UpdatedAt)
+	if err != nil {
+		return true
+	}
+	return updated.After(otherUpdated)
+}
+
+// shaRegex matches a full commit SHA, the only value a cache entry may pin
+var shaRegex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// ImportCache merges the entries of file, as written by ExportCache, into the
+// cache in dir. An entry replaces a local one only when it was updated later,
+// so importing an old export never rolls back fresher local pins. An export
+// with a SHA that isn't a full commit SHA is rejected as a whole, and timestamps
+// in the future are clamped to now, so a bad export can neither pin a bogus SHA
+// nor win every merge and never expire. It returns the number of entries added
+// or replaced.
+func ImportCache(dir, file string) (int, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", file, err)
+	}
+	imported := make(map[string]hashEntry)
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", file, err)
+	}
+
+	m, err := loadCache(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	merged := 0
+	for _, key := range slices.Sorted(maps.Keys(imported)) {
+		e := imported[key]
+		if e.SHA == "" {
+			continue
+		}
+		if !shaRegex.MatchString(e.SHA) {
+			return 0, fmt.Errorf("parsing %s: entry %s: invalid SHA %q, want 40 hex characters", file, key, e.SHA)
+		}
+		if updated, err := time.Parse(time.RFC3339Nano, e.UpdatedAt); err == nil && updated.After(now) {
+			e.UpdatedAt = now.Format(time.RFC3339Nano)
+		}
+		if local, ok := m[key]; ok && !e.newer(local) {
+			continue
+		}
+		m[key] = e
+		merged++
+	}
+
+	if merged == 0 {
+		return 0, nil
+	}
+	return merged, saveCache(dir, m)
+}
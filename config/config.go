@@ -0,0 +1,64 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+// Package config loads optional per-repository settings for scharf
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the config file looked up at the root of a repository
+const FileName = ".scharf.yml"
+
+// Config holds the settings read from a repository's .scharf.yml
+type Config struct {
+	// SeverityOverrides maps action globs (e.g. "owner/*") to a severity
+	SeverityOverrides map[string]string `yaml:"severity_overrides"`
+	// PathRules maps workflow path globs (e.g. "services/*/.github/workflows/*")
+	// to the rules applied to findings of matching workflow files
+	PathRules map[string]PathRules `yaml:"path_rules"`
+	// TrustedOwners lists owner globs (e.g. "actions", "my-org-*") whose
+	// actions are never flagged
+	TrustedOwners []string `yaml:"trusted_owners"`
+}
+
+// PathRules is the rule set applied to workflow files matching a path glob
+type PathRules struct {
+	// Ignore lists action names or globs whose findings are dropped
+	Ignore []string `yaml:"ignore"`
+	// MinSeverity drops findings below this severity
+	MinSeverity string `yaml:"min_severity"`
+}
+
+// Load reads the config file at the given path.
+// A missing file is not an error and yields an empty config.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+// LoadFromRepo reads .scharf.yml from the root of the given repository
+func LoadFromRepo(root string) (*Config, error) {
+	return Load(filepath.Join(root, FileName))
+}
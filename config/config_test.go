@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLoad_NoFile verifies that a missing config yields an empty config.
+func TestLoad_NoFile(t *testing.T) {
+	c, err := LoadFromRepo(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.SeverityOverrides) != 0 {
+		t.Errorf("expected no overrides, got %v", c.SeverityOverrides)
+	}
+}
+
+// TestLoad_SeverityOverrides verifies the severity_overrides section is parsed.
+func TestLoad_SeverityOverrides(t *testing.T) {
+	dir := t.TempDir()
+	content := "severity_overrides:\n  \"owner/*\": high\n  actions/checkout: low\n"
+	os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644)
+
+	c, err := LoadFromRepo(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.SeverityOverrides["owner/*"] != "high" {
+		t.Errorf("expected owner/* to be high, got %q", c.SeverityOverrides["owner/*"])
+	}
+	if c.SeverityOverrides["actions/checkout"] != "low" {
+		t.Errorf("expected actions/checkout to be low, got %q", c.SeverityOverrides["actions/checkout"])
+	}
+}
+
+// TestLoad_InvalidYAML ensures a broken config surfaces an error.
+func TestLoad_InvalidYAML(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, FileName), []byte("severity_overrides: [unclosed"), 0o644)
+	if _, err := LoadFromRepo(dir); err == nil {
+		t.Fatal("expected error from invalid yaml, got nil")
+	}
+}
+
+// TestLoad_PathRules verifies the path_rules section is parsed.
+func TestLoad_PathRules(t *testing.T) {
+	dir := t.TempDir()
+	content := "path_rules:\n  \"services/*/workflows/*\":\n    ignore: [actions/checkout]\n    min_severity: high\n"
+	os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644)
+
+	c, err := LoadFromRepo(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, ok := c.PathRules["services/*/workflows/*"]
+	if !ok {
+		t.Fatalf("expected path rule to be parsed, got %v", c.PathRules)
+	}
+	if len(r.Ignore) != 1 || r.Ignore[0] != "actions/checkout" || r.MinSeverity != "high" {
+		t.Errorf("unexpected path rule: %+v", r)
+	}
+}
+
+// TestLoad_TrustedOwners verifies the trusted_owners list is parsed.
+func TestLoad_TrustedOwners(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, FileName), []byte("trusted_owners: [actions, \"my-org-*\"]\n"), 0o644)
+
+	c, err := LoadFromRepo(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.TrustedOwners) != 2 || c.TrustedOwners[1] != "my-org-*" {
+		t.Errorf("unexpected trusted owners: %v", c.TrustedOwners)
+	}
+}
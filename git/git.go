@@ -9,17 +9,28 @@
 package git
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
 )
 
 // ListTags lists all tags available for a given repository
@@ -39,7 +50,8 @@ func ListTags(repo *git.Repository) ([]string, error) {
 }
 
 // ListGitBranches opens the Git repository located at repoPath
-// and returns a slice of branch names found in the repository.
+// and returns the names of its local branches (refs/heads/*). HEAD, tags,
+// remote-tracking refs and notes are not branches and are left out.
 func ListGitBranches(repoPath string) ([]string, error) {
 	// Open the repository at the given path
 	repo, err := git.PlainOpen(repoPath)
@@ -47,21 +59,16 @@ func ListGitBranches(repoPath string) ([]string, error) {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	// Get an iterator for the repository's branches
-	branches, err := repo.References()
+	// Get an iterator for the repository's references
+	refs, err := repo.References()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve branches: %w", err)
 	}
 
+	// Iterate over each local branch reference and add the short name to our list
 	var branchNames []string
-	tags, err := ListTags(repo)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve tags: %w", err)
-	}
-
-	// Iterate over each branch reference and add the short name to our list
-	err = branches.ForEach(func(ref *plumbing.Reference) error {
-		if !slices.Contains(tags, ref.Name().Short()) {
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsBranch() {
 			branchNames = append(branchNames, ref.Name().Short())
 		}
 		return nil
@@ -99,6 +106,148 @@ func CheckoutGitBranch(repoPath, branchName string) error {
 	return nil
 }
 
+// ReadFilesAtRef returns the files directly inside dirs as recorded at ref (a branch,
+// tag or commit), without touching the working tree. Keys are slash separated
+// paths relative to the repository root. Directories missing at ref are skipped.
+func ReadFilesAtRef(repoPath, ref string, dirs []string) (map[string][]byte, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve ref %s: %w", ref, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", ref, err)
+	}
+
+	files := map[string][]byte{}
+	for _, dir := range dirs {
+		dir = path.Clean(filepath.ToSlash(dir))
+		sub, err := tree.Tree(dir)
+		if err != nil {
+			if errors.Is(err, object.ErrDirectoryNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s at %s: %w", dir, ref, err)
+		}
+
+		for _, entry := range sub.Entries {
+			if !entry.Mode.IsFile() || entry.Mode == filemode.Symlink {
+				continue
+			}
+
+			f, err := sub.TreeEntryFile(&entry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s at %s: %w", entry.Name, ref, err)
+			}
+
+			content, err := f.Contents()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s at %s: %w", entry.Name, ref, err)
+			}
+			files[path.Join(dir, entry.Name)] = []byte(content)
+		}
+	}
+
+	return files, nil
+}
+
+// StagedFiles returns the files directly inside dirs that are staged for the
+// next commit, i.e. added or modified in the index compared to HEAD, with their
+// staged content rather than the working tree's. Keys are slash separated paths
+// relative to the repository root.
+func StagedFiles(repoPath string, dirs []string) (map[string][]byte, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	// Before the first commit, everything in the index is staged
+	var tree *object.Tree
+	head, err := repo.Head()
+	if err == nil {
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", head.Hash(), err)
+		}
+		if tree, err = commit.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to read tree of HEAD: %w", err)
+		}
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, dir := range dirs {
+		wanted[path.Clean(filepath.ToSlash(dir))] = true
+	}
+
+	files := map[string][]byte{}
+	for _, e := range idx.Entries {
+		if !wanted[path.Dir(e.Name)] || !e.Mode.IsFile() || e.Mode == filemode.Symlink {
+			continue
+		}
+		if tree != nil {
+			if f, err := tree.File(e.Name); err == nil && f.Hash == e.Hash {
+				continue // unchanged since HEAD
+			}
+		}
+
+		blob, err := repo.BlobObject(e.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read staged %s: %w", e.Name, err)
+		}
+		r, err := blob.Reader()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read staged %s: %w", e.Name, err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read staged %s: %w", e.Name, err)
+		}
+		files[e.Name] = content
+	}
+
+	return files, nil
+}
+
+// RemoteBranchRef returns the full name of the remote-tracking branch of remote,
+// Ex: refs/remotes/upstream/main, so ReadFilesAtRef reads that remote's branch
+// instead of a local branch of the same name
+func RemoteBranchRef(repoPath, remote, branch string) (string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if _, err := repo.Remote(remote); err != nil {
+		return "", fmt.Errorf("failed to find remote %s: %w", remote, err)
+	}
+
+	name := plumbing.NewRemoteReferenceName(remote, branch)
+	if _, err := repo.Reference(name, true); err != nil {
+		return "", fmt.Errorf("failed to find branch %s of remote %s, fetch it first: %w", branch, remote, err)
+	}
+
+	return name.String(), nil
+}
+
 // GetCurrentBranch returns the head ref of a Git Repository
 func GetCurrentBranch(path string) (string, error) {
 	repo, err := git.PlainOpen(path)
@@ -114,6 +263,49 @@ func GetCurrentBranch(path string) (string, error) {
 	return head.Name().String(), nil
 }
 
+// DefaultBranch returns the short name of the default branch of the repository:
+// the branch origin/HEAD points to in a clone, else the checked out branch
+func DefaultBranch(repoPath string) (string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false); err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().Short(), "origin/"), nil
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("git error: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return "", fmt.Errorf("git error: HEAD of %s is detached", repoPath)
+	}
+	return head.Name().Short(), nil
+}
+
+// IsWorktreeClean reports whether the repository at repoPath has no uncommitted
+// changes, including untracked files that are not ignored.
+func IsWorktreeClean(repoPath string) (bool, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	return status.IsClean(), nil
+}
+
 // IsGitRepo detects if a given repository is Git initialized
 func IsGitRepo(path string) bool {
 	_, err := git.PlainOpen(path)
@@ -124,10 +316,120 @@ func IsGitRepo(path string) bool {
 	return true
 }
 
+// TmpDirEnv overrides the directory remote repositories are cloned into
+const TmpDirEnv = "SCHARF_TMPDIR"
+
+// tempRoot returns the directory clones are created under: SCHARF_TMPDIR, or
+// the OS default ($TMPDIR on Unix, %TEMP% on Windows) when it's empty
+func tempRoot() string {
+	return strings.TrimSpace(os.Getenv(TmpDirEnv))
+}
+
+// tokenEnvs hold a GitHub token for cloning over HTTPS, in order of precedence,
+// like the API token of the network package
+var tokenEnvs = []string{"SCHARF_TOKEN", "GITHUB_TOKEN"}
+
+// apiURLEnv configures the GitHub Enterprise Server API, like in the network
+// package, which imports this one. Its host is the only host besides github.com
+// a token is sent to.
+const apiURLEnv = "SCHARF_GITHUB_API_URL"
+
+// isTokenHost reports whether host is github.com or the host of the configured
+// GitHub Enterprise Server, so a token never reaches other hosts, Ex: gitlab.com
+func isTokenHost(host string) bool {
+	if strings.EqualFold(host, "github.com") {
+		return true
+	}
+
+	api, err := url.Parse(strings.TrimSpace(os.Getenv(apiURLEnv)))
+	if err != nil || api.Host == "" {
+		return false
+	}
+	// GHE.com serves its API from an api. subdomain, Ex: api.octocorp.ghe.com
+	return strings.EqualFold(host, api.Host) || strings.EqualFold("api."+host, api.Host)
+}
+
+// cloneToken returns the token to clone repoURL with, or "" when it isn't an
+// HTTPS URL of a GitHub host or no token is set. SSH URLs authenticate with keys instead.
+func cloneToken(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme != "https" || !isTokenHost(u.Host) {
+		return ""
+	}
+	for _, env := range tokenEnvs {
+		if token := strings.TrimSpace(os.Getenv(env)); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
+
+// nativeCloneEnv returns the environment of a native git clone of repoURL. With
+// a token, an Authorization header is configured for the host of repoURL only,
+// through GIT_CONFIG_* variables so the token never shows in the process list.
+// The header is added after the GIT_CONFIG_* entries already in the environment.
+func nativeCloneEnv(repoURL string) []string {
+	env := os.Environ()
+	token := cloneToken(repoURL)
+	if token == "" {
+		return env
+	}
+
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return env
+	}
+
+	count := 0
+	if n, err := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT")); err == nil && n > 0 {
+		count = n
+	}
+	env = slices.DeleteFunc(env, func(e string) bool { return strings.HasPrefix(e, "GIT_CONFIG_COUNT=") })
+
+	creds := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	return append(env,
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", count+1),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=http.https://%s/.extraHeader", count, u.Host),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=Authorization: Basic %s", count, creds),
+	)
+}
+
+// cloneOptions returns the go-git options of a shallow clone of repoURL: SSH URLs
+// authenticate with ~/.ssh/id_rsa, HTTPS URLs with a token when one is set
+func cloneOptions(repoURL string) (*git.CloneOptions, error) {
+	opts := &git.CloneOptions{
+		URL:          repoURL,
+		Progress:     os.Stdout,
+		Depth:        1,    // <-- shallow
+		SingleBranch: true, // <-- single branch
+	}
+
+	if strings.HasPrefix(repoURL, "git@") ||
+		strings.HasPrefix(repoURL, "ssh://") {
+		// this will look for ~/.ssh/id_rsa (no passphrase)
+		auth, sshErr := ssh.NewPublicKeysFromFile(
+			"git",
+			filepath.Join(os.Getenv("HOME"), ".ssh", "id_rsa"),
+			"",
+		)
+		if sshErr != nil {
+			return nil, fmt.Errorf("setting up SSH auth: %w", sshErr)
+		}
+		opts.Auth = auth
+	} else if token := cloneToken(repoURL); token != "" {
+		opts.Auth = &githttp.BasicAuth{Username: "x-access-token", Password: token}
+	}
+
+	return opts, nil
+}
+
 // CloneRepoToTemp clones the given GitHub repository URL (https:// or ssh:// or git@...)
-// into a newly-created temporary directory under /tmp and returns the local path.
+// into a newly-created temporary directory and returns the local path. HTTPS
+// clones are authenticated with SCHARF_TOKEN or GITHUB_TOKEN when set, so private
+// repositories can be cloned in CI without SSH keys.
 func CloneRepoToTemp(repoURL string) (string, error) {
-	tmpDir, err := os.MkdirTemp("/tmp", "scharf-repo-*")
+	tmpDir, err := os.MkdirTemp(tempRoot(), "scharf-repo-*")
 	if err != nil {
 		return "", fmt.Errorf("creating temp dir: %w", err)
 	}
@@ -141,6 +443,7 @@ func CloneRepoToTemp(repoURL string) (string, error) {
 			repoURL,
 			tmpDir,
 		)
+		cmd.Env = nativeCloneEnv(repoURL)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err == nil {
@@ -151,25 +454,10 @@ func CloneRepoToTemp(repoURL string) (string, error) {
 	}
 
 	// 2) If native Git is not available, use go-git shallow clone
-	opts := &git.CloneOptions{
-		URL:          repoURL,
-		Progress:     os.Stdout,
-		Depth:        1,    // <-- shallow
-		SingleBranch: true, // <-- single branch
-	}
-
-	if strings.HasPrefix(repoURL, "git@") ||
-		strings.HasPrefix(repoURL, "ssh://") {
-		// this will look for ~/.ssh/id_rsa (no passphrase)
-		auth, sshErr := ssh.NewPublicKeysFromFile(
-			"git",
-			filepath.Join(os.Getenv("HOME"), ".ssh", "id_rsa"),
-			"",
-		)
-		if sshErr != nil {
-			return "", fmt.Errorf("setting up SSH auth: %w", sshErr)
-		}
-		opts.Auth = auth
+	opts, err := cloneOptions(repoURL)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
 	}
 
 	// clone the repo and cleanup left overs if op errors
@@ -183,3 +471,32 @@ func CloneRepoToTemp(repoURL string) (string, error) {
 
 	return tmpDir, nil
 }
+
+// ListRemoteRefs lists the refs of a remote repository like git ls-remote, mapping
+// each ref name (Ex: refs/tags/v4) to its SHA. Annotated tags map to the commit
+// they point to, not to the tag object.
+func ListRemoteRefs(url string) (map[string]string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{Name: "origin", URLs: []string{url}})
+	refs, err := remote.List(&git.ListOptions{PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+
+	shas := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		if ref.Type() != plumbing.HashReference {
+			continue
+		}
+
+		name := ref.Name().String()
+		if tag, peeled := strings.CutSuffix(name, "^{}"); peeled {
+			shas[tag] = ref.Hash().String()
+			continue
+		}
+		if _, ok := shas[name]; !ok {
+			shas[name] = ref.Hash().String()
+		}
+	}
+
+	return shas, nil
+}
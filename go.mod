@@ -6,6 +6,7 @@ require (
 	github.com/go-git/go-git/v5 v5.17.1
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/spf13/cobra v1.9.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -9,17 +9,106 @@
 package logging
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"os"
+	"slices"
+	"sync/atomic"
 )
 
-// Getlogger makes a new struct log object with given level
+// Formats of log records
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// level is shared by the loggers of GetLogger(0), so SetupLogging also
+// reconfigures the loggers packages created at init
+var level = new(slog.LevelVar)
+
+// output receives the log records, with secrets redacted (see AddSecret)
+var output io.Writer = os.Stderr
+
+// passAll lets handlers accept records of any level
+const passAll = slog.Level(math.MinInt)
+
+// configured is the handler set up by SetupLogging. It doesn't filter by level
+// itself, as each sharedHandler decides what is enabled.
+var configured atomic.Pointer[slog.Handler]
+
+func init() {
+	var h slog.Handler = slog.NewTextHandler(redactingWriter{output}, &slog.HandlerOptions{Level: passAll})
+	configured.Store(&h)
+}
+
+// sharedHandler forwards records to the configured handler, replaying the
+// attributes and groups added with With and WithGroup on it
+type sharedHandler struct {
+	level slog.Leveler
+	wrap  []func(slog.Handler) slog.Handler
+}
+
+func (h *sharedHandler) target() slog.Handler {
+	t := *configured.Load()
+	for _, w := range h.wrap {
+		t = w(t)
+	}
+	return t
+}
+
+func (h *sharedHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *sharedHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.target().Handle(ctx, r)
+}
+
+func (h *sharedHandler) with(w func(slog.Handler) slog.Handler) *sharedHandler {
+	return &sharedHandler{level: h.level, wrap: append(slices.Clip(h.wrap), w)}
+}
+
+func (h *sharedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(t slog.Handler) slog.Handler { return t.WithAttrs(attrs) })
+}
+
+func (h *sharedHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(t slog.Handler) slog.Handler { return t.WithGroup(name) })
+}
+
+// GetLogger returns a logger writing with the configuration of SetupLogging.
+// A zero lvl follows the configured level, any other fixes the logger's level.
+// The slog default is left alone.
 func GetLogger(lvl slog.Level) *slog.Logger {
-	if lvl == 0 {
-		lvl = slog.LevelInfo
+	var leveler slog.Leveler = level
+	if lvl != 0 {
+		leveler = lvl
 	}
-	h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: lvl})
-	slog.SetDefault(slog.New(h))
-	logger := slog.Default()
-	return logger
+
+	return slog.New(&sharedHandler{level: leveler})
+}
+
+// SetupLogging sets the level and format (text or json) of every logger and makes
+// them the slog default. Call it once, before any work is logged.
+func SetupLogging(lvl slog.Level, format string) error {
+	// Levels are filtered by sharedHandler
+	opts := &slog.HandlerOptions{Level: passAll}
+
+	var h slog.Handler
+	switch format {
+	case "", FormatText:
+		h = slog.NewTextHandler(redactingWriter{output}, opts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(redactingWriter{output}, opts)
+	default:
+		return fmt.Errorf("unknown log format %q. Available options: text, json", format)
+	}
+
+	level.Set(lvl)
+	configured.Store(&h)
+	slog.SetDefault(GetLogger(0))
+	return nil
 }
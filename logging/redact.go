@@ -0,0 +1,92 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package logging
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in redacted output
+const Redacted = "***"
+
+// minSecretLen is the length below which values aren't redacted. Replacing a
+// short value, Ex: a placeholder token "x", would mangle unrelated output, and
+// real tokens are far longer.
+const minSecretLen = 8
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecret makes Redact, and so every log record, replace s with ***.
+// Ex: the GitHub token, so it never leaks through a logged URL or error.
+func AddSecret(s string) {
+	s = strings.TrimSpace(s)
+	if len(s) < minSecretLen {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, known := range secrets {
+		if known == s {
+			return
+		}
+	}
+	secrets = append(secrets, s)
+}
+
+// Redact replaces every secret added with AddSecret in s with ***
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+
+	return s
+}
+
+// redactedError is an error whose message is redacted. It unwraps to the
+// original error, so errors.Is and errors.As keep working.
+type redactedError struct {
+	err error
+}
+
+func (e redactedError) Error() string {
+	return Redact(e.err.Error())
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+// RedactError returns err with secrets redacted from its message, or nil
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return redactedError{err: err}
+}
+
+// redactingWriter redacts secrets from what it writes. slog handlers write a
+// record at a time, so a secret is never split across writes.
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
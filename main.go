@@ -10,14 +10,17 @@ package main
 
 import (
 	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/cybrota/scharf/actcache"
 	"github.com/cybrota/scharf/logging"
 	nw "github.com/cybrota/scharf/network"
 	sc "github.com/cybrota/scharf/scanner"
@@ -71,15 +74,112 @@ func addSharedUpgradeFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool("dry-run", false, "Preview changes without writing files")
 }
 
-func writeToJSON(inv *sc.Inventory) {
-	f, _ := os.Create("findings.json")
-	defer f.Close()
-	enc := json.NewEncoder(f)
-	enc.SetIndent(" ", " ")
-	enc.Encode(inv)
+// newResolver builds a SHA resolver honoring the resolver related root flags
+func newResolver(cmd *cobra.Command) *nw.SHAResolver {
+	r := nw.NewSHAResolver()
+	r.CacheReadOnly, _ = cmd.Flags().GetBool("cache-read-only")
+	mode, _ := cmd.Flags().GetString("resolver")
+	mode, err := nw.ParseResolver(mode)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if mode == nw.ResolverGit {
+		r.External = nw.NewGitResolver()
+	}
+
+	if command, _ := cmd.Flags().GetString("resolver-cmd"); command != "" {
+		if mode == nw.ResolverGit {
+			fmt.Println("--resolver-cmd can't be combined with --resolver git")
+			os.Exit(1)
+		}
+		external, err := nw.NewCommandResolver(command)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		r.External = external
+	}
+	return r
+}
+
+// writeResolutionLog persists the resolutions attempted by r when --resolution-log is set
+func writeResolutionLog(cmd *cobra.Command, r *nw.SHAResolver) {
+	path, _ := cmd.Flags().GetString("resolution-log")
+	if path == "" {
+		return
+	}
+
+	if err := nw.WriteResolutionLog(path, r.Resolutions()); err != nil {
+		logger.Error("failed to write resolution log", "path", path, "err", err)
+	}
+}
+
+// writeReport writes the SARIF or Code Climate document of r to the output path,
+// or to stdout when it is empty
+func writeReport(stdout *os.File, output string, format sc.ReportFormat, r *sc.AuditReport) error {
+	marshal, name := sc.MarshalSARIF, "SARIF"
+	if format == sc.ReportFormatCodeClimate {
+		marshal, name = sc.MarshalCodeClimate, "Code Climate"
+	}
+	data, err := marshal(r)
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+
+	if output == "" {
+		_, err = fmt.Fprintln(stdout, string(data))
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	fmt.Printf("%s report written to %s\n", name, output)
+	return nil
 }
 
-func WriteToCSV(inv *sc.Inventory) {
+// createOutput opens the file findings are written to. "-" means stdout.
+func createOutput(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+	return f, nil
+}
+
+// nopCloser keeps stdout open after findings are written to it
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// isTerminal reports whether f is a terminal rather than a pipe or file, so
+// colors never end up in output that other tools read
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func writeToJSON(inv *sc.Inventory, output string, compact bool) error {
+	f, err := createOutput(output)
+	if err != nil {
+		return err
+	}
+
+	if err := sc.EncodeInventory(f, inv, compact); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	return nil
+}
+
+func WriteToCSV(inv *sc.Inventory, output string) error {
 	writeRows := [][]string{
 		{
 			"repository_name",
@@ -90,60 +190,441 @@ func WriteToCSV(inv *sc.Inventory) {
 	}
 
 	for _, ir := range inv.Records {
+		branch := ir.Branch
+		if len(ir.Branches) > 0 {
+			branch = strings.Join(ir.Branches, ";")
+		}
 		for _, mat := range ir.Matches {
 			writeRows = append(writeRows, []string{
 				ir.Repository,
-				ir.Branch,
+				branch,
 				ir.FilePath,
 				mat,
 			})
 		}
 	}
 
-	f, _ := os.Create("findings.csv")
-	defer f.Close()
+	f, err := createOutput(output)
+	if err != nil {
+		return err
+	}
+
 	csv_writer := csv.NewWriter(f)
-	csv_writer.WriteAll(writeRows)
+	if err := csv_writer.WriteAll(writeRows); err != nil {
+		f.Close()
+		return fmt.Errorf("csv: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	return nil
 }
 
-func main() {
-	// list table configuration
-	tw := tablewriter.NewWriter(os.Stdout)
+// listRows returns the table rows, name, SHA and kind, of the refs of action
+// listed by scharf list. refs picks tags, branches or all; tags come before
+// branches and each kind is sorted on its own when sortOrder is asc or desc.
+func listRows(action string, refs string, sortOrder string, limit int) ([][]string, error) {
+	kinds := map[string][]string{"tags": {"tag"}, "branches": {"branch"}, "all": {"tag", "branch"}}[refs]
+	if kinds == nil {
+		return nil, fmt.Errorf("invalid refs %q. Available options: tags, branches, all", refs)
+	}
 
-	var cmdAudit = &cobra.Command{
-		Use:   "audit",
-		Short: "🥽 Audit a local or remote Git repository to identify vulnerable actions with mutable references: 'scharf audit <repo>|<url>'",
-		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🥽 Audit the actions and raise error if any mutable references found. Good used with Ci/CD pipelines: 'scharf audit <repo>|<url>'`),
-		Args:  cobra.MinimumNArgs(0),
-		Run: func(cmd *cobra.Command, args []string) {
-			then := time.Now()
-			rp, err := sc.BuildRepoPath("audit", args)
+	var rows [][]string
+	for _, kind := range kinds {
+		var list []nw.BranchOrTag
+		var err error
+		if kind == "branch" {
+			list, err = nw.GetBranchList(action)
+		} else {
+			list, err = nw.GetRefList(action)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if sortOrder != "" {
+			nw.SortRefs(list, sortOrder == "desc")
+		}
+		for _, ref := range list {
+			rows = append(rows, []string{ref.Name, ref.Commit.Sha, kind})
+		}
+	}
+
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// runAudit runs the audit command and returns its exit code, so deferred calls,
+// Ex: removing a cloned repository, run before the process exits
+func runAudit(cmd *cobra.Command, args []string) int {
+	minSeverity, err := sc.ParseSeverity(cmd.Flag("min-severity").Value.String())
+	if err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+
+	failOn, err := sc.ParseFailOn(cmd.Flag("fail-on").Value.String())
+	if err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+
+	format, err := sc.ParseReportFormat(cmd.Flag("format").Value.String())
+	if err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+
+	owners, _ := cmd.Flags().GetStringSlice("trusted-owner")
+	if err := sc.ValidateTrustedOwners(owners); err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+
+	ignoreLines, _ := cmd.Flags().GetStringSlice("ignore-line")
+	if err := sc.ValidateIgnoreLines(ignoreLines); err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+	auditOpts := sc.AuditOptions{TrustedOwners: owners, IgnoreLines: ignoreLines}
+
+	output, _ := cmd.Flags().GetString("output")
+	listActions, _ := cmd.Flags().GetBool("list-actions")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if jsonOut && (listActions || format != sc.ReportFormatText) {
+		fmt.Fprintln(os.Stderr, "--json can't be combined with --list-actions or --format")
+		return 1
+	}
+	// Inside a GitHub Actions job, findings become inline annotations unless asked otherwise
+	if !cmd.Flags().Changed("format") && !jsonOut && !listActions && os.Getenv(sc.GitHubActionsEnv) == "true" {
+		format = sc.ReportFormatGitHub
+	}
+
+	// Keep stdout clean for machine-readable output; progress and warnings go to stderr
+	var out io.Writer = os.Stdout
+	machineReadable := format == sc.ReportFormatSarif || format == sc.ReportFormatCodeClimate
+	if (machineReadable && output == "") || listActions || jsonOut {
+		out = os.Stderr
+	}
+
+	// A pre-commit hook only checks what is about to be committed, and blocks the commit on findings
+	staged, _ := cmd.Flags().GetBool("staged")
+	then := time.Now()
+	res := newResolver(cmd)
+	danglingLocalRefs := 0
+	danglingPins := 0
+	// Removes a repository cloned from a URL
+	cleanup := func() {}
+	defer func() { cleanup() }()
+	var report *sc.AuditReport
+	if len(args) > 0 && sc.IsArchivePath(args[0]) {
+		report, err = sc.AuditArchiveReport(args[0], res, auditOpts)
+		writeResolutionLog(cmd, res)
+		if err != nil {
+			fmt.Fprintln(out, err.Error())
+			return 0
+		}
+		fmt.Fprint(out, report.Summary())
+	} else {
+		var rp *sc.FilePath
+		rp, cleanup, err = sc.BuildRepoPath("audit", args, out)
+		if err != nil {
+			fmt.Fprintln(out, err.Error())
+			return 0
+		}
+
+		// Guard against vacuous passes in CI when pointed at the wrong directory
+		// --min-workflows is an alias of --min-go-workflows
+		minWorkflows, _ := cmd.Flags().GetInt("min-go-workflows")
+		if cmd.Flags().Changed("min-workflows") {
+			minWorkflows, _ = cmd.Flags().GetInt("min-workflows")
+		}
+		if minWorkflows > 0 {
+			count, err := sc.CountWorkflowFiles(*rp)
 			if err != nil {
-				fmt.Println(err.Error())
-				return
+				fmt.Fprintln(out, err.Error())
+				return 1
+			}
+			if count < minWorkflows {
+				fmt.Fprintf(out, "Found %d workflow files in %s (looked in %s), expected at least %d\n", count, *rp, strings.Join(sc.WorkflowDirs(), ", "), minWorkflows)
+				return 1
 			}
+		}
 
-			wfs, err := sc.AuditRepository(*rp)
+		if staged {
+			report, err = sc.AuditStagedReport(*rp, res, auditOpts)
+		} else {
+			report, err = sc.AuditRepositoryReport(*rp, res, auditOpts)
+		}
+		writeResolutionLog(cmd, res)
+		if err != nil {
+			fmt.Fprintln(out, err.Error())
+			fmt.Fprintln(out, "Skipping checks!")
+			return 0
+		}
+		fmt.Fprint(out, report.Summary())
+
+		if checkLocal, _ := cmd.Flags().GetBool("check-local-refs"); checkLocal {
+			refs, err := sc.CheckLocalReferences(*rp)
 			if err != nil {
-				fmt.Printf("Not a git repository nor workflows found. Skipping checks!")
-				return
+				fmt.Fprintln(out, err.Error())
+				return 0
 			}
+			for _, ref := range refs {
+				if !ref.Exists {
+					danglingLocalRefs++
+					fmt.Fprintf(out, "%sWarning:%s local reference %s at %s:%d does not exist\n", sc.Yellow, sc.Reset, ref.Path, ref.FilePath, ref.Line)
+				}
+			}
+		}
 
-			now := time.Now()
-			di := now.Sub(then)
-			if len(*wfs) > 0 {
-				fmt.Println(sc.FormatAuditReport(*wfs))
-				shouldRaise := cmd.Flag("raise-error")
-				if shouldRaise.Value.String() == "true" {
-					os.Exit(1)
+		if compareRemote, _ := cmd.Flags().GetBool("compare-remote"); compareRemote {
+			pins, err := sc.CheckPinnedCommits(*rp, res)
+			if err != nil {
+				fmt.Fprintln(out, err.Error())
+				return 0
+			}
+			for _, pin := range pins {
+				if !pin.Exists {
+					danglingPins++
+					fmt.Fprintf(out, "%sWarning:%s dangling pin: commit %s of %s at %s:%d is not reachable upstream\n", sc.Yellow, sc.Reset, pin.SHA, pin.Action, pin.FilePath, pin.Line)
 				}
+			}
+		}
+
+		if checkContainers, _ := cmd.Flags().GetBool("check-containers"); checkContainers {
+			images, err := sc.CheckContainerImages(*rp)
+			if err != nil {
+				fmt.Fprintln(out, err.Error())
+				return 1
+			}
+			// Reported like the unpinned actions, in every format
+			sc.AddContainerFindings(report, images, nw.ResolveImageDigest)
+		}
+
+		if checkUpdates, _ := cmd.Flags().GetBool("check-updates"); checkUpdates {
+			var since time.Time
+			if s, _ := cmd.Flags().GetString("since"); s != "" {
+				since, err = sc.ParseSince(s)
+				if err != nil {
+					fmt.Fprintln(out, err.Error())
+					return 1
+				}
+			}
+			updates, warnings, err := sc.CheckUpdates(*rp, res, since)
+			if err != nil {
+				fmt.Fprintln(out, err.Error())
+				return 0
+			}
+			fmt.Fprint(out, sc.FormatWarnings(warnings))
+			for _, u := range updates {
+				fmt.Fprintf(out, "%sUpdate available:%s %s\n", sc.Cyan, sc.Reset, u)
+			}
+		}
+
+		if advisories, _ := cmd.Flags().GetBool("advisories"); advisories {
+			found, err := sc.CheckAdvisories(*rp)
+			if err != nil {
+				fmt.Fprintln(out, err.Error())
+				return 0
+			}
+			for _, a := range found {
+				fmt.Fprintf(out, "%sAdvisory:%s %s\n", sc.Yellow, sc.Reset, a)
+			}
+		}
+
+		if reportUnused, _ := cmd.Flags().GetBool("report-unused-ignores"); reportUnused {
+			rules, err := sc.ConfiguredIgnores(*rp)
+			if err != nil {
+				fmt.Fprintln(out, err.Error())
+				return 0
+			}
+			for _, r := range sc.UnusedIgnores(rules, report.Workflows) {
+				fmt.Fprintf(out, "%sUnused ignore:%s %s matched nothing\n", sc.Yellow, sc.Reset, r)
+			}
+		}
+	}
+
+	filtered := sc.FilterBySeverity(report.Workflows, minSeverity)
+	if sortByFindings, _ := cmd.Flags().GetBool("sort-by-findings"); sortByFindings {
+		filtered = sc.SortByFindings(filtered)
+	}
+	ignoreUnresolvable, _ := cmd.Flags().GetBool("ignore-unresolvable")
+	findingsExitCode := func() int {
+		if (sc.RaisesError(filtered, failOn, ignoreUnresolvable) || danglingLocalRefs > 0 || danglingPins > 0) && (cmd.Flag("raise-error").Value.String() == "true" || staged) {
+			return 1
+		}
+		return 0
+	}
+	now := time.Now()
+	di := now.Sub(then)
+	if listActions {
+		for _, ref := range sc.UniqueActions(filtered) {
+			fmt.Fprintln(os.Stdout, ref)
+		}
+		return findingsExitCode()
+	}
+
+	if jsonOut {
+		data, err := sc.MarshalAuditReport(&sc.AuditReport{Workflows: filtered, Warnings: report.Warnings})
+		if err != nil {
+			fmt.Fprintln(out, err.Error())
+			return 1
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return findingsExitCode()
+	}
+
+	if machineReadable {
+		if err := writeReport(os.Stdout, output, format, &sc.AuditReport{Workflows: filtered, Root: report.Root, Warnings: report.Warnings}); err != nil {
+			fmt.Fprintln(out, err.Error())
+			return 1
+		}
+		fmt.Fprintf(out, "Total time: %.2f s\n", di.Seconds())
+		return findingsExitCode()
+	}
+
+	if len(filtered) > 0 {
+		if format == sc.ReportFormatGrouped {
+			fmt.Fprintln(out, sc.FormatGroupedReport(filtered))
+		} else if format == sc.ReportFormatTeamCity {
+			fmt.Fprint(out, sc.FormatTeamCity(&sc.AuditReport{Workflows: filtered, Root: report.Root}))
+		} else if format == sc.ReportFormatGitHub {
+			fmt.Fprint(out, sc.FormatGitHubAnnotations(&sc.AuditReport{Workflows: filtered, Root: report.Root}))
+		} else {
+			fmt.Fprintln(out, sc.FormatAuditReport(filtered))
+		}
+	} else {
+		fmt.Fprintln(out, "No mutable references found. Good job!")
+	}
+	if code := findingsExitCode(); code != 0 {
+		return code
+	}
+	fmt.Fprintf(out, "Total time: %.2f s\n", di.Seconds())
+	return 0
+}
+
+// runAutoFix runs the autofix command and returns its exit code, so deferred
+// calls run before the process exits
+func runAutoFix(cmd *cobra.Command, args []string) int {
+	isDryRun := cmd.Flag("dry-run")
+	var isDR bool
+	if isDryRun.Value.String() == "true" {
+		isDR = true
+	} else {
+		isDR = false
+	}
+	// With --diff, only the diff goes to stdout, so it can be piped into git apply
+	showDiff, _ := cmd.Flags().GetBool("diff")
+	var out io.Writer = os.Stdout
+	if showDiff {
+		out = os.Stderr
+	}
+	then := time.Now()
+	rp, cleanup, err := sc.BuildRepoPath("autofix", args, out)
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 0
+	}
+	defer cleanup()
+
+	commentStyleFlag, _ := cmd.Flags().GetString("comment-style")
+	commentStyle, err := sc.ParseCommentStyle(commentStyleFlag)
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 0
+	}
+	normalize, _ := cmd.Flags().GetBool("normalize")
+	if normalize && commentStyle == sc.CommentStyleNone {
+		fmt.Fprintln(out, "config error: --normalize writes a version comment after every SHA, it can't be used with --comment-style none")
+		return 0
+	}
+
+	res := newResolver(cmd)
+	rewriteMoved, _ := cmd.Flags().GetBool("rewrite-moved")
+	requireClean, _ := cmd.Flags().GetBool("require-clean")
+	skipBranches, _ := cmd.Flags().GetBool("skip-branches")
+	exitOnChanges, _ := cmd.Flags().GetBool("exit-nonzero-on-changes")
+	verifyAfterFix, _ := cmd.Flags().GetBool("verify-after-fix")
+	opts := sc.AutoFixOptions{DryRun: isDR, RewriteMoved: rewriteMoved, RequireClean: requireClean, CommentStyle: commentStyle, SkipBranches: skipBranches, ExitNonzeroOnChanges: exitOnChanges, Normalize: normalize, VerifyAfterFix: verifyAfterFix}
+
+	var applied int
+	patchFile, _ := cmd.Flags().GetString("write-patch")
+	if patchFile != "" {
+		// The workflows stay untouched; the patch is applied later with git apply
+		var patch string
+		patch, applied, err = sc.AutoFixDiff(*rp, res, opts, out, false)
+		if err == nil && patch != "" {
+			if err = os.WriteFile(patchFile, []byte(patch), 0o644); err != nil {
+				err = fmt.Errorf("os: %w", err)
 			} else {
-				fmt.Println("No mutable references found. Good job!")
+				fmt.Fprintf(out, "Patch written to %s. Apply it from the repository root with 'git apply %s'\n", patchFile, patchFile)
+			}
+		}
+	} else if showDiff {
+		var patch string
+		patch, applied, err = sc.AutoFixDiff(*rp, res, opts, out, isTerminal(os.Stdout))
+		fmt.Fprint(os.Stdout, patch)
+	} else {
+		applied, err = sc.AutoFixRepository(*rp, res, opts)
+	}
+	writeResolutionLog(cmd, res)
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		fmt.Fprintln(out, "Skipping autofix!")
+		// A gate can't tell the repository is clean
+		if exitOnChanges {
+			return 1
+		}
+		return 0
+	}
+	if applied == 0 {
+		return 0
+	}
+	now := time.Now()
+	di := now.Sub(then)
+	fmt.Fprintf(out, "Total time: %.2f s\n", di.Seconds())
+	return sc.AutoFixExitCode(applied, opts)
+}
+
+func main() {
+	// list table configuration
+	tw := tablewriter.NewWriter(os.Stdout)
+
+	var cmdAudit = &cobra.Command{
+		Use:   "audit",
+		Short: "🥽 Audit a local or remote Git repository to identify vulnerable actions with mutable references: 'scharf audit <repo>|<url>|<archive>'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🥽 Audit the actions and raise error if any mutable references found. Good used with Ci/CD pipelines: 'scharf audit <repo>|<url>'`),
+		Args:  cobra.MinimumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			if code := runAudit(cmd, args); code != 0 {
+				os.Exit(code)
 			}
-			fmt.Printf("Total time: %.2f s\n", di.Seconds())
 		},
 	}
+	cmdAudit.PersistentFlags().Bool("staged", false, "Only audit the workflow files staged for commit, as staged, and exit with 1 on findings. For pre-commit hooks")
 	cmdAudit.PersistentFlags().Bool("raise-error", false, "Raise error on any matches. Useful for interrupting CI pipelines")
+	cmdAudit.PersistentFlags().String("fail-on", sc.RefAny, "With --raise-error, the kind of mutable references that fail the audit. Available options: branch (Ex: @main), tag (Ex: @v4), any")
+	cmdAudit.PersistentFlags().Bool("ignore-unresolvable", false, "With --raise-error, don't fail on references that couldn't be resolved (Ex: private or deleted actions, network errors). They are still reported")
+	cmdAudit.PersistentFlags().StringSlice("trusted-owner", nil, "Never flag actions of this owner or owner glob, Ex: actions or my-org-* (repeatable). Adds to trusted_owners of .scharf.yml")
+	cmdAudit.PersistentFlags().StringSlice("ignore-line", nil, "Exclude the findings at a location, Ex: .github/workflows/ci.yml:12 or a range, .github/workflows/ci.yml:12-14 (repeatable). Files are relative to the repository root")
+	cmdAudit.PersistentFlags().Bool("report-unused-ignores", false, "Report ignore patterns that matched nothing, so stale entries can be pruned")
+	cmdAudit.PersistentFlags().Bool("check-local-refs", false, "Warn about same-repository references (Ex: ./.github/actions/foo) whose path does not exist")
+	cmdAudit.PersistentFlags().Bool("compare-remote", false, "Warn about pinned SHAs that are not on the default branch nor at a tag of the action repository (dangling pins, Ex: to force-pushed away or fork commits)")
+	cmdAudit.PersistentFlags().Bool("check-containers", false, "Report job and service container images (jobs.*.container, jobs.*.services.*.image) that aren't pinned to a digest, with the digest of their tag")
+	cmdAudit.PersistentFlags().Bool("check-updates", false, "Report actions whose latest GitHub release is newer than the version in use")
+	cmdAudit.PersistentFlags().Bool("advisories", false, "Report risky run: steps beyond pinning, Ex: remote scripts piped to a shell (curl ... | bash) or raw GitHub files and gists not fetched at a commit SHA. Advisories don't fail --raise-error")
+	cmdAudit.PersistentFlags().String("since", "", "With --check-updates, only report releases published after this date, Ex: 2024-01-01")
+	cmdAudit.PersistentFlags().Int("min-go-workflows", 0, "Fail when fewer workflow files than this are found. Guards CI against auditing the wrong directory")
+	cmdAudit.PersistentFlags().Int("min-workflows", 0, "Alias of --min-go-workflows")
+	cmdAudit.PersistentFlags().String("format", string(sc.ReportFormatText), "Report format. Available options: text, grouped (one entry per action@version listing all its occurrences), sarif (SARIF 2.1.0 for GitHub code scanning), teamcity (TeamCity inspection service messages), codeclimate (Code Climate JSON for GitLab Code Quality), github (GitHub Actions annotations, the default when GITHUB_ACTIONS=true)")
+	cmdAudit.PersistentFlags().Bool("json", false, "Print the findings and warnings as JSON to stdout. Progress and summary lines go to stderr")
+	cmdAudit.PersistentFlags().Bool("list-actions", false, "Print only the distinct unpinned owner/repo@ref references, one per line. Ex: scharf audit --list-actions | xargs -n1 scharf lookup")
+	cmdAudit.PersistentFlags().String("output", "", "Write the sarif or codeclimate report to this file instead of stdout")
+	cmdAudit.PersistentFlags().Bool("sort-by-findings", false, "List the workflow files with the most mutable references first")
+	cmdAudit.PersistentFlags().String("min-severity", string(sc.SeverityLow), "Only report findings at or above this severity. Available options: low, medium, high")
 
 	var cmdAutoFix = &cobra.Command{
 		Use:   "autofix",
@@ -151,32 +632,21 @@ func main() {
 		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🪄 Auto-fixes vulnerable third-party GitHub actions with mutable references: 'scharf audit <repo>|<url>'`),
 		Args:  cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
-			isDryRun := cmd.Flag("dry-run")
-			var isDR bool
-			if isDryRun.Value.String() == "true" {
-				isDR = true
-			} else {
-				isDR = false
+			if code := runAutoFix(cmd, args); code != 0 {
+				os.Exit(code)
 			}
-			then := time.Now()
-			rp, err := sc.BuildRepoPath("autofix", args)
-			if err != nil {
-				fmt.Println(err.Error())
-				return
-			}
-
-			err = sc.AutoFixRepository(*rp, isDR)
-			if err != nil {
-				fmt.Println(err.Error())
-				fmt.Println("Not a git repository. Skipping autofix!")
-				return
-			}
-			now := time.Now()
-			di := now.Sub(then)
-			fmt.Printf("Total time: %.2f s\n", di.Seconds())
 		},
 	}
 	cmdAutoFix.PersistentFlags().Bool("dry-run", false, "Preview the fixes before actually making the changes")
+	cmdAutoFix.PersistentFlags().String("write-patch", "", "Write the fixes as a single unified diff to this file instead of editing the workflows, to review and apply later with 'git apply'")
+	cmdAutoFix.PersistentFlags().Bool("diff", false, "Print the fixes as a unified diff instead of writing them. Colorized on a terminal; pipe it into 'git apply' to apply it")
+	cmdAutoFix.PersistentFlags().String("comment-style", "tag", "Version comment after a pinned SHA: tag (the ref as written), none or semver (the most specific version tag of the SHA)")
+	cmdAutoFix.PersistentFlags().Bool("normalize", false, "Also rewrite already pinned references to owner/repo@<sha> # <version>, confirming version comments by resolving them again")
+	cmdAutoFix.PersistentFlags().Bool("exit-nonzero-on-changes", false, "Exit with 1 when any fix was applied, or would be with --dry-run. Useful for pre-commit hooks and CI gates")
+	cmdAutoFix.PersistentFlags().Bool("verify-after-fix", false, "Re-parse each fixed workflow as YAML and write none of them, with an error, if the fixes broke one")
+	cmdAutoFix.PersistentFlags().Bool("skip-branches", false, "Leave branch references (Ex: @main) as they are instead of pinning them to the branch's current head SHA")
+	cmdAutoFix.PersistentFlags().Bool("require-clean", false, "Abort if the repository has uncommitted changes so the pin changes stay isolated")
+	cmdAutoFix.PersistentFlags().Bool("rewrite-moved", false, "Rewrite references of renamed or moved action repositories to their new owner/repo")
 
 	var cmdFind = &cobra.Command{
 		Use:   "find",
@@ -193,24 +663,46 @@ func main() {
 				ho = false
 			}
 
-			inv, err := sc.Find(root_path_flag.Value.String(), ho)
+			patterns, _ := cmd.Flags().GetStringSlice("branches")
+			defaultOnly, _ := cmd.Flags().GetBool("default-branch-only")
+			filter, err := sc.NewBranchFilter(patterns, defaultOnly)
 			if err != nil {
 				log.Fatal(err.Error())
 			}
 
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			orderedOutput, _ := cmd.Flags().GetBool("concurrency-safe-output")
+			sc.SetOrderedOutput(orderedOutput)
+			inv, err := sc.Find(root_path_flag.Value.String(), ho, filter, concurrency)
+			if err != nil {
+				log.Fatal(err.Error())
+			}
+
+			if dedupe, _ := cmd.Flags().GetBool("dedupe-output"); dedupe {
+				inv = sc.DedupeInventory(inv)
+			}
+
 			out_fmt_flag := cmd.Flag("out")
 			out_fmt := out_fmt_flag.Value.String()
 
+			// Without --output, findings go to findings.<format> in the current directory
+			output, _ := cmd.Flags().GetString("output")
+			if output == "" {
+				output = "findings." + out_fmt
+			}
+
 			switch out_fmt {
 			case "json":
-				writeToJSON(inv)
-				break
+				compact, _ := cmd.Flags().GetBool("json-compact")
+				err = writeToJSON(inv, output, compact)
 			case "csv":
-				WriteToCSV(inv)
-				break
+				err = WriteToCSV(inv, output)
 			default:
 				logger.Error("The given value to --out flag is invalid. Valid values are json, csv.", "value", out_fmt)
 			}
+			if err != nil {
+				log.Fatal(err.Error())
+			}
 		},
 	}
 
@@ -220,10 +712,22 @@ func main() {
 		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `👀 Look up the immutable commit-SHA of a given third-party GitHub action plus reference. Ex: scharf lookup actions/checkout@v4`),
 		Args:  cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if args[0] != "" {
-				s := nw.NewSHAResolver()
-				sha, err := s.Resolve(args[0])
+			if release, _ := cmd.Flags().GetString("release"); release != "" {
+				allowPrerelease, _ := cmd.Flags().GetBool("allow-prerelease")
+				sha, err := newResolver(cmd).ResolveRelease(args[0], release, allowPrerelease)
 				if err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+
+				fmt.Println(sha)
+			} else if args[0] != "" {
+				s := newResolver(cmd)
+				sha, err := s.Resolve(args[0])
+				writeResolutionLog(cmd, s)
+				if errors.Is(err, nw.ErrRateLimited) {
+					logger.Error(err.Error(), "action", args[0])
+				} else if err != nil {
 					logger.Error("problem while fetching action SHA. Please check the action again.", "action", args[0])
 				}
 
@@ -234,6 +738,9 @@ func main() {
 		},
 	}
 
+	cmdLookup.Flags().String("release", "", "Resolve the commit SHA of a published GitHub release tag. Ex: scharf lookup actions/checkout --release v4.2.1")
+	cmdLookup.Flags().Bool("allow-prerelease", false, "Allow --release to resolve a prerelease")
+
 	var cmdUpgrade = &cobra.Command{
 		Use:   "upgrade <owner/repo@ref-or-sha>",
 		Short: "⬆️ Upgrade a pinned action to the next version and SHA",
@@ -261,8 +768,9 @@ func main() {
 				currentVersion = fromVersion
 			}
 
-			resolver := nw.NewSHAResolver()
+			resolver := newResolver(cmd)
 			result, err := resolver.ResolveNext(action, currentVersion, cooldownHours)
+			writeResolutionLog(cmd, resolver)
 			if err != nil {
 				fmt.Println(err.Error())
 				return
@@ -292,13 +800,17 @@ func main() {
 			isDryRun, _ := cmd.Flags().GetBool("dry-run")
 
 			then := time.Now()
-			rp, err := sc.BuildRepoPath("upgrade-all-sha", args)
+			rp, cleanup, err := sc.BuildRepoPath("upgrade-all-sha", args, os.Stdout)
 			if err != nil {
 				fmt.Println(err.Error())
 				return
 			}
+			defer cleanup()
 
-			if err := sc.UpgradePinnedSHAs(*rp, cooldownHours, isDryRun); err != nil {
+			res := newResolver(cmd)
+			err = sc.UpgradePinnedSHAs(*rp, res, cooldownHours, isDryRun)
+			writeResolutionLog(cmd, res)
+			if err != nil {
 				fmt.Println(err.Error())
 				return
 			}
@@ -308,12 +820,299 @@ func main() {
 			fmt.Printf("Total time: %.2f s\n", di.Seconds())
 		},
 	}
+
+	var cmdResolveFile = &cobra.Command{
+		Use:   "resolve-file <path>",
+		Short: "📌 Pin the mutable action references of a single workflow file in place: 'scharf resolve-file <path>'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `📌 Pin the mutable action references of a single workflow file in place. No Git repository is needed, so it suits editor "format on save" integrations`),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			opts := sc.AutoFixOptions{DryRun: dryRun}
+			if noComment, _ := cmd.Flags().GetBool("no-comment"); noComment {
+				opts.CommentStyle = sc.CommentStyleNone
+			}
+
+			res := newResolver(cmd)
+			_, err := sc.AutoFixFile(sc.FilePath(args[0]), res, opts)
+			writeResolutionLog(cmd, res)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	cmdResolveFile.Flags().Bool("dry-run", false, "Preview the fixes before actually making the changes")
+	cmdResolveFile.Flags().Bool("no-comment", false, "Write 'owner/repo@<sha>' without the version comment")
+
+	var cmdUnpin = &cobra.Command{
+		Use:   "unpin",
+		Short: "🔓 Revert pinned SHAs to the refs named by their comment: 'scharf unpin <repo>'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🔓 Revert pins like 'actions/checkout@<sha> # v4' to 'actions/checkout@v4', Ex: to test against a moving tag for a while. Pins without a version comment are left untouched`),
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			rp, cleanup, err := sc.BuildRepoPath("unpin", args, os.Stdout)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer cleanup()
+
+			if _, err := sc.UnpinRepository(*rp, dryRun); err != nil {
+				fmt.Println(err.Error())
+				cleanup()
+				os.Exit(1)
+			}
+		},
+	}
+	cmdUnpin.Flags().Bool("dry-run", false, "Preview the changes before actually making them")
+
+	var cmdAnnotate = &cobra.Command{
+		Use:   "annotate",
+		Short: "🏷️ Add missing version comments to bare SHA pins: 'scharf annotate <repo>'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🏷️ Rewrite bare pins like 'actions/checkout@<sha>' to 'actions/checkout@<sha> # v4.2.2', naming the most specific version tag pointing at the SHA. Pins that have a comment are left untouched`),
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			verify, _ := cmd.Flags().GetBool("verify-after-fix")
+			rp, cleanup, err := sc.BuildRepoPath("annotate", args, os.Stdout)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer cleanup()
+
+			res := newResolver(cmd)
+			_, err = sc.AnnotateRepository(*rp, res, dryRun, verify)
+			writeResolutionLog(cmd, res)
+			if err != nil {
+				fmt.Println(err.Error())
+				cleanup()
+				os.Exit(1)
+			}
+		},
+	}
+	cmdAnnotate.Flags().Bool("dry-run", false, "Preview the comments before actually writing them")
+	cmdAnnotate.Flags().Bool("verify-after-fix", false, "Re-parse each annotated workflow as YAML and write none of them, with an error, if the comments broke one")
+
+	var cmdPin = &cobra.Command{
+		Use:   "pin [owner/repo@ref...]",
+		Short: "📍 Print the SHA-pinned form of action references or of a Dependabot diff: 'scharf pin actions/checkout@v4'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `📍 Resolve action references to their commit SHA and print the pinned form, Ex: actions/checkout@<sha> # v4. Pass --diff with a Dependabot pull request diff to pin the versions it bumps to`),
+		Run: func(cmd *cobra.Command, args []string) {
+			refs := args
+			if diffPath, _ := cmd.Flags().GetString("diff"); diffPath != "" {
+				var diff []byte
+				var err error
+				if diffPath == "-" {
+					diff, err = io.ReadAll(os.Stdin)
+				} else {
+					diff, err = os.ReadFile(diffPath)
+				}
+				if err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+				refs = append(refs, sc.ReferencesFromDiff(diff)...)
+			}
+			if len(refs) == 0 {
+				logger.Error("Please give action references or a diff to pin. Ex: scharf pin actions/checkout@v4")
+				os.Exit(1)
+			}
+
+			res := newResolver(cmd)
+			pins, err := sc.PinReferences(refs, res)
+			writeResolutionLog(cmd, res)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			for _, p := range pins {
+				fmt.Println(p)
+			}
+		},
+	}
+	cmdPin.Flags().String("diff", "", "Unified diff, Ex: of a Dependabot pull request, whose added action references are pinned. Use - to read stdin")
+
+	var cmdReverse = &cobra.Command{
+		Use:   "reverse <owner/repo> <sha>",
+		Short: "🔁 List the tags pointing at a commit SHA, Ex: to add the '# version' comment of a pin: 'scharf reverse actions/checkout <sha>'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🔁 Reverse lookup: list the tags of an action pointing at a commit SHA, newest version first, so an already pinned but uncommented action can be annotated with its version. An abbreviated SHA of 7+ characters matches by prefix`),
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			tags, err := newResolver(cmd).TagsAt(args[0], args[1])
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			if len(tags) == 0 {
+				fmt.Printf("No tags of %s point to %s\n", args[0], args[1])
+				os.Exit(1)
+			}
+
+			for _, tag := range tags {
+				fmt.Println(tag)
+			}
+		},
+	}
+
+	var cmdVerify = &cobra.Command{
+		Use:   "verify",
+		Short: "🔏 Verify pinned SHAs still match their commented tags to detect force-moved tags: 'scharf verify <repo>|<url>'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🔏 Verify that every 'owner/repo@<sha> # <version>' pin still matches the SHA its tag resolves to on GitHub. A mismatch means the tag was force-moved after pinning`),
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			then := time.Now()
+			rp, cleanup, err := sc.BuildRepoPath("verify", args, os.Stdout)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer cleanup()
+
+			// A cached SHA would hide a tag moved since it was cached
+			res := newResolver(cmd)
+			res.SkipCache = true
+			wfs, err := sc.VerifyRepository(*rp, res)
+			writeResolutionLog(cmd, res)
+			if err != nil {
+				fmt.Println(err.Error())
+				fmt.Println("Skipping verification!")
+				return
+			}
+
+			now := time.Now()
+			di := now.Sub(then)
+			if len(*wfs) > 0 {
+				fmt.Println(sc.FormatAuditReport(*wfs))
+				if cmd.Flag("raise-error").Value.String() == "true" {
+					cleanup()
+					os.Exit(1)
+				}
+			} else {
+				fmt.Println("All pinned SHAs match their tags. Good job!")
+			}
+			fmt.Printf("Total time: %.2f s\n", di.Seconds())
+		},
+	}
+	cmdVerify.Flags().Bool("raise-error", false, "Raise error on any mismatch. Useful for interrupting CI pipelines")
+
+	var cmdDiffPins = &cobra.Command{
+		Use:   "diff-pins <a> [b]",
+		Short: "🔀 Compare pinned action SHAs between two repositories or two refs: 'scharf diff-pins <repo>|<url> [<repo>|<url>]'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🔀 Compare the action→SHA pins of two repositories, or of two refs of one repository using --ref-a/--ref-b, and print added, removed and changed pins`),
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			refA, _ := cmd.Flags().GetString("ref-a")
+			refB, _ := cmd.Flags().GetString("ref-b")
+			remote, _ := cmd.Flags().GetString("remote")
+			if remote != "" && refA == "" && refB == "" {
+				fmt.Println("config error: --remote needs a branch in --ref-a or --ref-b")
+				return
+			}
+			// Both sides of a single argument, or of the same one twice, live in one
+			// repository and differ only by ref, so a URL is cloned once for both
+			var paths [2]*sc.FilePath
+			for i, arg := range args {
+				if i > 0 && arg == args[0] {
+					break
+				}
+				rp, cleanup, err := sc.BuildRepoPath("diff-pins", []string{arg}, os.Stdout)
+				if err != nil {
+					fmt.Println(err.Error())
+					return
+				}
+				defer cleanup()
+				paths[i] = rp
+			}
+			if paths[1] == nil {
+				paths[1] = paths[0]
+			}
+
+			res := newResolver(cmd)
+			var sides [2]sc.PinSet
+			for i, ref := range []string{refA, refB} {
+				var err error
+				if remote != "" && ref != "" {
+					ref, err = sc.RemoteRef(*paths[i], remote, ref)
+					if err != nil {
+						fmt.Println(err.Error())
+						return
+					}
+				}
+				sides[i], err = sc.CollectPins(*paths[i], ref, res)
+				if err != nil {
+					fmt.Println(err.Error())
+					fmt.Println("Skipping diff!")
+					return
+				}
+			}
+			writeResolutionLog(cmd, res)
+
+			changes := sc.DiffPins(sides[0], sides[1])
+			if len(changes) == 0 {
+				fmt.Println("Pins are identical.")
+				return
+			}
+			fmt.Print(sc.FormatPinDiff(changes))
+		},
+	}
+	cmdDiffPins.Flags().String("ref-a", "", "Git ref (branch, tag or commit) to read the first repository at. Defaults to the working tree")
+	cmdDiffPins.Flags().String("ref-b", "", "Git ref (branch, tag or commit) to read the second repository at. Defaults to the working tree")
+	cmdDiffPins.Flags().String("remote", "", "Read the --ref-a and --ref-b branches from this remote's tracking branches, Ex: upstream. Fetch the remote first")
+
+	var cmdCache = &cobra.Command{
+		Use:   "cache",
+		Short: "🗄️ Share resolved SHAs between environments: 'scharf cache export|import <file>'",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `🗄️ Export the cache of resolved SHAs to a file, or merge such a file into the local cache, to seed a fresh environment from a known-good one`),
+	}
+
+	var cmdCacheExport = &cobra.Command{
+		Use:   "export <file>",
+		Short: "Write the resolved SHA cache to a file, optionally only the actions matching --action",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			glob, _ := cmd.Flags().GetString("action")
+			n, err := actcache.ExportCache(nw.CacheDir(), args[0], glob)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Exported %d cache entries to %s\n", n, args[0])
+		},
+	}
+	cmdCacheExport.Flags().String("action", "", "Only export actions matching this glob, Ex: actions/* or my-org/*")
+
+	var cmdCacheImport = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Merge an exported cache into the local cache. Of two entries for one reference, the newest wins",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := actcache.ImportCache(nw.CacheDir(), args[0])
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Imported %d cache entries from %s\n", n, args[0])
+		},
+	}
+	cmdCache.AddCommand(cmdCacheExport, cmdCacheImport)
+
 	addSharedUpgradeFlags(cmdUpgrade)
 	addSharedUpgradeFlags(cmdUpgradeAllSHA)
 	cmdUpgrade.Flags().String("from-version", "", "Current version to upgrade from when input is owner/repo@<sha>")
 	cmdFind.PersistentFlags().String("root", ".", "Absolute path of root directory of GitHub repositories")
 	cmdFind.PersistentFlags().String("out", "json", "Output format of findings. Available options: json, csv")
+	cmdFind.PersistentFlags().StringP("output", "o", "", "File to write the findings to, or - for stdout. Defaults to findings.json or findings.csv")
+	cmdFind.PersistentFlags().Bool("json-compact", false, "Write JSON findings on a single line instead of indented")
 	cmdFind.PersistentFlags().Bool("head-only", false, "Limit scan only to HEAD (Activated branch)")
+	cmdFind.PersistentFlags().StringSlice("branches", nil, "Only scan branches matching these names or globs, comma-separated, Ex: main,release/*")
+	cmdFind.PersistentFlags().Bool("default-branch-only", false, "Only scan the default branch of each repository (origin/HEAD, else the checked out branch)")
+	cmdFind.PersistentFlags().Bool("dedupe-output", false, "Collapse identical findings seen on multiple branches into one record listing the branches")
+	cmdFind.PersistentFlags().Int("concurrency", sc.DefaultConcurrency, "Number of repositories scanned in parallel")
+	cmdFind.PersistentFlags().Bool("concurrency-safe-output", false, "Buffer the log output of each repository and print it in repository order, so parallel scans don't interleave their lines")
 
 	var cmdList = &cobra.Command{
 		Use:   "list",
@@ -331,16 +1130,37 @@ func main() {
 			)
 
 			if args[0] != "" {
-				list, err := nw.GetRefList(args[0])
+				sortOrder, _ := cmd.Flags().GetString("sort")
+				if sortOrder != "" && sortOrder != "asc" && sortOrder != "desc" {
+					fmt.Printf("invalid sort order %q. Available options: asc, desc\n", sortOrder)
+					os.Exit(1)
+				}
+				refs, _ := cmd.Flags().GetString("refs")
+				if refs != "tags" && refs != "branches" && refs != "all" {
+					fmt.Printf("invalid refs %q. Available options: tags, branches, all\n", refs)
+					os.Exit(1)
+				}
+				limit, _ := cmd.Flags().GetInt("limit")
+
+				rows, err := listRows(args[0], refs, sortOrder, limit)
 				if err != nil {
-					logger.Error("No tags found. Please check the action again.", "action", args[0])
+					logger.Error("No references found. Please check the action again.", "action", args[0], "err", err)
 				}
 
-				for i := range list {
-					tw.Append([]string{
-						list[i].Name,
-						list[i].Commit.Sha,
-					})
+				// The type only tells rows apart when branches are listed too
+				if refs != "tags" {
+					tw.SetHeader([]string{"Version", "Commit SHA", "Type"})
+					tw.SetHeaderColor(
+						tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+						tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+						tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+					)
+				}
+				for _, row := range rows {
+					if refs == "tags" {
+						row = row[:2]
+					}
+					tw.Append(row)
 				}
 
 				tw.Render()
@@ -350,7 +1170,114 @@ func main() {
 		},
 	}
 
-	var rootCmd = &cobra.Command{Use: "scharf", Long: asciiLogo}
-	rootCmd.AddCommand(cmdLookup, cmdFind, cmdList, cmdAudit, cmdAutoFix, cmdUpgrade, cmdUpgradeAllSHA)
+	cmdList.Flags().String("sort", "", "Sort the references by version: asc or desc (newest first). Tags that aren't versions, Ex: latest, come last. Defaults to the API order")
+	cmdList.Flags().Int("limit", 0, "Show at most this many references, after sorting. 0 shows all")
+	cmdList.Flags().String("refs", "tags", "References to list: tags, branches or all. Branches follow the tags, with a Type column telling them apart")
+
+	var rootCmd = &cobra.Command{
+		Use:  "scharf",
+		Long: asciiLogo,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if redact, _ := cmd.Flags().GetBool("redact-token"); redact {
+				for _, env := range []string{nw.ScharfTokenEnv, nw.GitHubTokenEnv} {
+					logging.AddSecret(os.Getenv(env))
+				}
+			}
+			logLevel, _ := cmd.Flags().GetString("log-level")
+			logFormat, _ := cmd.Flags().GetString("log-format")
+			var lvl slog.Level
+			if err := lvl.UnmarshalText([]byte(logLevel)); err != nil {
+				fmt.Printf("invalid log level %q. Available options: debug, info, warn, error\n", logLevel)
+				os.Exit(1)
+			}
+			if err := logging.SetupLogging(lvl, logFormat); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			if yamlParse, _ := cmd.Flags().GetBool("yaml-parse"); !yamlParse {
+				sc.SetYAMLParse(false)
+			}
+			if dirs, _ := cmd.Flags().GetStringSlice("workflow-dir"); len(dirs) > 0 {
+				sc.SetWorkflowDirs(dirs)
+			}
+			// Progress never ends up in piped or logged output; a spinner only reads well
+			// when the report is shown on the same terminal
+			if showProgress, _ := cmd.Flags().GetBool("progress"); showProgress && isTerminal(os.Stderr) {
+				sc.SetProgress(sc.NewProgress(os.Stderr, isTerminal(os.Stdout)))
+			}
+			cacheTTL, _ := cmd.Flags().GetString("cache-ttl")
+			if cacheTTL == "" {
+				cacheTTL = os.Getenv(actcache.TTLEnv)
+			}
+			if cacheTTL != "" {
+				ttl, err := actcache.ParseTTL(cacheTTL)
+				if err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+				nw.SetCacheTTL(ttl)
+			}
+			httpTimeout, _ := cmd.Flags().GetString("http-timeout")
+			if httpTimeout == "" {
+				httpTimeout = os.Getenv(nw.HTTPTimeoutEnv)
+			}
+			if httpTimeout != "" {
+				timeout, err := nw.ParseHTTPTimeout(httpTimeout)
+				if err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+				nw.SetHTTPTimeout(timeout)
+			}
+			caCert, _ := cmd.Flags().GetString("ca-cert")
+			if caCert == "" {
+				caCert = os.Getenv(nw.CACertEnv)
+			}
+			if caCert != "" {
+				if err := nw.SetCACert(caCert); err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+			}
+			if proxy, _ := cmd.Flags().GetString("proxy"); proxy != "" {
+				if err := nw.SetProxy(proxy); err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+			}
+			// Beneath the API cache, so revalidations count against the limits too
+			if values, _ := cmd.Flags().GetStringSlice("resolve-concurrency-per-host"); len(values) > 0 {
+				limits, err := nw.ParseHostLimits(values)
+				if err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+				nw.LimitConcurrencyPerHost(limits)
+			}
+			if dir, _ := cmd.Flags().GetString("api-cache-dir"); dir != "" {
+				if err := nw.EnableAPICache(dir); err != nil {
+					fmt.Println(err.Error())
+					os.Exit(1)
+				}
+			}
+		},
+	}
+	rootCmd.PersistentFlags().String("log-level", "info", "Log level. Available options: debug, info, warn, error")
+	rootCmd.PersistentFlags().String("log-format", logging.FormatText, "Log format. Available options: text, json")
+	rootCmd.PersistentFlags().String("cache-ttl", "", fmt.Sprintf("How long cached SHAs are trusted before they are resolved again, Ex: 48h or 14d. 0 disables expiry. Defaults to $%s or 7d", actcache.TTLEnv))
+	rootCmd.PersistentFlags().Bool("progress", false, "Show each action reference as it is resolved on stderr, Ex: [3/42] resolving actions/checkout@v4. Ignored when stderr isn't a terminal")
+	rootCmd.PersistentFlags().Bool("cache-read-only", false, "Read resolved SHAs from the cache but never write new entries to it")
+	rootCmd.PersistentFlags().String("resolver", nw.ResolverAPI, "How references are resolved to SHAs. Available options: api (GitHub REST API), git (git ls-remote against github.com, not rate limited and needs no token for public repositories)")
+	rootCmd.PersistentFlags().String("resolver-cmd", "", "Resolve references with this program instead of the GitHub API. It reads owner/repo@ref on stdin and prints the commit SHA, Ex: ./my-resolver")
+	rootCmd.PersistentFlags().String("ca-cert", "", fmt.Sprintf("PEM file of extra root CAs to trust, Ex: of a TLS-inspecting proxy. Defaults to $%s", nw.CACertEnv))
+	rootCmd.PersistentFlags().String("http-timeout", "", fmt.Sprintf("Give up on a GitHub API call after this long, Ex: 30s or 2m. 0 disables the timeout. Defaults to $%s or %s", nw.HTTPTimeoutEnv, nw.DefaultHTTPTimeout))
+	rootCmd.PersistentFlags().String("proxy", "", "HTTP proxy for GitHub API requests, Ex: http://proxy.internal:3128. Defaults to $HTTPS_PROXY")
+	rootCmd.PersistentFlags().StringSlice("resolve-concurrency-per-host", nil, "Cap concurrent requests per host as host=N (repeatable), Ex: api.github.com=8,ghe.internal=2. The host * limits every other host")
+	rootCmd.PersistentFlags().String("api-cache-dir", "", "Cache GitHub API responses in this directory and revalidate them with their ETag")
+	rootCmd.PersistentFlags().Bool("redact-token", true, "Replace the GitHub token ($SCHARF_TOKEN, $GITHUB_TOKEN) with *** in logs, resolution errors and the resolution log")
+	rootCmd.PersistentFlags().String("resolution-log", "", "Write a JSON log of every SHA resolution attempted during the run to this file")
+	rootCmd.PersistentFlags().Bool("yaml-parse", true, "Find action references by parsing workflows as YAML (jobs.*.uses and jobs.*.steps[].uses). Set to false to scan raw lines with a regex")
+	rootCmd.PersistentFlags().StringSlice("workflow-dir", nil, fmt.Sprintf("Workflow directories relative to the repository root (repeatable). Defaults to $%s (colon-separated) or %s", sc.WorkflowDirEnv, sc.DefaultWorkflowDir))
+	rootCmd.AddCommand(cmdLookup, cmdFind, cmdList, cmdAudit, cmdAutoFix, cmdResolveFile, cmdPin, cmdUnpin, cmdAnnotate, cmdReverse, cmdUpgrade, cmdUpgradeAllSHA, cmdDiffPins, cmdVerify, cmdCache)
 	rootCmd.Execute()
 }
@@ -0,0 +1,112 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	nw "github.com/cybrota/scharf/network"
+	sc "github.com/cybrota/scharf/scanner"
+)
+
+var testInventory = &sc.Inventory{Records: []*sc.InventoryRecord{
+	{Repository: "repo", Branch: "main", FilePath: ".github/workflows/ci.yml", Matches: []string{"actions/checkout@v4"}},
+}}
+
+func TestWriteFindingsToUnwritablePath(t *testing.T) {
+	// A file can't be created below a path that is itself a regular file
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatalf("writing blocker: %v", err)
+	}
+	output := filepath.Join(blocker, "findings")
+
+	if err := writeToJSON(testInventory, output, false); err == nil {
+		t.Error("writeToJSON: expected an error for an unwritable path")
+	}
+	if err := WriteToCSV(testInventory, output); err == nil {
+		t.Error("WriteToCSV: expected an error for an unwritable path")
+	}
+}
+
+func TestWriteFindings(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "out.json")
+	if err := writeToJSON(testInventory, jsonPath, true); err != nil {
+		t.Fatalf("writeToJSON returned error: %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil || !strings.Contains(string(data), `"matches":["actions/checkout@v4"]`) {
+		t.Fatalf("unexpected JSON output %q, %v", data, err)
+	}
+
+	csvPath := filepath.Join(dir, "out.csv")
+	if err := WriteToCSV(testInventory, csvPath); err != nil {
+		t.Fatalf("WriteToCSV returned error: %v", err)
+	}
+	data, err = os.ReadFile(csvPath)
+	if err != nil || !strings.HasSuffix(string(data), "repo,main,.github/workflows/ci.yml,actions/checkout@v4\n") {
+		t.Fatalf("unexpected CSV output %q, %v", data, err)
+	}
+}
+
+func TestListRowsTagsAndBranches(t *testing.T) {
+	bodies := map[string]string{
+		"/repos/actions/checkout/tags":     `[{"name":"v4","commit":{"sha":"sha-v4"}},{"name":"v4.2.2","commit":{"sha":"sha-v4.2.2"}}]`,
+		"/repos/actions/checkout/branches": `[{"name":"main","commit":{"sha":"sha-main"}},{"name":"releases/v4","commit":{"sha":"sha-rel"}}]`,
+	}
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request: %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+	t.Setenv(nw.APIURLEnv, server.URL)
+
+	rows, err := listRows("actions/checkout", "all", "desc", 0)
+	if err != nil {
+		t.Fatalf("listRows returned error: %v", err)
+	}
+	want := [][]string{
+		{"v4.2.2", "sha-v4.2.2", "tag"},
+		{"v4", "sha-v4", "tag"},
+		{"main", "sha-main", "branch"},
+		{"releases/v4", "sha-rel", "branch"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("listRows = %v; want %v", rows, want)
+	}
+
+	clear(requests)
+	if rows, err := listRows("actions/checkout", "branches", "", 1); err != nil || len(rows) != 1 || rows[0][2] != "branch" {
+		t.Fatalf("listRows(branches) = %v, %v; want only the first branch", rows, err)
+	}
+	if n := requests["/repos/actions/checkout/tags"]; n != 0 {
+		t.Fatalf("listRows(branches) listed the tags %d times; want never", n)
+	}
+	if _, err := listRows("actions/checkout", "heads", "", 0); err == nil {
+		t.Fatal("expected an error for unknown refs")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var commandSHARegex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// CommandResolver resolves references with an external program, Ex: for
+// internal registries scharf can't reach. The program gets owner/repo@ref on
+// stdin and must print the commit SHA on stdout. A non-zero exit status
+// reports a failed resolution; its stderr becomes the error message.
+type CommandResolver struct {
+	// Command is the program followed by its arguments, Ex: ./my-resolver --org acme
+	Command []string
+}
+
+// NewCommandResolver splits a command line on whitespace into a CommandResolver
+func NewCommandResolver(command string) (*CommandResolver, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("config error: empty resolver command")
+	}
+
+	return &CommandResolver{Command: fields}, nil
+}
+
+// Resolve runs the resolver command for action and returns the SHA it printed
+func (c *CommandResolver) Resolve(action string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(c.Command[0], c.Command[1:]...)
+	cmd.Stdin = strings.NewReader(action + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("resolver command: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("resolver command: %w", err)
+	}
+
+	sha := strings.TrimSpace(stdout.String())
+	if !commandSHARegex.MatchString(sha) {
+		return "", fmt.Errorf("resolver command: printed %q for %s; want a 40 character commit SHA", sha, action)
+	}
+
+	return strings.ToLower(sha), nil
+}
+
+// Source names the command resolver in the resolution log
+func (c *CommandResolver) Source() string {
+	return ResolutionSourceCommand
+}
+
+// Endpoint returns the command line, whatever the action
+func (c *CommandResolver) Endpoint(string) string {
+	return strings.Join(c.Command, " ")
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const fakeResolverScript = `#!/bin/sh
+read ref
+echo "$ref" >> "$(dirname "$0")/calls"
+case "$ref" in
+  internal/deploy@v1) echo 1111111111111111111111111111111111111111 ;;
+  internal/garbage@v1) echo not-a-sha ;;
+  *) echo "unknown reference $ref" >&2; exit 3 ;;
+esac
+`
+
+// writeFakeResolver writes an executable resolver script and returns its path
+func writeFakeResolver(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake resolver is a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "resolver")
+	if err := os.WriteFile(path, []byte(fakeResolverScript), 0o755); err != nil {
+		t.Fatalf("writing fake resolver: %v", err)
+	}
+	return path
+}
+
+func TestCommandResolver_Resolve(t *testing.T) {
+	res, err := NewCommandResolver(writeFakeResolver(t))
+	if err != nil {
+		t.Fatalf("NewCommandResolver returned error: %v", err)
+	}
+
+	sha, err := res.Resolve("internal/deploy@v1")
+	if err != nil || sha != strings.Repeat("1", 40) {
+		t.Fatalf("Resolve = %q, %v; want the SHA printed by the command", sha, err)
+	}
+
+	if _, err := res.Resolve("internal/missing@v1"); err == nil || !strings.Contains(err.Error(), "unknown reference internal/missing@v1") {
+		t.Fatalf("expected the command's stderr in the error, got %v", err)
+	}
+	if _, err := res.Resolve("internal/garbage@v1"); err == nil || !strings.Contains(err.Error(), "not-a-sha") {
+		t.Fatalf("expected an error for output that is not a SHA, got %v", err)
+	}
+}
+
+func TestNewCommandResolver_Empty(t *testing.T) {
+	if _, err := NewCommandResolver("  "); err == nil {
+		t.Fatal("expected an error for an empty command")
+	}
+}
+
+func TestSHAResolver_ResolveWithExternal(t *testing.T) {
+	script := writeFakeResolver(t)
+	external, err := NewCommandResolver(script)
+	if err != nil {
+		t.Fatalf("NewCommandResolver returned error: %v", err)
+	}
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected GitHub request: %s", req.URL.String())
+		return nil, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}, External: external}
+		for range 2 {
+			if sha, err := resolver.Resolve("internal/deploy@v1"); err != nil || sha != strings.Repeat("1", 40) {
+				t.Fatalf("Resolve = %q, %v", sha, err)
+			}
+		}
+
+		resolutions := resolver.Resolutions()
+		if len(resolutions) != 2 || resolutions[0].Source != ResolutionSourceCommand || resolutions[0].Endpoint != script {
+			t.Fatalf("unexpected resolutions: %+v", resolutions)
+		}
+		if resolutions[1].Source != ResolutionSourceCache {
+			t.Fatalf("expected the repeated reference to be served from memory: %+v", resolutions[1])
+		}
+	})
+
+	calls, err := os.ReadFile(filepath.Join(filepath.Dir(script), "calls"))
+	if err != nil {
+		t.Fatalf("reading calls: %v", err)
+	}
+	if got := strings.Count(string(calls), "\n"); got != 1 {
+		t.Fatalf("resolver command ran %d times; want 1", got)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CommitExists reports whether the commit sha is reachable in the action
+// repository: on its default branch or at one of its tags. A pin to a commit
+// that was force-pushed away is answered with 404 and is dangling. So is a pin
+// to a commit that only lives on a fork or a pull request branch: GitHub serves
+// those from the parent repository too, but they can disappear at any time.
+func (s *SHAResolver) CommitExists(action string, sha string) (bool, error) {
+	repo := escapeAction(actionRepository(action))
+	lookupURL := fmt.Sprintf("%s/%s/commits/%s", reposURL(s.APIURL), repo, url.PathEscape(sha))
+	found, err := s.getAPIJSON(lookupURL, nil)
+	if err != nil || !found {
+		return false, err
+	}
+
+	var repository struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if _, err := s.getAPIJSON(fmt.Sprintf("%s/%s", reposURL(s.APIURL), repo), &repository); err != nil {
+		return false, err
+	}
+
+	// Comparing the pin with the default branch tells whether the branch contains it.
+	// Commits without a common ancestor are answered with 404.
+	var comparison struct {
+		Status string `json:"status"`
+	}
+	compareURL := fmt.Sprintf("%s/%s/compare/%s...%s", reposURL(s.APIURL), repo, url.PathEscape(sha), url.PathEscape(repository.DefaultBranch))
+	found, err = s.getAPIJSON(compareURL, &comparison)
+	if err != nil {
+		return false, err
+	}
+	if found && (comparison.Status == "ahead" || comparison.Status == "identical") {
+		return true, nil
+	}
+
+	// Releases of older majors are often tagged on maintenance branches, Ex: releases/v1
+	tags, err := s.TagsAt(action, sha)
+	if err != nil {
+		return false, err
+	}
+	return len(tags) > 0, nil
+}
+
+// getAPIJSON gets lookupURL from the GitHub API and decodes its body into v,
+// unless v is nil. A 404, or a 422 as GitHub answers for a SHA that is no
+// commit of the repository, is reported as not found.
+func (s *SHAResolver) getAPIJSON(lookupURL string, v any) (bool, error) {
+	resp, err := githubAPIGet(s.httpClient(), lookupURL)
+	if err != nil {
+		return false, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := rateLimitError(resp); err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity {
+		return false, nil
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return false, fmt.Errorf("http status %d for %s", resp.StatusCode, lookupURL)
+	}
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return false, fmt.Errorf("json: %w", err)
+		}
+	}
+	return true, nil
+}
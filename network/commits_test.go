@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSHAResolver_CommitExists(t *testing.T) {
+	tests := []struct {
+		name          string
+		commitStatus  int
+		compareStatus int
+		comparison    string
+		tagged        bool
+		want          bool
+		wantErr       bool
+	}{
+		{name: "commit on the default branch", commitStatus: http.StatusOK, compareStatus: http.StatusOK, comparison: "ahead", want: true},
+		{name: "head of the default branch", commitStatus: http.StatusOK, compareStatus: http.StatusOK, comparison: "identical", want: true},
+		{name: "tagged commit off the default branch", commitStatus: http.StatusOK, compareStatus: http.StatusOK, comparison: "diverged", tagged: true, want: true},
+		{name: "fork or pull request commit", commitStatus: http.StatusOK, compareStatus: http.StatusOK, comparison: "diverged", want: false},
+		{name: "no common ancestor", commitStatus: http.StatusOK, compareStatus: http.StatusNotFound, want: false},
+		{name: "dangling commit", commitStatus: http.StatusNotFound, want: false},
+		{name: "sha of another repository", commitStatus: http.StatusUnprocessableEntity, want: false},
+		{name: "server error", commitStatus: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				switch req.URL.Path {
+				case "/repos/owner/repo/commits/abc1234":
+					return statusResponse(tt.commitStatus, []byte(`{}`)), nil
+				case "/repos/owner/repo":
+					return jsonResponse(t, http.StatusOK, map[string]string{"default_branch": "main"}), nil
+				case "/repos/owner/repo/compare/abc1234...main":
+					return jsonResponse(t, tt.compareStatus, map[string]string{"status": tt.comparison}), nil
+				case "/repos/owner/repo/tags":
+					tags := []BranchOrTag{{Name: "v1.0.0", Commit: Commit{Sha: "def4567"}}}
+					if tt.tagged {
+						tags = append(tags, BranchOrTag{Name: "v0.9.0", Commit: Commit{Sha: "abc1234"}})
+					}
+					return jsonResponse(t, http.StatusOK, tags), nil
+				}
+				t.Fatalf("unexpected request: %s", req.URL.String())
+				return nil, nil
+			})
+
+			withHTTPClientTransport(customTransport, func() {
+				resolver := SHAResolver{}
+				got, err := resolver.CommitExists("owner/repo", "abc1234")
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("err = %v; wantErr %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("CommitExists = %v; want %v", got, tt.want)
+				}
+			})
+		})
+	}
+}
+
+func TestSHAResolver_CommitExists_RateLimited(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := statusResponse(http.StatusForbidden, []byte(`{"message":"API rate limit exceeded"}`))
+		resp.Header.Set("X-RateLimit-Remaining", "0")
+		return resp, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{}
+		if _, err := resolver.CommitExists("owner/repo", "abc1234"); !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("expected ErrRateLimited, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cybrota/scharf/git"
+)
+
+const (
+	// ResolverAPI resolves references with the GitHub REST API
+	ResolverAPI = "api"
+	// ResolverGit resolves references with the git protocol, like git ls-remote
+	ResolverGit = "git"
+
+	// DefaultGitBaseURL is the host action repositories are listed from
+	DefaultGitBaseURL = "https://github.com"
+)
+
+// listRemoteRefs is swapped in tests to count remote listings
+var listRemoteRefs = git.ListRemoteRefs
+
+// GitResolver resolves references by listing the refs of the action repository
+// over the git protocol, like git ls-remote. It isn't subject to GitHub API rate
+// limits, works for any git host and needs no token for public repositories.
+type GitResolver struct {
+	// BaseURL is prefixed to owner/repo to get the remote, Ex: https://github.com
+	BaseURL string
+
+	mu   sync.Mutex
+	refs map[string]*remoteListing // remote URL -> its listing
+}
+
+// remoteListing is the listing of the refs of a remote, done once. Lookups of a
+// remote being listed wait on done.
+type remoteListing struct {
+	done chan struct{}
+	refs map[string]string // ref name -> SHA
+	err  error
+}
+
+// NewGitResolver returns a GitResolver listing repositories on GitHub
+func NewGitResolver() *GitResolver {
+	return &GitResolver{BaseURL: DefaultGitBaseURL}
+}
+
+// ParseResolver validates the name of a resolver mode, Ex: of the --resolver flag
+func ParseResolver(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case ResolverAPI, "":
+		return ResolverAPI, nil
+	case ResolverGit:
+		return ResolverGit, nil
+	}
+
+	return "", fmt.Errorf("config error: unknown resolver %q. Available options: %s, %s", name, ResolverAPI, ResolverGit)
+}
+
+// Source names the git resolver in the resolution log
+func (g *GitResolver) Source() string {
+	return ResolutionSourceGit
+}
+
+// Endpoint returns the remote URL of the action repository
+func (g *GitResolver) Endpoint(action string) string {
+	splits, err := splitRawAction(action)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(g.BaseURL, "/") + "/" + actionRepository(splits[0])
+}
+
+// remoteRefs lists the refs of a remote once per run. Remotes are listed
+// concurrently; lookups of a remote being listed wait for its listing. A failed
+// listing is retried by the next lookup.
+func (g *GitResolver) remoteRefs(url string) (map[string]string, error) {
+	g.mu.Lock()
+	if l, ok := g.refs[url]; ok {
+		g.mu.Unlock()
+		<-l.done
+		return l.refs, l.err
+	}
+
+	l := &remoteListing{done: make(chan struct{})}
+	if g.refs == nil {
+		g.refs = make(map[string]*remoteListing)
+	}
+	g.refs[url] = l
+	g.mu.Unlock()
+
+	l.refs, l.err = listRemoteRefs(url)
+	if l.err != nil {
+		g.mu.Lock()
+		delete(g.refs, url)
+		g.mu.Unlock()
+	}
+	close(l.done)
+	return l.refs, l.err
+}
+
+// Resolve returns the commit SHA of a tag or branch. Like the API lookup, tags
+// win over branches of the same name, a partial version (Ex: v4.2) resolves
+// to its latest patch tag and no version to the latest release tag.
+func (g *GitResolver) Resolve(action string) (string, error) {
+	splits, err := splitRawAction(action)
+	if err != nil {
+		return "", fmt.Errorf("parse: %w", err)
+	}
+	version := decodeVersion(splits[1])
+
+	refs, err := g.remoteRefs(g.Endpoint(action))
+	if err != nil {
+		return "", err
+	}
+
+	if sha, ok := refs["refs/tags/"+version]; ok && version != "" {
+		return sha, nil
+	}
+
+	var tags []BranchOrTag
+	for name, sha := range refs {
+		if tag, ok := strings.CutPrefix(name, "refs/tags/"); ok {
+			tags = append(tags, BranchOrTag{Name: tag, Commit: Commit{Sha: sha}})
+		}
+	}
+	if version == "" {
+		if found, sha, _ := searchLatestTag(tags); found {
+			return sha, nil
+		}
+		return "", fmt.Errorf("no release tag is found for action: %s", actionRepository(splits[0]))
+	}
+	if found, sha, _ := searchLatestPatch(tags, version); found {
+		return sha, nil
+	}
+
+	if sha, ok := refs["refs/heads/"+version]; ok {
+		return sha, nil
+	}
+
+	return "", fmt.Errorf("given version: %s is not found for action: %s", version, actionRepository(splits[0]))
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gitlib "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// bareRemote pushes a repository with one commit, the given tags and a main
+// branch to a bare repository at base/owner/repo, and returns the commit SHA
+func bareRemote(t *testing.T, base string, repo string, tags ...string) string {
+	t.Helper()
+	src := t.TempDir()
+	r, err := gitlib.PlainInit(src, false)
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "action.yml"), []byte("name: test\n"), 0o644); err != nil {
+		t.Fatalf("writing action.yml: %v", err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatalf("worktree: %v", err)
+	}
+	if _, err := w.Add("action.yml"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	hash, err := w.Commit("release", &gitlib.CommitOptions{
+		Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), hash)); err != nil {
+		t.Fatalf("branch: %v", err)
+	}
+	for _, tag := range tags {
+		if _, err := r.CreateTag(tag, hash, nil); err != nil {
+			t.Fatalf("tag %s: %v", tag, err)
+		}
+	}
+
+	bare := filepath.Join(base, filepath.FromSlash(repo))
+	if _, err := gitlib.PlainInit(bare, true); err != nil {
+		t.Fatalf("init bare: %v", err)
+	}
+	if _, err := r.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{bare}}); err != nil {
+		t.Fatalf("remote: %v", err)
+	}
+	if err := r.Push(&gitlib.PushOptions{RemoteName: "origin", RefSpecs: []gitconfig.RefSpec{"refs/*:refs/*"}}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	return hash.String()
+}
+
+func TestGitResolver_Resolve(t *testing.T) {
+	base := t.TempDir()
+	sha := bareRemote(t, base, "actions/checkout", "v4", "v4.2.3", "v4.2.10")
+	res := &GitResolver{BaseURL: base}
+
+	for _, ref := range []string{"actions/checkout@v4", "actions/checkout@v4.2", "actions/checkout@main", "actions/checkout/sub/path@v4"} {
+		got, err := res.Resolve(ref)
+		if err != nil || got != sha {
+			t.Errorf("Resolve(%q) = %q, %v; want %s", ref, got, err, sha)
+		}
+	}
+
+	if _, err := res.Resolve("actions/checkout@v9"); err == nil {
+		t.Error("expected an error for a missing tag")
+	}
+	if _, err := res.Resolve("actions/missing@v1"); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+}
+
+func TestGitResolver_ListsEachRemoteOnce(t *testing.T) {
+	base := t.TempDir()
+	bareRemote(t, base, "actions/checkout", "v3", "v4")
+
+	calls := 0
+	orig := listRemoteRefs
+	listRemoteRefs = func(url string) (map[string]string, error) {
+		calls++
+		return orig(url)
+	}
+	t.Cleanup(func() { listRemoteRefs = orig })
+
+	res := &GitResolver{BaseURL: base}
+	for _, ref := range []string{"actions/checkout@v3", "actions/checkout@v4", "actions/checkout@v4"} {
+		if _, err := res.Resolve(ref); err != nil {
+			t.Fatalf("Resolve(%q) returned error: %v", ref, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("listed the remote %d times; want 1", calls)
+	}
+}
+
+func TestGitResolver_ListsRemotesConcurrently(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	calls := map[string]int{}
+	orig := listRemoteRefs
+	listRemoteRefs = func(url string) (map[string]string, error) {
+		mu.Lock()
+		calls[url]++
+		mu.Unlock()
+		if strings.HasSuffix(url, "/slow/repo") {
+			<-release
+		}
+		return map[string]string{"refs/tags/v1": "sha-" + url}, nil
+	}
+	t.Cleanup(func() { listRemoteRefs = orig })
+
+	res := &GitResolver{BaseURL: "https://git.example"}
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sha, err := res.Resolve("slow/repo@v1"); err != nil || sha != "sha-https://git.example/slow/repo" {
+				t.Errorf("Resolve(slow/repo@v1) = %q, %v", sha, err)
+			}
+		}()
+	}
+
+	// Another remote is listed while the slow one is still being listed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := res.Resolve("fast/repo@v1"); err != nil {
+			t.Errorf("Resolve(fast/repo@v1) returned error: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listing a remote waited on the listing of another")
+	}
+
+	close(release)
+	wg.Wait()
+	if n := calls["https://git.example/slow/repo"]; n != 1 {
+		t.Fatalf("listed the slow remote %d times; want 1", n)
+	}
+}
+
+func TestSHAResolver_ResolveWithGitResolver(t *testing.T) {
+	base := t.TempDir()
+	sha := bareRemote(t, base, "actions/checkout", "v4")
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected GitHub API request: %s", req.URL.String())
+		return nil, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}, External: &GitResolver{BaseURL: base}}
+		if got, err := resolver.Resolve("actions/checkout@v4"); err != nil || got != sha {
+			t.Fatalf("Resolve = %q, %v; want %s", got, err, sha)
+		}
+
+		r := resolver.Resolutions()[0]
+		if r.Source != ResolutionSourceGit || r.Endpoint != base+"/actions/checkout" {
+			t.Fatalf("unexpected resolution: %+v", r)
+		}
+	})
+}
+
+func TestParseResolver(t *testing.T) {
+	if r, err := ParseResolver("Git"); err != nil || r != ResolverGit {
+		t.Fatalf("ParseResolver(Git) = %q, %v", r, err)
+	}
+	if r, err := ParseResolver(""); err != nil || r != ResolverAPI {
+		t.Fatalf("ParseResolver(\"\") = %q, %v", r, err)
+	}
+	if _, err := ParseResolver("graphql"); err == nil {
+		t.Fatal("expected an error for an unknown resolver")
+	}
+}
+
+func TestGitResolver_ResolveLatestTag(t *testing.T) {
+	orig := listRemoteRefs
+	listRemoteRefs = func(url string) (map[string]string, error) {
+		return map[string]string{
+			"refs/heads/main":       "sha-main",
+			"refs/tags/v1.0.0":      "sha-1.0.0",
+			"refs/tags/v2.1.0":      "sha-2.1.0",
+			"refs/tags/v2.0.0":      "sha-2.0.0",
+			"refs/tags/v3.0.0-rc.1": "sha-rc",
+		}, nil
+	}
+	t.Cleanup(func() { listRemoteRefs = orig })
+
+	res := &GitResolver{BaseURL: "https://github.com"}
+	if got, err := res.Resolve("actions/checkout"); err != nil || got != "sha-2.1.0" {
+		t.Fatalf("Resolve() = %q, %v; want the latest release tag sha-2.1.0", got, err)
+	}
+	if got, err := res.Resolve("actions/checkout@main"); err != nil || got != "sha-main" {
+		t.Fatalf("Resolve(@main) = %q, %v; want the branch head", got, err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AnyHost is the key of a host limit applying to every host without a limit of its own
+const AnyHost = "*"
+
+// ParseHostLimits parses per-host concurrency limits given as host=N, Ex:
+// api.github.com=8 or ghe.internal=2. The host * sets the limit of all other hosts.
+func ParseHostLimits(values []string) (map[string]int, error) {
+	limits := make(map[string]int, len(values))
+	for _, v := range values {
+		host, n, ok := strings.Cut(v, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		limit, err := strconv.Atoi(strings.TrimSpace(n))
+		if !ok || host == "" || err != nil || limit < 1 {
+			return nil, fmt.Errorf("config error: invalid host limit %q: want host=N with N at least 1, Ex: api.github.com=8", v)
+		}
+		limits[host] = limit
+	}
+
+	return limits, nil
+}
+
+// hostLimitTransport caps the number of in-flight requests per host. A request
+// holds its host's slot until its response body is closed, as the connection is
+// busy until then.
+type hostLimitTransport struct {
+	limits map[string]int // host -> max in-flight requests
+	next   http.RoundTripper
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// apiHostLimit caps the GitHub API calls of apiClient per host, once
+// LimitConcurrencyPerHost is called
+var apiHostLimit *hostLimitTransport
+
+func (t *hostLimitTransport) base() http.RoundTripper {
+	if t.next != nil {
+		return t.next
+	}
+	return belowHostLimit()
+}
+
+// slot returns the semaphore of host, or nil when host is unlimited
+func (t *hostLimitTransport) slot(host string) chan struct{} {
+	host = strings.ToLower(host)
+	limit, ok := t.limits[host]
+	if !ok {
+		limit, ok = t.limits[AnyHost]
+	}
+	if !ok {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.slots == nil {
+		t.slots = make(map[string]chan struct{})
+	}
+	sem, ok := t.slots[host]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		t.slots[host] = sem
+	}
+	return sem
+}
+
+func (t *hostLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sem := t.slot(req.URL.Hostname())
+	if sem == nil {
+		return t.base().RoundTrip(req)
+	}
+
+	select {
+	case sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-sem })
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody frees a host slot once the response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// LimitConcurrencyPerHost caps the in-flight HTTP requests of every host with a
+// limit, Ex: from ParseHostLimits, so no single endpoint is overwhelmed
+func LimitConcurrencyPerHost(limits map[string]int) {
+	if len(limits) == 0 {
+		return
+	}
+
+	apiHostLimit = &hostLimitTransport{limits: limits}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// inFlightServer records the peak number of concurrent requests per host
+type inFlightServer struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	peak     map[string]int
+}
+
+func (s *inFlightServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	s.mu.Lock()
+	s.inFlight[host]++
+	s.peak[host] = max(s.peak[host], s.inFlight[host])
+	s.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	s.mu.Lock()
+	s.inFlight[host]--
+	s.mu.Unlock()
+	return statusResponse(http.StatusOK, []byte(`{}`)), nil
+}
+
+func TestHostLimitTransport_RespectsLimits(t *testing.T) {
+	server := &inFlightServer{inFlight: map[string]int{}, peak: map[string]int{}}
+	rt := &hostLimitTransport{
+		limits: map[string]int{"api.github.com": 8, "registry.internal": 2, AnyHost: 3},
+		next:   server,
+	}
+
+	var wg sync.WaitGroup
+	for _, host := range []string{"api.github.com", "registry.internal", "ghe.internal"} {
+		for range 24 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, _ := http.NewRequest(http.MethodGet, "https://"+host+"/repos/owner/repo/tags", nil)
+				resp, err := rt.RoundTrip(req)
+				if err != nil {
+					t.Errorf("RoundTrip returned error: %v", err)
+					return
+				}
+				resp.Body.Close()
+			}()
+		}
+	}
+	wg.Wait()
+
+	for host, limit := range map[string]int{"api.github.com": 8, "registry.internal": 2, "ghe.internal": 3} {
+		if peak := server.peak[host]; peak > limit || peak == 0 {
+			t.Errorf("peak in-flight requests to %s = %d; want at most %d", host, peak, limit)
+		}
+	}
+}
+
+func TestHostLimitTransport_HoldsSlotUntilBodyIsClosed(t *testing.T) {
+	rt := &hostLimitTransport{
+		limits: map[string]int{"api.github.com": 1},
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return statusResponse(http.StatusOK, []byte(`{}`)), nil
+		}),
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/a", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+
+	// The only slot is taken until the first body is closed
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := rt.RoundTrip(req.WithContext(ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the second request to wait for a slot, got %v", err)
+	}
+
+	resp.Body.Close()
+	resp.Body.Close() // closing twice frees the slot once
+	resp, err = rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip after close returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	// Unlimited hosts never wait
+	other, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	if _, err := rt.RoundTrip(other); err != nil {
+		t.Fatalf("RoundTrip to an unlimited host returned error: %v", err)
+	}
+}
+
+func TestParseHostLimits(t *testing.T) {
+	limits, err := ParseHostLimits([]string{"API.github.com=8", " * = 2"})
+	if err != nil || limits["api.github.com"] != 8 || limits[AnyHost] != 2 {
+		t.Fatalf("ParseHostLimits = %v, %v", limits, err)
+	}
+
+	for _, v := range []string{"api.github.com", "=3", "ghe.internal=0", "ghe.internal=x"} {
+		if _, err := ParseHostLimits([]string{v}); err == nil {
+			t.Errorf("expected an error for %q", v)
+		}
+	}
+}
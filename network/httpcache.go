@@ -0,0 +1,157 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// cachedResponse is the on-disk form of a GitHub API response
+type cachedResponse struct {
+	URL    string      `json:"url"`
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// cachingTransport keeps successful GET responses on disk, keyed by URL, and
+// revalidates them with their ETag. GitHub answers a matching If-None-Match with
+// 304 Not Modified, which doesn't count against the API rate limit.
+type cachingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// apiCache keeps the GitHub API responses of apiClient, once EnableAPICache is called
+var apiCache *cachingTransport
+
+func (t *cachingTransport) base() http.RoundTripper {
+	if t.next != nil {
+		return t.next
+	}
+	return apiTransport
+}
+
+func (t *cachingTransport) entryPath(lookupURL string) string {
+	sum := sha256.Sum256([]byte(lookupURL))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base().RoundTrip(req)
+	}
+
+	path := t.entryPath(req.URL.String())
+	cached, ok := readCachedResponse(path, req.URL.String())
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return cached.response(req), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// A failed write only costs a cache miss on the next run
+	_ = writeCachedResponse(path, cachedResponse{URL: req.URL.String(), ETag: etag, Header: resp.Header, Body: body})
+	return resp, nil
+}
+
+func (c cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+func readCachedResponse(path string, lookupURL string) (cachedResponse, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cachedResponse{}, false
+	}
+
+	var c cachedResponse
+	if err := json.Unmarshal(data, &c); err != nil || c.URL != lookupURL || c.ETag == "" {
+		return cachedResponse{}, false
+	}
+
+	return c, true
+}
+
+func writeCachedResponse(path string, c cachedResponse) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+
+	// Write to a temp file first so a concurrent reader never sees half an entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("os: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("os: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("os: %w", err)
+	}
+
+	return nil
+}
+
+// EnableAPICache keeps GitHub API responses in dir and revalidates them with
+// their ETag on later runs
+func EnableAPICache(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+
+	apiCache = &cachingTransport{dir: dir}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+// etagServer answers with body and etag, or 304 when the request revalidates etag
+type etagServer struct {
+	etag        string
+	body        string
+	calls       int
+	notModified int
+	ifNoneMatch string
+}
+
+func (s *etagServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	s.ifNoneMatch = req.Header.Get("If-None-Match")
+	if s.etag != "" && s.ifNoneMatch == s.etag {
+		s.notModified++
+		return statusResponse(http.StatusNotModified, nil), nil
+	}
+
+	resp := statusResponse(http.StatusOK, []byte(s.body))
+	if s.etag != "" {
+		resp.Header.Set("ETag", s.etag)
+	}
+	return resp, nil
+}
+
+func getBody(t *testing.T, rt http.RoundTripper, lookupURL string) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, lookupURL, nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d; want 200", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return string(data)
+}
+
+func TestCachingTransport_HitRevalidatesWithETag(t *testing.T) {
+	server := &etagServer{etag: `"abc"`, body: `[{"name":"v4"}]`}
+	rt := &cachingTransport{dir: t.TempDir(), next: server}
+	lookupURL := "https://api.github.com/repos/actions/checkout/tags"
+
+	if got := getBody(t, rt, lookupURL); got != server.body {
+		t.Fatalf("first body = %q; want %q", got, server.body)
+	}
+	if server.ifNoneMatch != "" {
+		t.Fatalf("first request sent If-None-Match %q; want none", server.ifNoneMatch)
+	}
+
+	// The origin still answers, but only with 304 and no body
+	server.body = "should not be read"
+	if got := getBody(t, rt, lookupURL); got != `[{"name":"v4"}]` {
+		t.Fatalf("cached body = %q", got)
+	}
+	if server.notModified != 1 {
+		t.Fatalf("notModified = %d; want 1", server.notModified)
+	}
+}
+
+func TestCachingTransport_Miss(t *testing.T) {
+	t.Run("changed ETag refreshes the entry", func(t *testing.T) {
+		server := &etagServer{etag: `"v1"`, body: "old"}
+		rt := &cachingTransport{dir: t.TempDir(), next: server}
+		lookupURL := "https://api.github.com/repos/actions/checkout/tags"
+
+		getBody(t, rt, lookupURL)
+		server.etag, server.body = `"v2"`, "new"
+		if got := getBody(t, rt, lookupURL); got != "new" {
+			t.Fatalf("body = %q; want new", got)
+		}
+		if got := getBody(t, rt, lookupURL); got != "new" || server.notModified != 1 {
+			t.Fatalf("body = %q, notModified = %d; want new, 1", got, server.notModified)
+		}
+	})
+
+	t.Run("other URLs are not served from the cache", func(t *testing.T) {
+		server := &etagServer{etag: `"abc"`, body: "checkout"}
+		rt := &cachingTransport{dir: t.TempDir(), next: server}
+
+		getBody(t, rt, "https://api.github.com/repos/actions/checkout/tags")
+		getBody(t, rt, "https://api.github.com/repos/actions/setup-go/tags")
+		if server.ifNoneMatch != "" || server.notModified != 0 {
+			t.Fatalf("expected a cache miss for a different URL")
+		}
+	})
+
+	t.Run("responses without ETag are not cached", func(t *testing.T) {
+		server := &etagServer{body: "tags"}
+		rt := &cachingTransport{dir: t.TempDir(), next: server}
+		lookupURL := "https://api.github.com/repos/actions/checkout/tags"
+
+		getBody(t, rt, lookupURL)
+		getBody(t, rt, lookupURL)
+		if server.ifNoneMatch != "" || server.calls != 2 {
+			t.Fatalf("calls = %d, If-None-Match = %q; want 2 plain requests", server.calls, server.ifNoneMatch)
+		}
+	})
+}
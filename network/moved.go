@@ -0,0 +1,121 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const maxRedirects = 5
+
+var reposPathRegex = regexp.MustCompile(`/repos/([^/]+/[^/]+)(?:/|$)`)
+var repositoriesPathRegex = regexp.MustCompile(`^(.*)/repositories/(\d+)(?:/|$)`)
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
+
+// githubAPIGetTrackingMoves performs a GET like githubAPIGet, but follows redirects
+// itself. GitHub answers with a redirect when a repository is renamed or
+// transferred, so the new owner/repo is returned to let callers report stale references.
+func githubAPIGetTrackingMoves(client *http.Client, lookupURL string) (*http.Response, string, error) {
+	noRedirects := &http.Client{
+		Transport: client.Transport,
+		Timeout:   client.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	origin, err := url.Parse(lookupURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("request: %w", err)
+	}
+
+	movedTo := ""
+	for i := 0; i <= maxRedirects; i++ {
+		req, err := newAPIRequest(lookupURL)
+		if err != nil {
+			return nil, "", err
+		}
+		// Never leak the token to a different host
+		if req.URL.Host != origin.Host {
+			req.Header.Del("Authorization")
+		}
+
+		resp, err := noRedirects.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		if !isRedirect(resp.StatusCode) {
+			return resp, movedTo, nil
+		}
+
+		loc, err := resp.Location()
+		resp.Body.Close()
+		if err != nil {
+			return nil, "", fmt.Errorf("redirect without location: %w", err)
+		}
+
+		if movedTo == "" {
+			movedTo = movedActionFromLocation(client, loc)
+		}
+		lookupURL = loc.String()
+	}
+
+	return nil, "", errors.New("too many redirects")
+}
+
+// movedActionFromLocation extracts the new owner/repo from a redirect location.
+// GitHub may redirect either to /repos/<owner>/<repo>/... or to /repositories/<id>/...,
+// in which case the repository is looked up to learn its current full name.
+func movedActionFromLocation(client *http.Client, loc *url.URL) string {
+	if m := reposPathRegex.FindStringSubmatch(loc.Path); m != nil {
+		return m[1]
+	}
+
+	m := repositoriesPathRegex.FindStringSubmatch(loc.Path)
+	if m == nil {
+		return ""
+	}
+
+	repoURL := fmt.Sprintf("%s://%s%s/repositories/%s", loc.Scheme, loc.Host, m[1], m[2])
+	resp, err := githubAPIGet(client, repoURL)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		FullName string `json:"full_name"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&payload) != nil {
+		return ""
+	}
+
+	return payload.FullName
+}
+
+// MovedTo reports the new owner/repo of an action whose repository was
+// renamed or transferred, as detected while resolving it
+func (s *SHAResolver) MovedTo(action string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	to, ok := s.moved[strings.ToLower(action)]
+	return to, ok
+}
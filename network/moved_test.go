@@ -0,0 +1,176 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cybrota/scharf/actcache"
+)
+
+func jsonResponse(t *testing.T, status int, v any) *http.Response {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewReader(b)),
+		Header:     make(http.Header),
+	}
+}
+
+func redirectResponse(location string) *http.Response {
+	h := make(http.Header)
+	h.Set("Location", location)
+	return &http.Response{
+		StatusCode: http.StatusMovedPermanently,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Header:     h,
+	}
+}
+
+func TestSHAResolver_Resolve_DetectsMovedRepository(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.String() {
+		case "https://api.github.com/repos/old/name/tags?per_page=100":
+			return redirectResponse("https://api.github.com/repos/new/name/tags?per_page=100"), nil
+		case "https://api.github.com/repos/new/name/tags?per_page=100":
+			return jsonResponse(t, http.StatusOK, []BranchOrTag{{Name: "v1", Commit: Commit{Sha: "sha-new"}}}), nil
+		}
+		return nil, fmt.Errorf("unexpected URL: %s", req.URL.String())
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		sha, err := resolver.Resolve("old/name@v1")
+		if err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+		if sha != "sha-new" {
+			t.Fatalf("Resolve() = %q; want %q", sha, "sha-new")
+		}
+
+		to, moved := resolver.MovedTo("old/name")
+		if !moved || to != "new/name" {
+			t.Fatalf("MovedTo() = (%q, %v); want (new/name, true)", to, moved)
+		}
+	})
+
+	// The move is persisted with the SHA, so the next run knows it from the cache
+	if to, moved := NewSHAResolver().MovedTo("old/name"); !moved || to != "new/name" {
+		t.Fatalf("MovedTo() after reloading the cache = (%q, %v); want (new/name, true)", to, moved)
+	}
+}
+
+func TestSHAResolver_Resolve_ReportsMoveFromCache(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	if err := actcache.UpdateMovedCacheEntry(scharfDir, "old/name@v1", "sha-new", "new/name"); err != nil {
+		t.Fatalf("UpdateMovedCacheEntry returned error: %v", err)
+	}
+
+	withHTTPClientTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected request: %s", req.URL.String())
+		return nil, nil
+	}), func() {
+		resolver := NewSHAResolver()
+		if sha, err := resolver.Resolve("Old/Name@v1"); err != nil || sha != "sha-new" {
+			t.Fatalf("Resolve() = %q, %v; want the cached sha-new", sha, err)
+		}
+		if to, moved := resolver.MovedTo("Old/Name"); !moved || to != "new/name" {
+			t.Fatalf("MovedTo() = (%q, %v); want (new/name, true)", to, moved)
+		}
+	})
+}
+
+func TestSHAResolver_Resolve_DetectsMovedRepositoryByID(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.String() {
+		case "https://api.github.com/repos/old/name/tags?per_page=100":
+			return redirectResponse("https://api.github.com/repositories/42/tags?per_page=100"), nil
+		case "https://api.github.com/repositories/42/tags?per_page=100":
+			return jsonResponse(t, http.StatusOK, []BranchOrTag{{Name: "v1", Commit: Commit{Sha: "sha-new"}}}), nil
+		case "https://api.github.com/repositories/42":
+			return jsonResponse(t, http.StatusOK, map[string]string{"full_name": "new/name"}), nil
+		}
+		return nil, fmt.Errorf("unexpected URL: %s", req.URL.String())
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		if _, err := resolver.Resolve("old/name@v1"); err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+
+		to, moved := resolver.MovedTo("old/name")
+		if !moved || to != "new/name" {
+			t.Fatalf("MovedTo() = (%q, %v); want (new/name, true)", to, moved)
+		}
+	})
+}
+
+func TestSHAResolver_Resolve_NotMovedWithoutRedirect(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(t, http.StatusOK, []BranchOrTag{{Name: "v1", Commit: Commit{Sha: "sha"}}}), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		if _, err := resolver.Resolve("owner/repo@v1"); err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+		if to, moved := resolver.MovedTo("owner/repo"); moved {
+			t.Fatalf("MovedTo() = %q; want not moved", to)
+		}
+	})
+}
+
+func TestGithubAPIGetTrackingMoves_DropsTokenOnOtherHost(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "test-token")
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "api.github.com" {
+			return redirectResponse("https://elsewhere.example/repos/new/name/tags"), nil
+		}
+		if auth := req.Header.Get("Authorization"); auth != "" {
+			t.Fatalf("token leaked to %s", req.URL.Host)
+		}
+		return jsonResponse(t, http.StatusOK, []BranchOrTag{}), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resp, movedTo, err := githubAPIGetTrackingMoves(apiClient, "https://api.github.com/repos/old/name/tags")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if movedTo != "new/name" {
+			t.Fatalf("movedTo = %q; want new/name", movedTo)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// perPage is the largest page the list endpoints of the GitHub API serve. The
+// default of 30 makes actions with many releases take several pages.
+const perPage = 100
+
+// maxPages bounds the pages followed for one list, Ex: against a Link loop
+const maxPages = 50
+
+// linkNextRegex matches the rel="next" URL of a Link header, Ex:
+// <https://api.github.com/repositories/1/tags?per_page=100&page=2>; rel="next"
+var linkNextRegex = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="next"`)
+
+// pagedURL asks a list endpoint for its largest page
+func pagedURL(lookupURL string) string {
+	u, err := url.Parse(lookupURL)
+	if err != nil {
+		return lookupURL
+	}
+
+	q := u.Query()
+	q.Set("per_page", strconv.Itoa(perPage))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// nextPage returns the URL of the page following resp, from its Link header
+func nextPage(resp *http.Response) (string, bool) {
+	m := linkNextRegex.FindStringSubmatch(resp.Header.Get("Link"))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// decodeAllRefs decodes the refs of resp, the first page of a list, and of every
+// page following it, fetched with get. Without it, refs beyond the first page,
+// Ex: old release tags, would be reported as not found.
+func decodeAllRefs(resp *http.Response, get func(pageURL string) (*http.Response, error)) ([]BranchOrTag, error) {
+	refs, err := decodeRefs(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	next, ok := nextPage(resp)
+	for page := 1; ok; page++ {
+		if page >= maxPages {
+			return nil, fmt.Errorf("more than %d pages of refs, stopped at %s", maxPages, next)
+		}
+
+		r, err := get(next)
+		if err != nil {
+			return nil, fmt.Errorf("http: %w", err)
+		}
+		more, err := func() ([]BranchOrTag, error) {
+			defer r.Body.Close()
+
+			if err := rateLimitError(r); err != nil {
+				return nil, err
+			}
+			if r.StatusCode < http.StatusOK || r.StatusCode >= http.StatusMultipleChoices {
+				return nil, fmt.Errorf("http status %d for page %s", r.StatusCode, next)
+			}
+			return decodeRefs(r.Body)
+		}()
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, more...)
+		next, ok = nextPage(r)
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"net/http"
+	"testing"
+)
+
+// twoPageTags serves the tags of owner/repo over two pages, linked like the
+// GitHub API links them
+func twoPageTags(t *testing.T) roundTripFunc {
+	const (
+		firstPage  = "https://api.github.com/repos/owner/repo/tags?per_page=100"
+		secondPage = "https://api.github.com/repositories/1/tags?per_page=100&page=2"
+	)
+
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.String() {
+		case firstPage:
+			resp := jsonResponse(t, http.StatusOK, []BranchOrTag{
+				{Name: "v2.0.0", Commit: Commit{Sha: "sha-2.0.0"}},
+				{Name: "v1.1.0", Commit: Commit{Sha: "sha-1.1.0"}},
+			})
+			resp.Header.Set("Link", `<`+secondPage+`>; rel="next", <`+secondPage+`>; rel="last"`)
+			return resp, nil
+		case secondPage:
+			resp := jsonResponse(t, http.StatusOK, []BranchOrTag{
+				{Name: "v1.0.0", Commit: Commit{Sha: "sha-1.0.0"}},
+			})
+			resp.Header.Set("Link", `<`+firstPage+`>; rel="prev", <`+firstPage+`>; rel="first"`)
+			return resp, nil
+		}
+		t.Fatalf("unexpected request: %s", req.URL.String())
+		return nil, nil
+	})
+}
+
+func TestSHAResolver_Resolve_FollowsPages(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	withHTTPClientTransport(twoPageTags(t), func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		sha, err := resolver.Resolve("owner/repo@v1.0.0")
+		if err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+		if sha != "sha-1.0.0" {
+			t.Fatalf("sha = %q; want sha-1.0.0 from the second page", sha)
+		}
+	})
+}
+
+func TestGetRefList_FollowsPages(t *testing.T) {
+	withHTTPClientTransport(twoPageTags(t), func() {
+		tags, err := GetRefList("owner/repo")
+		if err != nil {
+			t.Fatalf("GetRefList() returned error: %v", err)
+		}
+		if len(tags) != 3 || tags[2].Name != "v1.0.0" {
+			t.Fatalf("tags = %v; want the tags of both pages", tags)
+		}
+	})
+}
+
+func TestNextPage(t *testing.T) {
+	cases := []struct {
+		link string
+		next string
+		ok   bool
+	}{
+		{"", "", false},
+		{`<https://x/tags?page=2>; rel="next", <https://x/tags?page=5>; rel="last"`, "https://x/tags?page=2", true},
+		{`<https://x/tags?page=1>; rel="prev", <https://x/tags?page=3>; rel="next"`, "https://x/tags?page=3", true},
+		{`<https://x/tags?page=1>; rel="first", <https://x/tags?page=4>; rel="prev"`, "", false},
+	}
+
+	for _, tc := range cases {
+		resp := statusResponse(http.StatusOK, nil)
+		resp.Header.Set("Link", tc.link)
+		next, ok := nextPage(resp)
+		if next != tc.next || ok != tc.ok {
+			t.Errorf("nextPage(%q) = %q, %v; want %q, %v", tc.link, next, ok, tc.next, tc.ok)
+		}
+	}
+}
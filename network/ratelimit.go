@@ -0,0 +1,61 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited matches any RateLimitedError with errors.Is
+var ErrRateLimited = errors.New("github api rate limit exceeded")
+
+// RateLimitedError reports that GitHub refused a request because the API rate
+// limit is exhausted, as opposed to the requested reference not existing
+type RateLimitedError struct {
+	Reset time.Time // when the limit resets. Zero when GitHub did not tell
+}
+
+func (e *RateLimitedError) Error() string {
+	msg := fmt.Sprintf("GitHub API rate limit exceeded. Set %s or %s to raise the limit", ScharfTokenEnv, GitHubTokenEnv)
+	if !e.Reset.IsZero() {
+		msg += fmt.Sprintf(". Limit resets at %s", e.Reset.Local().Format(time.Kitchen))
+	}
+
+	return msg
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// rateLimitError inspects a response and returns a *RateLimitedError when GitHub
+// rejected it for rate limiting, or nil otherwise
+func rateLimitError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	retryAfter := resp.Header.Get("Retry-After")
+	// A plain 403 is a permission problem; only treat it as rate limiting when GitHub says so
+	if resp.StatusCode == http.StatusForbidden && remaining != "0" && retryAfter == "" {
+		return nil
+	}
+
+	rl := &RateLimitedError{}
+	if secs, err := strconv.Atoi(retryAfter); err == nil {
+		rl.Reset = time.Now().Add(time.Duration(secs) * time.Second)
+	} else if epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(epoch, 0)
+	}
+
+	return rl
+}
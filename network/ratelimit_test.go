@@ -0,0 +1,118 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rateLimitedResponse(status int, header map[string]string) *http.Response {
+	h := make(http.Header)
+	for k, v := range header {
+		h.Set(k, v)
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"message":"API rate limit exceeded"}`))),
+		Header:     h,
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		resp      *http.Response
+		limited   bool
+		wantReset time.Time
+	}{
+		{
+			name:      "403 with exhausted quota",
+			resp:      rateLimitedResponse(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)}),
+			limited:   true,
+			wantReset: reset,
+		},
+		{
+			name:    "429 without headers",
+			resp:    rateLimitedResponse(http.StatusTooManyRequests, nil),
+			limited: true,
+		},
+		{
+			name:    "403 permission problem",
+			resp:    rateLimitedResponse(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "42"}),
+			limited: false,
+		},
+		{
+			name:    "404 not found",
+			resp:    rateLimitedResponse(http.StatusNotFound, nil),
+			limited: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rateLimitError(tt.resp)
+			if got := errors.Is(err, ErrRateLimited); got != tt.limited {
+				t.Fatalf("errors.Is(err, ErrRateLimited) = %v; want %v (err: %v)", got, tt.limited, err)
+			}
+
+			var rl *RateLimitedError
+			if tt.limited && errors.As(err, &rl) && !rl.Reset.Equal(tt.wantReset) {
+				t.Errorf("reset = %v; want %v", rl.Reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestSHAResolver_Resolve_RateLimited(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	reset := time.Now().Add(time.Hour)
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return rateLimitedResponse(http.StatusForbidden, map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+		}), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		_, err := resolver.Resolve("owner/repo@v1")
+		if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("expected ErrRateLimited, got: %v", err)
+		}
+		if strings.Contains(err.Error(), "not found") {
+			t.Fatalf("rate limiting must not be reported as a missing version, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), GitHubTokenEnv) || !strings.Contains(err.Error(), "resets at") {
+			t.Fatalf("expected token hint and reset time in error, got: %v", err)
+		}
+	})
+}
+
+func TestGetRefList_RateLimited(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return rateLimitedResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "60"}), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if _, err := GetRefList("owner/repo"); !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("expected ErrRateLimited, got: %v", err)
+		}
+	})
+}
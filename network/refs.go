@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// UnmarshalJSON reads a ref of the /tags or /branches endpoints, {"name", "commit"},
+// as well as of the git refs endpoint, {"ref", "object"}, which some GitHub
+// Enterprise Server versions answer with
+func (b *BranchOrTag) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name   string  `json:"name"`
+		Ref    string  `json:"ref"`
+		Commit *Commit `json:"commit"`
+		Object *Commit `json:"object"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	b.Name = raw.Name
+	if b.Name == "" {
+		b.Name = strings.TrimPrefix(strings.TrimPrefix(raw.Ref, "refs/tags/"), "refs/heads/")
+	}
+	b.Commit = Commit{}
+	if raw.Commit != nil {
+		b.Commit = *raw.Commit
+	} else if raw.Object != nil {
+		b.Commit = *raw.Object
+	}
+
+	return nil
+}
+
+// decodeRefs decodes a list of refs, or the single ref returned when a specific
+// branch or tag is queried, Ex: /branches/main
+func decodeRefs(r io.Reader) ([]BranchOrTag, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var ref BranchOrTag
+		if err := json.Unmarshal(trimmed, &ref); err != nil {
+			return nil, fmt.Errorf("json: %w", err)
+		}
+		return []BranchOrTag{ref}, nil
+	}
+
+	var refs []BranchOrTag
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return refs, nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRefs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body string
+		want []BranchOrTag
+	}{
+		"list": {
+			body: `[{"name":"v4","commit":{"sha":"sha-v4","url":"u"}},{"name":"v3","commit":{"sha":"sha-v3"}}]`,
+			want: []BranchOrTag{{Name: "v4", Commit: Commit{Sha: "sha-v4", URL: "u"}}, {Name: "v3", Commit: Commit{Sha: "sha-v3"}}},
+		},
+		"single branch": {
+			body: "\n  {\"name\":\"main\",\"commit\":{\"sha\":\"sha-main\"},\"protected\":true}",
+			want: []BranchOrTag{{Name: "main", Commit: Commit{Sha: "sha-main"}}},
+		},
+		"git refs list": {
+			body: `[{"ref":"refs/tags/v4","object":{"sha":"sha-tag","type":"tag"}},{"ref":"refs/heads/main","object":{"sha":"sha-main","type":"commit"}}]`,
+			want: []BranchOrTag{{Name: "v4", Commit: Commit{Sha: "sha-tag", Type: "tag"}}, {Name: "main", Commit: Commit{Sha: "sha-main", Type: "commit"}}},
+		},
+		"single git ref": {
+			body: `{"ref":"refs/heads/main","object":{"sha":"sha-main","type":"commit"}}`,
+			want: []BranchOrTag{{Name: "main", Commit: Commit{Sha: "sha-main", Type: "commit"}}},
+		},
+		"empty list": {body: `[]`, want: []BranchOrTag{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeRefs(strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("decodeRefs returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("decodeRefs = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := decodeRefs(strings.NewReader(`"not a ref"`)); err == nil || !strings.HasPrefix(err.Error(), "json: ") {
+		t.Fatalf("decodeRefs error = %v; want a json error", err)
+	}
+}
+
+func TestSHAResolver_ResolveSingleRefResponse(t *testing.T) {
+	for name, body := range map[string]string{
+		"branch":  `{"name":"main","commit":{"sha":"sha-main"}}`,
+		"git ref": `{"ref":"refs/heads/main","object":{"sha":"sha-main","type":"commit"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return statusResponse(http.StatusOK, []byte(body)), nil
+			})
+
+			withHTTPClientTransport(customTransport, func() {
+				resolver := SHAResolver{cache: map[string]string{}, APIURL: DefaultAPIURL}
+				if got, err := resolver.Resolve("actions/checkout@main"); err != nil || got != "sha-main" {
+					t.Fatalf("Resolve = %q, %v; want sha-main", got, err)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// dockerHubHost is the registry of images without a registry host, Ex: postgres:16
+	dockerHubHost = "registry-1.docker.io"
+
+	// manifestAccept asks for the multi-platform index of an image first, so the
+	// digest is the one a runner pulls on any platform
+	manifestAccept = "application/vnd.oci.image.index.v1+json, application/vnd.docker.distribution.manifest.list.v2+json, application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var (
+	digestRegex = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+	// bearerParamRegex matches a parameter of a WWW-Authenticate challenge, Ex: realm="https://auth.docker.io/token"
+	bearerParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// splitImage splits an image reference into its registry host, repository and
+// tag, Ex: ghcr.io/org/app:1 -> ghcr.io, org/app, 1. Images without a registry
+// host are on Docker Hub, official ones under library/; no tag means latest.
+func splitImage(image string) (string, string, string) {
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+
+	host, repo, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host, repo = dockerHubHost, name
+		if !strings.Contains(repo, "/") {
+			repo = "library/" + repo
+		}
+	}
+	if host == "docker.io" || host == "index.docker.io" {
+		host = dockerHubHost
+	}
+
+	return host, repo, tag
+}
+
+// ResolveImageDigest looks up the manifest digest of a container image tag on
+// its registry, Ex: postgres:16 -> sha256:..., with an anonymous token when the
+// registry asks for one, like docker pull does for public images
+func ResolveImageDigest(image string) (string, error) {
+	host, repo, tag := splitImage(image)
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repo, url.PathEscape(tag))
+
+	resp, err := headManifest(manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := registryToken(resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if resp, err = headManifest(manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("image %s is not found on %s", image, host)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return "", fmt.Errorf("http status %d for image %s", resp.StatusCode, image)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !digestRegex.MatchString(digest) {
+		return "", fmt.Errorf("registry %s returned no sha256 digest for image %s", host, image)
+	}
+
+	return digest, nil
+}
+
+// headManifest asks for the headers of a manifest, with a bearer token if any
+func headManifest(manifestURL string, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	req.Header.Set("Accept", manifestAccept)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// registryToken fetches an anonymous pull token from the realm of a Bearer
+// challenge, Ex: Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/postgres:pull"
+func registryToken(challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("registry asks for %q authentication, only anonymous bearer tokens are supported", scheme)
+	}
+
+	q := url.Values{}
+	realm := ""
+	for _, m := range bearerParamRegex.FindAllStringSubmatch(params, -1) {
+		if m[1] == "realm" {
+			realm = m[2]
+		} else {
+			q.Set(m[1], m[2])
+		}
+	}
+	u, err := url.Parse(realm)
+	if err != nil || u.Scheme != "https" {
+		return "", fmt.Errorf("registry token realm %q is not an https URL", realm)
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := apiClient.Get(u.String())
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http status %d for registry token", resp.StatusCode)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	if body.Token == "" {
+		body.Token = body.AccessToken
+	}
+
+	return body.Token, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSplitImage(t *testing.T) {
+	cases := map[string][3]string{
+		"postgres":                  {dockerHubHost, "library/postgres", "latest"},
+		"postgres:16":               {dockerHubHost, "library/postgres", "16"},
+		"bitnami/redis:7.2":         {dockerHubHost, "bitnami/redis", "7.2"},
+		"docker.io/library/node:20": {dockerHubHost, "library/node", "20"},
+		"ghcr.io/org/app:1":         {"ghcr.io", "org/app", "1"},
+		"localhost:5000/app":        {"localhost:5000", "app", "latest"},
+	}
+
+	for image, want := range cases {
+		host, repo, tag := splitImage(image)
+		if got := [3]string{host, repo, tag}; got != want {
+			t.Errorf("splitImage(%q) = %v; want %v", image, got, want)
+		}
+	}
+}
+
+func TestResolveImageDigest_FetchesAnonymousToken(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.String() {
+		case "https://registry-1.docker.io/v2/library/postgres/manifests/16":
+			if req.Method != http.MethodHead || !strings.Contains(req.Header.Get("Accept"), "image.index") {
+				t.Fatalf("unexpected manifest request: %s %v", req.Method, req.Header)
+			}
+			if req.Header.Get("Authorization") != "Bearer anon" {
+				resp := statusResponse(http.StatusUnauthorized, nil)
+				resp.Header.Set("WWW-Authenticate", `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/postgres:pull"`)
+				return resp, nil
+			}
+			resp := statusResponse(http.StatusOK, nil)
+			resp.Header.Set("Docker-Content-Digest", digest)
+			return resp, nil
+		case "https://auth.docker.io/token?scope=repository%3Alibrary%2Fpostgres%3Apull&service=registry.docker.io":
+			return jsonResponse(t, http.StatusOK, map[string]string{"token": "anon"}), nil
+		}
+		t.Fatalf("unexpected request: %s", req.URL.String())
+		return nil, nil
+	})
+
+	withHTTPClientTransport(rt, func() {
+		got, err := ResolveImageDigest("postgres:16")
+		if err != nil {
+			t.Fatalf("ResolveImageDigest returned error: %v", err)
+		}
+		if got != digest {
+			t.Fatalf("digest = %q; want %q", got, digest)
+		}
+	})
+}
+
+func TestResolveImageDigest_NotFound(t *testing.T) {
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return statusResponse(http.StatusNotFound, nil), nil
+	})
+
+	withHTTPClientTransport(rt, func() {
+		if _, err := ResolveImageDigest("ghcr.io/org/gone:1"); err == nil || !strings.Contains(err.Error(), "is not found on ghcr.io") {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+	})
+}
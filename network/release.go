@@ -0,0 +1,100 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrDraftRelease is returned when the release is an unpublished draft
+	ErrDraftRelease = errors.New("release is a draft")
+	// ErrPrerelease is returned when the release is a prerelease and prereleases are not allowed
+	ErrPrerelease = errors.New("release is a prerelease")
+)
+
+// Release is the subset of a GitHub release needed for verification
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type gitRefResponse struct {
+	Object Commit `json:"object"`
+}
+
+// getJSON fetches a GitHub API URL and decodes a successful response into v
+func getJSON(client *http.Client, lookupURL string, what string, v any) error {
+	resp, err := githubAPIGet(client, lookupURL)
+	if err != nil {
+		return fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := rateLimitError(resp); err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s is not found", what)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("http status %d for %s", resp.StatusCode, what)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveRelease returns the commit SHA of the tag of a published GitHub release.
+// Drafts are always rejected, prereleases unless allowPrerelease is set, so only
+// releases a maintainer actually published get pinned.
+func (s *SHAResolver) ResolveRelease(action string, tag string, allowPrerelease bool) (string, error) {
+	base := reposURL(s.APIURL)
+	what := fmt.Sprintf("release %s of action %s", tag, action)
+
+	var release Release
+	if err := getJSON(s.httpClient(), fmt.Sprintf("%s/%s/releases/tags/%s", base, escapeAction(action), url.PathEscape(tag)), what, &release); err != nil {
+		return "", err
+	}
+	if release.Draft {
+		return "", fmt.Errorf("%s: %w", what, ErrDraftRelease)
+	}
+	if release.Prerelease && !allowPrerelease {
+		return "", fmt.Errorf("%s: %w. Pass --allow-prerelease to pin it anyway", what, ErrPrerelease)
+	}
+
+	var ref gitRefResponse
+	if err := getJSON(s.httpClient(), fmt.Sprintf("%s/%s/git/ref/tags/%s", base, escapeAction(action), url.PathEscape(tag)), fmt.Sprintf("tag %s of action %s", tag, action), &ref); err != nil {
+		return "", err
+	}
+	if ref.Object.Sha == "" {
+		return "", fmt.Errorf("tag %s of action %s points to nothing", tag, action)
+	}
+
+	return dereferenceTag(s.httpClient(), s.APIURL, action, ref.Object)
+}
+
+// LatestRelease returns the latest published release of an action. GitHub leaves
+// drafts and prereleases out, so this is what users are nudged to upgrade to.
+func (s *SHAResolver) LatestRelease(action string) (*Release, error) {
+	var release Release
+	lookupURL := fmt.Sprintf("%s/%s/releases/latest", reposURL(s.APIURL), escapeAction(action))
+	if err := getJSON(s.httpClient(), lookupURL, fmt.Sprintf("latest release of action %s", action), &release); err != nil {
+		return nil, err
+	}
+
+	return &release, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSHAResolver_ResolveRelease(t *testing.T) {
+	tests := []struct {
+		name            string
+		release         string
+		allowPrerelease bool
+		wantSHA         string
+		wantErr         error
+	}{
+		{name: "published release", release: `{"tag_name":"v4.2.1","draft":false,"prerelease":false}`, wantSHA: "commit-sha"},
+		{name: "draft is rejected", release: `{"tag_name":"v4.2.1","draft":true}`, wantErr: ErrDraftRelease},
+		{name: "draft is rejected even with prereleases allowed", release: `{"tag_name":"v4.2.1","draft":true}`, allowPrerelease: true, wantErr: ErrDraftRelease},
+		{name: "prerelease is rejected", release: `{"tag_name":"v4.2.1","prerelease":true}`, wantErr: ErrPrerelease},
+		{name: "prerelease is allowed on request", release: `{"tag_name":"v4.2.1","prerelease":true}`, allowPrerelease: true, wantSHA: "commit-sha"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				switch req.URL.Path {
+				case "/repos/owner/repo/releases/tags/v4.2.1":
+					return statusResponse(http.StatusOK, []byte(tt.release)), nil
+				case "/repos/owner/repo/git/ref/tags/v4.2.1":
+					return statusResponse(http.StatusOK, []byte(`{"object":{"sha":"tag-object","type":"tag"}}`)), nil
+				case "/repos/owner/repo/git/tags/tag-object":
+					return statusResponse(http.StatusOK, []byte(`{"object":{"sha":"commit-sha","type":"commit"}}`)), nil
+				}
+				t.Fatalf("unexpected request: %s", req.URL.String())
+				return nil, nil
+			})
+
+			withHTTPClientTransport(customTransport, func() {
+				resolver := SHAResolver{}
+				sha, err := resolver.ResolveRelease("owner/repo", "v4.2.1", tt.allowPrerelease)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if sha != tt.wantSHA {
+					t.Errorf("sha = %q; want %q", sha, tt.wantSHA)
+				}
+			})
+		})
+	}
+}
+
+func TestSHAResolver_ResolveRelease_NotFound(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return statusResponse(http.StatusNotFound, []byte(`{"message":"Not Found"}`)), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{}
+		if _, err := resolver.ResolveRelease("owner/repo", "v9.9.9", false); err == nil {
+			t.Fatal("expected error for a missing release")
+		}
+	})
+}
+
+func TestSHAResolver_LatestRelease(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/repos/owner/repo/releases/latest" {
+			t.Fatalf("unexpected request: %s", req.URL.String())
+		}
+		return statusResponse(http.StatusOK, []byte(`{"tag_name":"v5.0.0","published_at":"2025-08-11T12:00:00Z"}`)), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{}
+		release, err := resolver.LatestRelease("owner/repo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if release.TagName != "v5.0.0" || release.PublishedAt.Format("2006-01-02") != "2025-08-11" {
+			t.Errorf("unexpected release: %+v", release)
+		}
+	})
+}
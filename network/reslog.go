@@ -0,0 +1,87 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cybrota/scharf/logging"
+)
+
+const (
+	ResolutionSourceCache = "cache"
+	ResolutionSourceAPI   = "api"
+	// ResolutionSourceCommand marks resolutions answered by an external resolver command
+	ResolutionSourceCommand = "command"
+	// ResolutionSourceGit marks resolutions answered by listing the refs of the action repository
+	ResolutionSourceGit = "git"
+)
+
+// Resolution is an evidence record of a single resolution attempt.
+// Reviewers use it to reproduce exactly which ref resolved to which SHA.
+type Resolution struct {
+	Action    string `json:"action"`
+	Version   string `json:"version"`
+	Endpoint  string `json:"endpoint"`
+	SHA       string `json:"sha"`
+	Source    string `json:"source"`
+	Timestamp string `json:"timestamp"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (s *SHAResolver) recordResolution(raw string, endpoint string, sha string, source string, err error) {
+	// A malformed reference still counts as an attempt; keep it whole as the action
+	splits, splitErr := splitRawAction(raw)
+	if splitErr != nil {
+		splits = [2]string{raw, ""}
+	}
+
+	r := Resolution{
+		Action:    splits[0],
+		Version:   splits[1],
+		Endpoint:  logging.Redact(endpoint),
+		SHA:       sha,
+		Source:    source,
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err != nil {
+		r.Error = logging.Redact(err.Error())
+	}
+
+	s.mu.Lock()
+	s.resolutions = append(s.resolutions, r)
+	s.mu.Unlock()
+}
+
+// Resolutions returns every resolution attempted by the resolver, in order
+func (s *SHAResolver) Resolutions() []Resolution {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Resolution(nil), s.resolutions...)
+}
+
+// WriteResolutionLog writes resolutions to the given file as a JSON array
+func WriteResolutionLog(path string, resolutions []Resolution) error {
+	// Always emit an array, even for runs that resolved nothing
+	if resolutions == nil {
+		resolutions = []Resolution{}
+	}
+
+	buf, err := json.MarshalIndent(resolutions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cybrota/scharf/logging"
+)
+
+func TestSHAResolver_Resolutions_RecordSource(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := json.Marshal([]BranchOrTag{{Name: "v1.0.0", Commit: Commit{Sha: "sha-valid"}}})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{"owner/cached@v2": "sha-cached"}}
+		resolver.Resolve("owner/cached@v2")
+		resolver.Resolve("owner/repo@v1.0.0")
+		resolver.Resolve("owner/repo@v9.9.9")
+
+		got := resolver.Resolutions()
+		if len(got) != 3 {
+			t.Fatalf("got %d resolutions, want 3", len(got))
+		}
+
+		cached := got[0]
+		if cached.Source != ResolutionSourceCache || cached.SHA != "sha-cached" || cached.Action != "owner/cached" || cached.Version != "v2" {
+			t.Errorf("unexpected cache resolution: %+v", cached)
+		}
+
+		api := got[1]
+		if api.Source != ResolutionSourceAPI || api.SHA != "sha-valid" || api.Endpoint != "https://api.github.com/repos/owner/repo/tags" {
+			t.Errorf("unexpected api resolution: %+v", api)
+		}
+		if api.Timestamp == "" {
+			t.Errorf("expected timestamp to be set")
+		}
+
+		missing := got[2]
+		if missing.Source != ResolutionSourceAPI || missing.SHA != "" || missing.Error == "" {
+			t.Errorf("expected failed attempt to be logged with error: %+v", missing)
+		}
+	})
+}
+
+func TestWriteResolutionLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolutions.json")
+	entries := []Resolution{{Action: "owner/repo", Version: "v1", SHA: "sha", Source: ResolutionSourceAPI}}
+	if err := WriteResolutionLog(path, entries); err != nil {
+		t.Fatalf("WriteResolutionLog returned error: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading log: %v", err)
+	}
+	var out []Resolution
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("invalid json written: %v", err)
+	}
+	if len(out) != 1 || out[0].Source != "api" {
+		t.Errorf("unexpected log content: %s", string(b))
+	}
+
+	if err := WriteResolutionLog(path, nil); err != nil {
+		t.Fatalf("WriteResolutionLog returned error: %v", err)
+	}
+	b, _ = os.ReadFile(path)
+	if string(b) != "[]" {
+		t.Errorf("expected empty JSON array, got %s", string(b))
+	}
+}
+
+func TestSHAResolver_RedactsTokenFromErrorsAndLog(t *testing.T) {
+	const token = "ghp_redact0123456789"
+	logging.AddSecret(token)
+	withoutRetryDelay(t)
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("proxy rejected credentials %s", token)
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}, APIURL: "https://x-access-token:" + token + "@ghe.internal/api/v3", MaxAttempts: 1}
+		_, err := resolver.Resolve("actions/checkout@v4")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if strings.Contains(err.Error(), token) {
+			t.Fatalf("error leaked the token: %v", err)
+		}
+
+		path := filepath.Join(t.TempDir(), "resolutions.json")
+		if err := WriteResolutionLog(path, resolver.Resolutions()); err != nil {
+			t.Fatalf("WriteResolutionLog returned error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), token) || !strings.Contains(string(data), logging.Redacted) {
+			t.Fatalf("resolution log leaked the token:\n%s", data)
+		}
+	})
+}
+
+func TestSHAResolver_ResolveNext_RecordsResolution(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.Contains(req.URL.Path, "/commits/") {
+			return statusResponse(http.StatusNotFound, nil), nil
+		}
+		return jsonResponse(t, http.StatusOK, []BranchOrTag{
+			{Name: "v1.1.0", Commit: Commit{Sha: "sha-110"}},
+			{Name: "v1.0.0", Commit: Commit{Sha: "sha-100"}},
+		}), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		if _, err := resolver.ResolveNext("owner/repo", "v1.0.0", 0); err != nil {
+			t.Fatalf("ResolveNext() returned error: %v", err)
+		}
+		if _, err := resolver.ResolveNext("owner/repo", "v1.1.0", 0); err == nil {
+			t.Fatal("expected an error for the latest version")
+		}
+
+		got := resolver.Resolutions()
+		if len(got) != 2 {
+			t.Fatalf("got %d resolutions; want 2: %+v", len(got), got)
+		}
+		if got[0].Version != "v1.1.0" || got[0].SHA != "sha-110" || got[0].Source != ResolutionSourceAPI || got[0].Error != "" {
+			t.Errorf("first resolution = %+v; want the next version v1.1.0 at sha-110", got[0])
+		}
+		if got[1].Version != "v1.1.0" || got[1].SHA != "" || got[1].Error == "" {
+			t.Errorf("second resolution = %+v; want the failed lookup from v1.1.0", got[1])
+		}
+		if got[0].Endpoint != "https://api.github.com/repos/owner/repo/tags" {
+			t.Errorf("endpoint = %q; want the tags endpoint", got[0].Endpoint)
+		}
+	})
+}
@@ -13,103 +13,323 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cybrota/scharf/actcache"
+	"github.com/cybrota/scharf/logging"
 )
 
-const apiURL = "https://api.github.com/repos"
+// DefaultAPIURL is the public GitHub API. GitHub Enterprise Server instances
+// serve the API under their own host, Ex: https://ghe.internal/api/v3
+const DefaultAPIURL = "https://api.github.com"
+
+// APIURLEnv overrides the GitHub API base URL
+const APIURLEnv = "SCHARF_GITHUB_API_URL"
 const defaultCooldownHours = 24
 
 var homedir, _ = os.UserHomeDir()
 var scharfDir = filepath.Join(homedir, ".scharf")
 
+// CacheDir returns the directory of the resolved SHA cache, Ex: ~/.scharf
+func CacheDir() string {
+	return scharfDir
+}
+
+// cacheFileMu serializes updates of the cache file in scharfDir
+var cacheFileMu sync.Mutex
+
+// cacheTTL is how long entries of the cache file are trusted
+var cacheTTL = actcache.DefaultTTL
+
+// SetCacheTTL sets how long cached SHAs are trusted before they are resolved
+// again. A ttl of 0 disables expiry.
+func SetCacheTTL(ttl time.Duration) {
+	cacheTTL = ttl
+}
+
 // Resolver is a converter for action@version to a SHA string
 type Resolver interface {
 	// Resolve checks if SHA is available for a given version of GitHub action
 	Resolve(action string) (string, error)
 }
 
-// searchTag probes for a given version tag in list of tags and returns SHA commit
-func searchTag(tags []BranchOrTag, version string) (bool, string) {
+// ExternalResolver resolves references in place of the GitHub API, Ex: a
+// CommandResolver or GitResolver
+type ExternalResolver interface {
+	Resolver
+	// Source names the resolver in the resolution log, Ex: command
+	Source() string
+	// Endpoint is what the resolver consults for action, Ex: a remote URL
+	Endpoint(action string) string
+}
+
+// findRef returns the branch or tag of the given name
+func findRef(tags []BranchOrTag, name string) (BranchOrTag, bool) {
 	for _, t := range tags {
-		if t.Name == version {
-			if t.Commit.Sha == "" {
-				return false, ""
-			} else {
-				return true, t.Commit.Sha
-			}
+		if t.Name == name {
+			return t, true
 		}
-		continue
 	}
 
-	return false, ""
+	return BranchOrTag{}, false
+}
+
+// searchTag probes for a given version tag in list of tags and returns SHA commit
+func searchTag(tags []BranchOrTag, version string) (bool, string) {
+	t, ok := findRef(tags, version)
+	if !ok || t.Commit.Sha == "" {
+		return false, ""
+	}
+
+	return true, t.Commit.Sha
 }
 
-// splitRawAction takes a raw action reference and splits it as action & version
-func splitRawAction(raw string) [2]string {
-	splits := strings.Split(raw, "@")
+var partialSemverRegex = regexp.MustCompile(`^v?\d+\.\d+$`)
+
+// searchLatestPatch resolves a partial major.minor version (Ex: v4.2) to the
+// highest matching patch tag (Ex: v4.2.3). Pre-release tags are never picked.
+func searchLatestPatch(tags []BranchOrTag, version string) (bool, string, string) {
+	if !partialSemverRegex.MatchString(version) {
+		return false, "", ""
+	}
+
+	prefix := version + "."
+	bestPatch := -1
+	var best BranchOrTag
+	for _, t := range tags {
+		if !strings.HasPrefix(t.Name, prefix) || t.Commit.Sha == "" {
+			continue
+		}
 
-	if len(splits) == 2 {
-		return [2]string{
-			splits[0],
-			splits[1],
+		patch, err := strconv.Atoi(strings.TrimPrefix(t.Name, prefix))
+		if err != nil || patch < 0 {
+			continue
 		}
-	} else if len(splits) == 1 {
-		return [2]string{
-			splits[0],
-			"",
+		if patch > bestPatch {
+			bestPatch = patch
+			best = t
+		}
+	}
+
+	if bestPatch == -1 {
+		return false, "", ""
+	}
+
+	return true, best.Commit.Sha, best.Name
+}
+
+// splitRawAction takes a raw action reference and splits it as action & version.
+// The version is separated by the last '@' so that odd pastes such as
+// owner/repo@weird@tag still split deterministically. A line ending left over
+// from a CRLF file or pasted input is dropped.
+func splitRawAction(raw string) ([2]string, error) {
+	raw = strings.TrimRight(raw, "\r\n")
+	idx := strings.LastIndex(raw, "@")
+	if idx == -1 {
+		if raw == "" {
+			return [2]string{}, errors.New("empty action reference")
 		}
+		return [2]string{raw, ""}, nil
+	}
+
+	action := raw[:idx]
+	version := raw[idx+1:]
+	if action == "" || version == "" {
+		return [2]string{}, fmt.Errorf("malformed action reference: %q", raw)
+	}
+
+	return [2]string{action, version}, nil
+}
+
+// actionRepository strips the path of a sub-action or reusable workflow from an
+// action, Ex: github/codeql-action/init -> github/codeql-action. Tags and
+// branches belong to the repository.
+func actionRepository(action string) string {
+	segments := strings.SplitN(action, "/", 3)
+	if len(segments) < 3 {
+		return action
+	}
+
+	return segments[0] + "/" + segments[1]
+}
+
+// normalizeAction lowercases the owner/repo of a raw action reference, as GitHub
+// treats them case-insensitively, Ex: Actions/Checkout@v4 -> actions/checkout@v4.
+// Refs are case-sensitive and kept as is.
+func normalizeAction(raw string) string {
+	idx := strings.LastIndex(raw, "@")
+	if idx == -1 {
+		return strings.ToLower(raw)
 	}
 
-	return [2]string{}
+	return strings.ToLower(raw[:idx]) + raw[idx:]
+}
+
+// decodeVersion turns a URL-encoded ref (Ex: release%2Fv1) into the raw ref name
+// GitHub reports, so that it can be compared against listed tags and branches.
+func decodeVersion(version string) string {
+	decoded, err := url.PathUnescape(version)
+	if err != nil {
+		return version
+	}
+
+	return decoded
+}
+
+// escapeAction escapes each path segment of owner/repo for use in a URL
+func escapeAction(action string) string {
+	segments := strings.Split(action, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// APIBaseURL returns the GitHub API base URL from SCHARF_GITHUB_API_URL, or the public API
+func APIBaseURL() string {
+	if base := strings.TrimSpace(os.Getenv(APIURLEnv)); base != "" {
+		return base
+	}
+
+	return DefaultAPIURL
+}
+
+// reposURL builds the repository endpoint root of a GitHub API base URL.
+// An empty base falls back to APIBaseURL.
+func reposURL(base string) string {
+	if base == "" {
+		base = APIBaseURL()
+	}
+
+	return strings.TrimRight(base, "/") + "/repos"
 }
 
 // makeAPIEndpoint checks if  agiven version is a branch or tag and builds endpoint
-func makeAPIEndpoint(action string, version string) string {
+// under the given API base URL. No version looks up the latest release tag.
+func makeAPIEndpoint(base string, action string, version string) string {
 	var lookupURL string
 
-	if strings.HasPrefix(strings.ToLower(version), "v") {
-		lookupURL = fmt.Sprintf("%s/%s/tags", apiURL, action)
+	if isCommitVersion(version) {
+		lookupURL = fmt.Sprintf("%s/%s/commits/%s", reposURL(base), escapeAction(action), url.PathEscape(version))
+	} else if version == "" || isTagVersion(version) {
+		lookupURL = fmt.Sprintf("%s/%s/tags", reposURL(base), escapeAction(action))
 	} else {
-		lookupURL = fmt.Sprintf("%s/%s/branches", apiURL, action)
+		lookupURL = fmt.Sprintf("%s/%s/branches", reposURL(base), escapeAction(action))
 	}
 
 	return lookupURL
 }
 
-func githubAPIGet(lookupURL string) (*http.Response, error) {
+// commitVersionRegex matches an abbreviated or full commit SHA, Ex: abc1234
+var commitVersionRegex = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// isCommitVersion reports whether version looks like a commit SHA, which is
+// expanded through the commits endpoint rather than looked up among refs
+func isCommitVersion(version string) bool {
+	return commitVersionRegex.MatchString(version)
+}
+
+// isTagVersion reports whether version is looked up among tags (Ex: v4) rather than branches (Ex: main)
+func isTagVersion(version string) bool {
+	return strings.HasPrefix(strings.ToLower(version), "v")
+}
+
+// IsBranchVersion reports whether version is looked up among branches, Ex: main
+// or develop, like makeAPIEndpoint does: it is neither a tag (v prefix), an
+// abbreviated SHA nor empty (the latest release)
+func IsBranchVersion(version string) bool {
+	return version != "" && !isTagVersion(version) && !isCommitVersion(version)
+}
+
+// Environment variables holding a GitHub API token. SCHARF_TOKEN wins so users
+// can override the GITHUB_TOKEN a CI runner injects.
+const (
+	ScharfTokenEnv = "SCHARF_TOKEN"
+	GitHubTokenEnv = "GITHUB_TOKEN"
+)
+
+// apiToken returns the configured GitHub API token, or "" for anonymous access
+func apiToken() string {
+	for _, env := range []string{ScharfTokenEnv, GitHubTokenEnv} {
+		if token := strings.TrimSpace(os.Getenv(env)); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
+
+// newAPIRequest builds a GET request for the GitHub API, authenticated when a token is set.
+// Without a token requests are anonymous and limited to 60 per hour.
+func newAPIRequest(lookupURL string) (*http.Request, error) {
 	req, err := http.NewRequest(http.MethodGet, lookupURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("request: %w", err)
 	}
 
-	if token := strings.TrimSpace(os.Getenv("GITHUB_TOKEN")); token != "" {
+	// A fixed media type keeps responses cacheable by shared proxies and by --api-cache-dir
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := apiToken(); token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
-	return http.DefaultClient.Do(req)
+	return req, nil
+}
+
+func githubAPIGet(client *http.Client, lookupURL string) (*http.Response, error) {
+	req, err := newAPIRequest(lookupURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(req)
 }
 
 // GetRefList takes an action and returns a list of matching tags
 func GetRefList(action string) ([]BranchOrTag, error) {
-	lookupURL := fmt.Sprintf("%s/%s/tags", apiURL, action)
-	resp, err := githubAPIGet(lookupURL)
+	return getRefList(apiClient, APIBaseURL(), action)
+}
+
+// getRefList lists the tags of an action from the GitHub API at base
+func getRefList(client *http.Client, base string, action string) ([]BranchOrTag, error) {
+	return listRefs(client, base, action, "tags")
+}
+
+// GetBranchList takes an action and returns a list of its branches
+func GetBranchList(action string) ([]BranchOrTag, error) {
+	return listRefs(apiClient, APIBaseURL(), action, "branches")
+}
+
+// listRefs lists the refs of an action from the tags or branches endpoint of
+// the GitHub API at base
+func listRefs(client *http.Client, base string, action string, endpoint string) ([]BranchOrTag, error) {
+	lookupURL := fmt.Sprintf("%s/%s/%s", reposURL(base), escapeAction(action), endpoint)
+	resp, err := githubAPIGet(client, pagedURL(lookupURL))
 	if err != nil {
 		return []BranchOrTag{}, fmt.Errorf("http: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := rateLimitError(resp); err != nil {
+		return []BranchOrTag{}, err
+	}
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
 		return []BranchOrTag{}, fmt.Errorf("http status %d for action %s", resp.StatusCode, action)
 	}
 
-	var b []BranchOrTag
-	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
-		return []BranchOrTag{}, fmt.Errorf("json: %w", err)
+	b, err := decodeAllRefs(resp, func(pageURL string) (*http.Response, error) {
+		return githubAPIGet(client, pageURL)
+	})
+	if err != nil {
+		return []BranchOrTag{}, err
 	}
 
 	return b, nil
@@ -117,11 +337,50 @@ func GetRefList(action string) ([]BranchOrTag, error) {
 
 // SHAResolver resolves a given action to it's safe SHA commit
 type SHAResolver struct {
+	// mu guards cache, resolutions, moved, concrete and commitTagsOf, so a
+	// resolver can be shared by goroutines
+	mu    sync.RWMutex
 	cache map[string]string
+
+	// CacheReadOnly consumes the cache file but never writes new entries to it
+	CacheReadOnly bool
+
+	// SkipCache always asks GitHub, Ex: to detect tags moved since they were cached
+	SkipCache bool
+
+	// APIURL is the GitHub API base URL, Ex: https://ghe.internal/api/v3.
+	// Empty means SCHARF_GITHUB_API_URL or the public API.
+	APIURL string
+
+	// MaxAttempts is the number of tries per API lookup when GitHub fails
+	// transiently (network errors, 5xx, 429). Values below 1 mean a single try.
+	MaxAttempts int
+
+	// External, when set, resolves references that aren't cached instead of the
+	// GitHub API, Ex: a CommandResolver for an internal registry
+	External ExternalResolver
+
+	// Client makes the GitHub API calls. Nil means a client giving up after
+	// DefaultHTTPTimeout or the timeout set with SetHTTPTimeout.
+	Client *http.Client
+
+	resolutions []Resolution
+	moved       map[string]string
+	concrete    map[string]string // partial version refs -> concrete tag they resolved to
+
+	commitTagsOf map[string][]BranchOrTag // owner/repo -> tags at their commits, listed once by TagsAt
+}
+
+// httpClient returns the client of the GitHub API calls of s
+func (s *SHAResolver) httpClient() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return apiClient
 }
 
-func (s SHAResolver) ListTags(action string) ([]BranchOrTag, error) {
-	return GetRefList(action)
+func (s *SHAResolver) ListTags(action string) ([]BranchOrTag, error) {
+	return getRefList(s.httpClient(), s.APIURL, actionRepository(action))
 }
 
 // UpgradeResult holds the details needed for pinned SHA upgrade flows.
@@ -138,22 +397,35 @@ type UpgradeResult struct {
 func NewSHAResolver() *SHAResolver {
 	cache := make(map[string]string)
 
-	// Fill resolver cache from cache file
-	c, err := actcache.GetCache(scharfDir)
+	moved := make(map[string]string)
+
+	// Fill resolver cache from cache file. Expired entries are resolved again.
+	c, err := actcache.GetFreshCache(scharfDir, cacheTTL)
 	if err == nil && len(c) > 0 {
 		for k, v := range c {
 			cache[k] = v.SHA
+			// A cache hit skips the API, so moves detected then are kept with the SHA
+			if splits, err := splitRawAction(k); err == nil && v.MovedTo != "" {
+				moved[actionRepository(splits[0])] = v.MovedTo
+			}
 		}
 	}
 
 	return &SHAResolver{
-		cache: cache,
+		cache:       cache,
+		moved:       moved,
+		APIURL:      APIBaseURL(),
+		MaxAttempts: DefaultMaxAttempts,
+		Client:      apiClient,
 	}
 }
 
 type Commit struct {
 	Sha string `json:"sha"`
 	URL string `json:"url"`
+	// Type is the git object type Sha refers to. "tag" marks an annotated tag
+	// object that must be dereferenced to reach the commit
+	Type string `json:"type,omitempty"`
 }
 
 type BranchOrTag struct {
@@ -192,14 +464,17 @@ func isUnderCooldown(tagTime time.Time, cooldownHours int) bool {
 	return time.Since(tagTime) < time.Duration(safeCooldown)*time.Hour
 }
 
-func fetchCommitTimestamp(action string, sha string) (time.Time, error) {
-	lookupURL := fmt.Sprintf("%s/%s/commits/%s", apiURL, action, sha)
-	resp, err := githubAPIGet(lookupURL)
+func fetchCommitTimestamp(client *http.Client, base string, action string, sha string) (time.Time, error) {
+	lookupURL := fmt.Sprintf("%s/%s/commits/%s", reposURL(base), escapeAction(action), url.PathEscape(sha))
+	resp, err := githubAPIGet(client, lookupURL)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("http: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := rateLimitError(resp); err != nil {
+		return time.Time{}, err
+	}
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
 		return time.Time{}, fmt.Errorf("http status: %d", resp.StatusCode)
 	}
@@ -222,8 +497,21 @@ func fetchCommitTimestamp(action string, sha string) (time.Time, error) {
 }
 
 // ResolveNext resolves the next version and SHA for an action's current version.
+// The resolution log records the next version, or the current one on errors.
 func (s *SHAResolver) ResolveNext(action string, currentVersion string, cooldownHours int) (*UpgradeResult, error) {
-	refs, err := GetRefList(action)
+	endpoint := fmt.Sprintf("%s/%s/tags", reposURL(s.APIURL), escapeAction(action))
+	result, err := s.resolveNext(action, currentVersion, cooldownHours)
+	if err != nil {
+		s.recordResolution(action+"@"+currentVersion, endpoint, "", ResolutionSourceAPI, err)
+		return nil, logging.RedactError(err)
+	}
+
+	s.recordResolution(action+"@"+result.NextVersion, endpoint, result.NextSHA, ResolutionSourceAPI, nil)
+	return result, nil
+}
+
+func (s *SHAResolver) resolveNext(action string, currentVersion string, cooldownHours int) (*UpgradeResult, error) {
+	refs, err := getRefList(s.httpClient(), s.APIURL, action)
 	if err != nil {
 		return nil, err
 	}
@@ -248,8 +536,20 @@ func (s *SHAResolver) ResolveNext(action string, currentVersion string, cooldown
 		return nil, fmt.Errorf("given version: %s is not found for action: %s", nextVer, action)
 	}
 
+	// Pinned SHAs are commits, so annotated tags are compared by the commit they point to
+	if ref, ok := findRef(refs, currentVersion); ok {
+		if currentSHA, err = dereferenceTag(s.httpClient(), s.APIURL, action, ref.Commit); err != nil {
+			return nil, err
+		}
+	}
+	if ref, ok := findRef(refs, nextVer); ok {
+		if nextSHA, err = dereferenceTag(s.httpClient(), s.APIURL, action, ref.Commit); err != nil {
+			return nil, err
+		}
+	}
+
 	underCooldown := false
-	if ts, err := fetchCommitTimestamp(action, nextSHA); err == nil {
+	if ts, err := fetchCommitTimestamp(s.httpClient(), s.APIURL, action, nextSHA); err == nil {
 		underCooldown = isUnderCooldown(ts, cooldownHours)
 	}
 
@@ -265,43 +565,201 @@ func (s *SHAResolver) ResolveNext(action string, currentVersion string, cooldown
 }
 
 // Resolve fetches list of tags for a given GitHub action and picks SHA commit
+// of the version. An action without a version, Ex: actions/checkout, resolves to
+// its highest release tag.
 func (s *SHAResolver) Resolve(action string) (string, error) {
+	// Differently cased references share cache entries and API lookups, while the
+	// resolution log keeps the reference as written
+	key := normalizeAction(action)
+
 	// See if SHA can be found in resolver cache
-	if s.cache[action] != "" {
-		return s.cache[action], nil
+	if !s.SkipCache {
+		if sha, ok := s.cachedSHA(key); ok {
+			s.recordResolution(action, "", sha, ResolutionSourceCache, nil)
+			return sha, nil
+		}
 	}
 
-	splits := splitRawAction(action)
-	actionBase := splits[0]
-	version := splits[1]
+	if s.External != nil {
+		sha, err := s.External.Resolve(action)
+		s.recordResolution(action, s.External.Endpoint(action), sha, s.External.Source(), err)
+		if err != nil {
+			return "", logging.RedactError(err)
+		}
 
-	if version == "" {
-		version = "main"
+		// Keep the answer for repeated references of this run only; the external
+		// resolver stays the source of truth across runs
+		s.mu.Lock()
+		s.cache[key] = sha
+		s.mu.Unlock()
+		return sha, nil
 	}
 
-	url := makeAPIEndpoint(actionBase, version)
+	sha, endpoint, err := s.resolveFromAPI(key)
+	s.recordResolution(action, endpoint, sha, ResolutionSourceAPI, err)
+	// Errors may quote a URL or a resolver's output holding the token
+	return sha, logging.RedactError(err)
+}
+
+// ResolvedVersion returns the concrete tag a partial version reference (Ex: owner/repo@v4.2)
+// was resolved to by Resolve (Ex: v4.2.3)
+func (s *SHAResolver) ResolvedVersion(action string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.concrete[normalizeAction(action)]
+	return v, ok
+}
+
+// cachedSHA returns the SHA of action from the resolver cache
+func (s *SHAResolver) cachedSHA(action string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sha := s.cache[action]
+	return sha, sha != ""
+}
+
+// resolveFromAPI looks up the SHA on GitHub and returns it with the endpoint used
+func (s *SHAResolver) resolveFromAPI(action string) (string, string, error) {
+	splits, err := splitRawAction(action)
+	if err != nil {
+		return "", "", fmt.Errorf("parse: %w", err)
+	}
+	actionBase := actionRepository(splits[0])
+	// Without a version, the latest release tag is meant; a branch needs an
+	// explicit @main
+	version := decodeVersion(splits[1])
+
+	lookupURL := makeAPIEndpoint(s.APIURL, actionBase, version)
+	requestURL := lookupURL
+	if !isCommitVersion(version) {
+		requestURL = pagedURL(lookupURL)
+	}
 
-	resp, err := githubAPIGet(url)
+	resp, movedTo, err := getWithRetries(s.MaxAttempts, func() (*http.Response, string, error) {
+		return githubAPIGetTrackingMoves(s.httpClient(), requestURL)
+	})
 	if err != nil {
-		return "", fmt.Errorf("http: %w", err)
+		return "", lookupURL, fmt.Errorf("http: %w", err)
 	}
 	defer resp.Body.Close()
 
-	var b []BranchOrTag
-	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
-		return "", fmt.Errorf("json: %w", err)
+	if strings.EqualFold(movedTo, actionBase) {
+		movedTo = ""
+	}
+	if movedTo != "" {
+		s.mu.Lock()
+		if s.moved == nil {
+			s.moved = make(map[string]string)
+		}
+		s.moved[actionBase] = movedTo
+		s.mu.Unlock()
 	}
 
-	found, sha := searchTag(b, version)
-	if !found {
-		return "", errors.New(fmt.Sprintf("given version: %s is not found for action: %s", version, actionBase))
+	// A rate limited or failed lookup says nothing about whether the version exists
+	if err := rateLimitError(resp); err != nil {
+		return "", lookupURL, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return "", lookupURL, fmt.Errorf("http status %d for action %s", resp.StatusCode, actionBase)
+	}
+
+	if isCommitVersion(version) {
+		sha, err := s.expandCommit(resp, action, version)
+		return sha, lookupURL, err
+	}
+
+	b, err := decodeAllRefs(resp, func(pageURL string) (*http.Response, error) {
+		resp, _, err := getWithRetries(s.MaxAttempts, func() (*http.Response, string, error) {
+			return githubAPIGetTrackingMoves(s.httpClient(), pageURL)
+		})
+		return resp, err
+	})
+	if err != nil {
+		return "", lookupURL, err
+	}
+
+	matched := version
+	concrete := ""
+	var found bool
+	var sha string
+	if version == "" {
+		if found, sha, concrete = searchLatestTag(b); !found {
+			return "", lookupURL, fmt.Errorf("no release tag is found for action: %s", actionBase)
+		}
+		matched = concrete
+	} else if found, sha = searchTag(b, version); !found {
+		found, sha, concrete = searchLatestPatch(b, version)
+		if !found {
+			return "", lookupURL, errors.New(fmt.Sprintf("given version: %s is not found for action: %s", version, actionBase))
+		}
+		matched = concrete
+	}
+
+	// Annotated tags list the tag object; pin the commit it points to
+	if ref, ok := findRef(b, matched); ok {
+		if sha, err = dereferenceTag(s.httpClient(), s.APIURL, actionBase, ref.Commit); err != nil {
+			return "", lookupURL, err
+		}
 	}
 
 	// Add SHA to resolver cache for repeated asks
+	s.mu.Lock()
 	s.cache[action] = sha
+	if concrete != "" {
+		if s.concrete == nil {
+			s.concrete = make(map[string]string)
+		}
+		s.concrete[action] = concrete
+	}
+	s.mu.Unlock()
+
+	if concrete != "" {
+		// Partial and missing versions float with every release, so they are
+		// kept only in memory and never persisted to the cache file
+		return sha, lookupURL, nil
+	}
+	// Branch heads move with every push, so a cached head would go stale
+	if !isTagVersion(version) {
+		return sha, lookupURL, nil
+	}
+
+	// Add SHA to cache file for future calls, unless a pre-warmed cache
+	// must stay untouched (Ex: shared CI caches)
+	if !s.CacheReadOnly {
+		// The cache file is read, updated and written back as a whole
+		cacheFileMu.Lock()
+		actcache.UpdateMovedCacheEntry(scharfDir, action, sha, movedTo)
+		cacheFileMu.Unlock()
+	}
 
-	// Add SHA to cache file for future calls
-	actcache.UpdateCacheEntry(scharfDir, action, sha)
+	return sha, lookupURL, nil
+}
+
+// expandCommit reads the full SHA of a commit lookup of version, Ex: abc1234,
+// from resp and caches it
+func (s *SHAResolver) expandCommit(resp *http.Response, action string, version string) (string, error) {
+	var commit struct {
+		Sha string `json:"sha"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&commit); err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	if commit.Sha == "" {
+		return "", fmt.Errorf("given version: %s is not found for action: %s", version, action)
+	}
+
+	s.mu.Lock()
+	s.cache[action] = commit.Sha
+	s.mu.Unlock()
+
+	// The commits endpoint also accepts branch names, Ex: deadbeef, whose head
+	// moves; only a SHA prefix always expands to the same commit
+	if s.CacheReadOnly || !strings.HasPrefix(commit.Sha, strings.ToLower(version)) {
+		return commit.Sha, nil
+	}
+	cacheFileMu.Lock()
+	actcache.UpdateCacheEntry(scharfDir, action, commit.Sha)
+	cacheFileMu.Unlock()
 
-	return sha, nil
+	return commit.Sha, nil
 }
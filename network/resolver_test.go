@@ -17,8 +17,11 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/cybrota/scharf/actcache"
 )
 
 // --- Helper functions for testing ---
@@ -33,9 +36,9 @@ func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 
 // withHTTPClientTransport temporarily replaces the default transport.
 func withHTTPClientTransport(rt http.RoundTripper, fn func()) {
-	orig := http.DefaultClient.Transport
-	http.DefaultClient.Transport = rt
-	defer func() { http.DefaultClient.Transport = orig }()
+	orig := apiTransport
+	apiTransport = rt
+	defer func() { apiTransport = orig }()
 	fn()
 }
 
@@ -43,9 +46,10 @@ func withHTTPClientTransport(rt http.RoundTripper, fn func()) {
 
 func TestSplitRawAction(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    string
-		expected [2]string
+		name      string
+		input     string
+		expected  [2]string
+		expectErr bool
 	}{
 		{
 			name:     "action with version",
@@ -58,15 +62,47 @@ func TestSplitRawAction(t *testing.T) {
 			expected: [2]string{"owner/repo", ""},
 		},
 		{
-			name:     "empty string",
-			input:    "",
-			expected: [2]string{"", ""},
+			name:      "empty string",
+			input:     "",
+			expected:  [2]string{"", ""},
+			expectErr: true,
+		},
+		{
+			name:     "CRLF line ending is dropped",
+			input:    "owner/repo@v4\r\n",
+			expected: [2]string{"owner/repo", "v4"},
+		},
+		{
+			name:     "multiple @ splits on the last one",
+			input:    "owner/repo@weird@tag",
+			expected: [2]string{"owner/repo@weird", "tag"},
+		},
+		{
+			name:      "trailing @ without version",
+			input:     "owner/repo@",
+			expected:  [2]string{"", ""},
+			expectErr: true,
+		},
+		{
+			name:      "missing action before @",
+			input:     "@v1",
+			expected:  [2]string{"", ""},
+			expectErr: true,
+		},
+		{
+			name:      "multiple @ with trailing separator",
+			input:     "owner/repo@v1@",
+			expected:  [2]string{"", ""},
+			expectErr: true,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got := splitRawAction(tc.input)
+			got, err := splitRawAction(tc.input)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("splitRawAction(%q) error = %v; expectErr %v", tc.input, err, tc.expectErr)
+			}
 			if got != tc.expected {
 				t.Errorf("splitRawAction(%q) = %v; want %v", tc.input, got, tc.expected)
 			}
@@ -95,17 +131,53 @@ func TestMakeAPIEndpoint(t *testing.T) {
 			version:  "main",
 			expected: "https://api.github.com/repos/owner/repo/branches",
 		},
+		{
+			name:     "special characters in action are escaped",
+			action:   "owner/re po#1",
+			version:  "v1",
+			expected: "https://api.github.com/repos/owner/re%20po%231/tags",
+		},
+		{
+			name:     "slashes in version never leak into the endpoint",
+			action:   "owner/repo",
+			version:  "release/v1",
+			expected: "https://api.github.com/repos/owner/repo/branches",
+		},
 		{
 			name:     "version lowercase check",
 			action:   "owner/repo",
 			version:  "V2.0.0", // Even if uppercase, we lowercase the prefix
 			expected: "https://api.github.com/repos/owner/repo/tags",
 		},
+		{
+			name:     "short SHA is expanded by the commits endpoint",
+			action:   "owner/repo",
+			version:  "abc1234",
+			expected: "https://api.github.com/repos/owner/repo/commits/abc1234",
+		},
+		{
+			name:     "full SHA is looked up by the commits endpoint",
+			action:   "owner/repo",
+			version:  "0123456789abcdef0123456789abcdef01234567",
+			expected: "https://api.github.com/repos/owner/repo/commits/0123456789abcdef0123456789abcdef01234567",
+		},
+		{
+			name:     "non-hex version of SHA length is a branch",
+			action:   "owner/repo",
+			version:  "abc123z",
+			expected: "https://api.github.com/repos/owner/repo/branches",
+		},
+		{
+			name:     "hex version shorter than 7 is a branch",
+			action:   "owner/repo",
+			version:  "abc12",
+			expected: "https://api.github.com/repos/owner/repo/branches",
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got := makeAPIEndpoint(tc.action, tc.version)
+			got := makeAPIEndpoint("", tc.action, tc.version)
 			if got != tc.expected {
 				t.Errorf("makeAPIEndpoint(%q, %q) = %v; want %v", tc.action, tc.version, got, tc.expected)
 			}
@@ -113,6 +185,25 @@ func TestMakeAPIEndpoint(t *testing.T) {
 	}
 }
 
+func TestDecodeVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"v1.0.0", "v1.0.0"},
+		{"release%2Fv1", "release/v1"},
+		{"v1%23beta", "v1#beta"},
+		{"feature/x", "feature/x"},
+		{"bad%zzescape", "bad%zzescape"},
+	}
+
+	for _, tc := range tests {
+		if got := decodeVersion(tc.input); got != tc.expected {
+			t.Errorf("decodeVersion(%q) = %q; want %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
 // --- Tests for searchTag ---
 
 func TestSearchTag(t *testing.T) {
@@ -269,7 +360,7 @@ func TestSHAResolver_ResolveNext_UnderCooldownFromCommitTimestamp(t *testing.T)
 		var err error
 
 		switch req.URL.String() {
-		case "https://api.github.com/repos/owner/repo/tags":
+		case "https://api.github.com/repos/owner/repo/tags?per_page=100":
 			data := []BranchOrTag{
 				{Name: "v1.2.0", Commit: Commit{Sha: "sha-120"}},
 				{Name: "v1.1.0", Commit: Commit{Sha: "sha-110"}},
@@ -349,9 +440,9 @@ func TestSHAResolver_resolve(t *testing.T) {
 		url := req.URL.String()
 		// For test of not found case, we simulate a valid empty list.
 		var data []BranchOrTag
-		if url == "https://api.github.com/repos/owner/repo/tags" {
+		if url == "https://api.github.com/repos/owner/repo/tags?per_page=100" {
 			data = responses["https://api.github.com/repos/owner/repo/tags"]
-		} else if url == "https://api.github.com/repos/owner/repo/branches" {
+		} else if url == "https://api.github.com/repos/owner/repo/branches?per_page=100" {
 			data = responses["https://api.github.com/repos/owner/repo/branches"]
 		} else {
 			data = responses["https://api.github.com/repos/owner/repo/tags-notfound"]
@@ -487,7 +578,7 @@ func TestGetRefList(t *testing.T) {
 		// Create a custom transport that returns the expected JSON.
 		customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
 			// Verify that the URL is constructed as expected.
-			expectedURL := "https://api.github.com/repos/owner/repo/tags"
+			expectedURL := "https://api.github.com/repos/owner/repo/tags?per_page=100"
 			if req.URL.String() != expectedURL {
 				t.Errorf("unexpected URL: got %q, want %q", req.URL.String(), expectedURL)
 			}
@@ -498,7 +589,7 @@ func TestGetRefList(t *testing.T) {
 			}, nil
 		})
 
-		// Use the custom transport to override http.DefaultClient.Transport.
+		// Use the custom transport to answer the API calls.
 		withHTTPClientTransport(customTransport, func() {
 			refs, err := GetRefList("owner/repo")
 			if err != nil {
@@ -596,3 +687,600 @@ func TestGetRefList_UsesGitHubTokenWhenPresent(t *testing.T) {
 		}
 	})
 }
+
+func TestGetRefList_ScharfTokenWinsOverGitHubToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	t.Setenv("SCHARF_TOKEN", "scharf-token")
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if got := req.Header.Get("Authorization"); got != "Bearer scharf-token" {
+			t.Fatalf("authorization header = %q; want %q", got, "Bearer scharf-token")
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`[]`))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if _, err := GetRefList("owner/repo"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestGetRefList_AnonymousWithoutToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("SCHARF_TOKEN", "")
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if got := req.Header.Get("Authorization"); got != "" {
+			t.Fatalf("expected no authorization header, got %q", got)
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`[]`))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if _, err := GetRefList("owner/repo"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSHAResolver_Resolve_CacheReadOnly(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := json.Marshal([]BranchOrTag{{Name: "v1.0.0", Commit: Commit{Sha: "sha-valid"}}})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}, CacheReadOnly: true}
+		sha, err := resolver.Resolve("owner/repo@v1.0.0")
+		if err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+		if sha != "sha-valid" {
+			t.Fatalf("Resolve() = %q; want %q", sha, "sha-valid")
+		}
+	})
+
+	if actcache.CacheExists(scharfDir) {
+		t.Fatalf("expected no cache file to be written in read-only mode")
+	}
+}
+
+func TestSHAResolver_Resolve_WritesCacheByDefault(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := json.Marshal([]BranchOrTag{{Name: "v1.0.0", Commit: Commit{Sha: "sha-valid"}}})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		if _, err := resolver.Resolve("owner/repo@v1.0.0"); err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+	})
+
+	if !actcache.CacheExists(scharfDir) {
+		t.Fatalf("expected cache file to be written")
+	}
+}
+
+func TestSHAResolver_Resolve_SpecialCharacterRefs(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var data []BranchOrTag
+		switch req.URL.String() {
+		case "https://api.github.com/repos/owner/repo/branches?per_page=100":
+			data = []BranchOrTag{{Name: "release/v1", Commit: Commit{Sha: "sha-release"}}}
+		case "https://api.github.com/repos/owner/repo/tags?per_page=100":
+			data = []BranchOrTag{{Name: "v1#beta", Commit: Commit{Sha: "sha-beta"}}}
+		default:
+			return nil, fmt.Errorf("unexpected URL: %s", req.URL.String())
+		}
+
+		b, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		tests := []struct {
+			input    string
+			expected string
+		}{
+			{"owner/repo@release/v1", "sha-release"},
+			{"owner/repo@release%2Fv1", "sha-release"},
+			{"owner/repo@v1%23beta", "sha-beta"},
+		}
+
+		for _, tc := range tests {
+			resolver := SHAResolver{cache: map[string]string{}}
+			sha, err := resolver.Resolve(tc.input)
+			if err != nil {
+				t.Fatalf("Resolve(%q) returned error: %v", tc.input, err)
+			}
+			if sha != tc.expected {
+				t.Errorf("Resolve(%q) = %q; want %q", tc.input, sha, tc.expected)
+			}
+		}
+	})
+}
+
+func TestSearchLatestPatch(t *testing.T) {
+	tags := []BranchOrTag{
+		{Name: "v4.2.10-beta", Commit: Commit{Sha: "sha-beta"}},
+		{Name: "v4.2.3", Commit: Commit{Sha: "sha-423"}},
+		{Name: "v4.2.1", Commit: Commit{Sha: "sha-421"}},
+		{Name: "v4.20.0", Commit: Commit{Sha: "sha-4200"}},
+		{Name: "v4.3.0", Commit: Commit{Sha: "sha-430"}},
+	}
+
+	found, sha, concrete := searchLatestPatch(tags, "v4.2")
+	if !found || sha != "sha-423" || concrete != "v4.2.3" {
+		t.Fatalf("searchLatestPatch(v4.2) = %v, %q, %q; want true, sha-423, v4.2.3", found, sha, concrete)
+	}
+
+	for _, version := range []string{"v4", "v4.2.3", "v4.4", "main"} {
+		if found, _, _ := searchLatestPatch(tags, version); found {
+			t.Errorf("searchLatestPatch(%q) unexpectedly found a tag", version)
+		}
+	}
+}
+
+func TestSHAResolver_Resolve_PartialSemver(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := json.Marshal([]BranchOrTag{
+			{Name: "v4.2.3", Commit: Commit{Sha: "sha-423"}},
+			{Name: "v4.2.2", Commit: Commit{Sha: "sha-422"}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		sha, err := resolver.Resolve("owner/repo@v4.2")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sha != "sha-423" {
+			t.Fatalf("sha = %q; want %q", sha, "sha-423")
+		}
+
+		concrete, ok := resolver.ResolvedVersion("owner/repo@v4.2")
+		if !ok || concrete != "v4.2.3" {
+			t.Fatalf("ResolvedVersion = %q, %v; want v4.2.3, true", concrete, ok)
+		}
+	})
+
+	if actcache.CacheExists(scharfDir) {
+		t.Fatalf("partial versions must not be persisted to the cache file")
+	}
+}
+
+func TestMakeAPIEndpoint_CustomBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		env      string
+		version  string
+		expected string
+	}{
+		{
+			name:     "enterprise base",
+			base:     "https://ghe.internal/api/v3",
+			version:  "v1",
+			expected: "https://ghe.internal/api/v3/repos/owner/repo/tags",
+		},
+		{
+			name:     "trailing slash is trimmed",
+			base:     "https://ghe.internal/api/v3/",
+			version:  "main",
+			expected: "https://ghe.internal/api/v3/repos/owner/repo/branches",
+		},
+		{
+			name:     "empty base falls back to env",
+			env:      "https://ghe.internal/api/v3",
+			version:  "v1",
+			expected: "https://ghe.internal/api/v3/repos/owner/repo/tags",
+		},
+		{
+			name:     "explicit base wins over env",
+			base:     "https://other.internal/api/v3",
+			env:      "https://ghe.internal/api/v3",
+			version:  "v1",
+			expected: "https://other.internal/api/v3/repos/owner/repo/tags",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(APIURLEnv, tc.env)
+			if got := makeAPIEndpoint(tc.base, "owner/repo", tc.version); got != tc.expected {
+				t.Errorf("makeAPIEndpoint(%q, owner/repo, %q) = %v; want %v", tc.base, tc.version, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSHAResolver_Resolve_EnterpriseBase(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+	t.Setenv(APIURLEnv, "https://ghe.internal/api/v3")
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if got := req.URL.String(); got != "https://ghe.internal/api/v3/repos/owner/repo/tags?per_page=100" {
+			t.Fatalf("unexpected URL: %s", got)
+		}
+		b, err := json.Marshal([]BranchOrTag{{Name: "v1", Commit: Commit{Sha: "sha-ghe"}}})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := NewSHAResolver()
+		if resolver.APIURL != "https://ghe.internal/api/v3" {
+			t.Fatalf("APIURL = %q; want the env value", resolver.APIURL)
+		}
+		sha, err := resolver.Resolve("owner/repo@v1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sha != "sha-ghe" {
+			t.Fatalf("sha = %q; want %q", sha, "sha-ghe")
+		}
+	})
+}
+
+func TestSHAResolver_Resolve_SkipCache(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := json.Marshal([]BranchOrTag{{Name: "v1", Commit: Commit{Sha: "sha-moved"}}})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{"owner/repo@v1": "sha-cached"}, SkipCache: true}
+		sha, err := resolver.Resolve("owner/repo@v1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sha != "sha-moved" {
+			t.Fatalf("sha = %q; want the fresh %q", sha, "sha-moved")
+		}
+	})
+}
+
+func TestSHAResolver_Resolve_BranchHeadIsNotPersisted(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() != "https://api.github.com/repos/owner/repo/branches?per_page=100" {
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		b, err := json.Marshal([]BranchOrTag{{Name: "main", Commit: Commit{Sha: "sha-head"}}})
+		if err != nil {
+			return nil, err
+		}
+		return statusResponse(http.StatusOK, b), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		sha, err := resolver.Resolve("owner/repo@main")
+		if err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+		if sha != "sha-head" {
+			t.Fatalf("sha = %q; want sha-head", sha)
+		}
+	})
+
+	if actcache.CacheExists(scharfDir) {
+		t.Fatalf("branch heads must not be persisted to the cache file")
+	}
+}
+
+func TestSHAResolver_Resolve_ShortSHA(t *testing.T) {
+	const full = "abc1234def5678abc1234def5678abc1234def56"
+	cases := []struct {
+		name    string
+		version string
+	}{
+		{"7-char SHA", "abc1234"},
+		{"40-char SHA", full},
+		{"uppercase SHA", "ABC1234"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			origDir := scharfDir
+			scharfDir = t.TempDir()
+			t.Cleanup(func() { scharfDir = origDir })
+
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if want := "https://api.github.com/repos/owner/repo/commits/" + tc.version; req.URL.String() != want {
+					t.Fatalf("unexpected request to %s; want %s", req.URL, want)
+				}
+				return statusResponse(http.StatusOK, []byte(`{"sha": "`+full+`"}`)), nil
+			})
+
+			withHTTPClientTransport(customTransport, func() {
+				resolver := SHAResolver{cache: map[string]string{}}
+				sha, err := resolver.Resolve("owner/repo@" + tc.version)
+				if err != nil {
+					t.Fatalf("Resolve() returned error: %v", err)
+				}
+				if sha != full {
+					t.Fatalf("sha = %q; want the full %q", sha, full)
+				}
+			})
+		})
+	}
+}
+
+func TestSHAResolver_Resolve_LatestTagWithoutVersion(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() != "https://api.github.com/repos/owner/repo/tags?per_page=100" {
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		b, err := json.Marshal([]BranchOrTag{
+			{Name: "v1.0.0", Commit: Commit{Sha: "sha-1.0.0"}},
+			{Name: "v2.1.0", Commit: Commit{Sha: "sha-2.1.0"}},
+			{Name: "v2.0.0", Commit: Commit{Sha: "sha-2.0.0"}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return statusResponse(http.StatusOK, b), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		sha, err := resolver.Resolve("owner/repo")
+		if err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+		if sha != "sha-2.1.0" {
+			t.Fatalf("sha = %q; want the latest release sha-2.1.0", sha)
+		}
+		if v, ok := resolver.ResolvedVersion("owner/repo"); !ok || v != "v2.1.0" {
+			t.Fatalf("ResolvedVersion() = %q, %v; want v2.1.0", v, ok)
+		}
+	})
+
+	// A new release changes the answer, so it is never persisted
+	if actcache.CacheExists(scharfDir) {
+		t.Fatalf("the latest tag must not be persisted to the cache file")
+	}
+}
+
+func TestSHAResolver_Resolve_HexBranchIsNotPersisted(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	// The commits endpoint resolves a branch named like a SHA to its head
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return statusResponse(http.StatusOK, []byte(`{"sha": "0123456789abcdef0123456789abcdef01234567"}`)), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		if _, err := resolver.Resolve("owner/repo@deadbeef"); err != nil {
+			t.Fatalf("Resolve() returned error: %v", err)
+		}
+	})
+
+	if actcache.CacheExists(scharfDir) {
+		t.Fatalf("branch heads must not be persisted to the cache file")
+	}
+}
+
+// Run with -race to prove concurrent use of one resolver is safe
+func TestSHAResolver_Resolve_Concurrent(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := json.Marshal([]BranchOrTag{
+			{Name: "v1.0.0", Commit: Commit{Sha: "sha-100"}},
+			{Name: "v1.2.3", Commit: Commit{Sha: "sha-123"}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return statusResponse(http.StatusOK, b), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := &SHAResolver{cache: map[string]string{}}
+		actions := []string{"owner/a@v1.0.0", "owner/b@v1.0.0", "owner/c@v1.2", "owner/a@v1.0.0"}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				action := actions[i%len(actions)]
+				if _, err := resolver.Resolve(action); err != nil {
+					t.Errorf("Resolve(%s) returned error: %v", action, err)
+				}
+				resolver.ResolvedVersion(action)
+				resolver.MovedTo("owner/a")
+				resolver.Resolutions()
+			}(i)
+		}
+		wg.Wait()
+
+		if got := len(resolver.Resolutions()); got != 50 {
+			t.Fatalf("recorded %d resolutions; want 50", got)
+		}
+		if v, ok := resolver.ResolvedVersion("owner/c@v1.2"); !ok || v != "v1.2.3" {
+			t.Fatalf("ResolvedVersion = %q, %v; want v1.2.3, true", v, ok)
+		}
+	})
+}
+
+func TestSHAResolver_Resolve_SubPathLooksUpRepository(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	var paths []string
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		b, err := json.Marshal([]BranchOrTag{{Name: "v3", Commit: Commit{Sha: "sha-v3"}}})
+		if err != nil {
+			return nil, err
+		}
+		return statusResponse(http.StatusOK, b), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := &SHAResolver{cache: map[string]string{}}
+		for _, action := range []string{"github/codeql-action/init@v3", "owner/repo/.github/workflows/ci.yml@v3"} {
+			sha, err := resolver.Resolve(action)
+			if err != nil || sha != "sha-v3" {
+				t.Fatalf("Resolve(%s) = %q, %v; want sha-v3", action, sha, err)
+			}
+		}
+	})
+
+	want := []string{"/repos/github/codeql-action/tags", "/repos/owner/repo/tags"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("looked up %v; want %v", paths, want)
+	}
+}
+
+func TestSHAResolver_Resolve_MixedCase(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	var paths []string
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		b, err := json.Marshal([]BranchOrTag{{Name: "v4", Commit: Commit{Sha: "sha-v4"}}, {Name: "V5", Commit: Commit{Sha: "sha-V5"}}})
+		if err != nil {
+			return nil, err
+		}
+		return statusResponse(http.StatusOK, b), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := &SHAResolver{cache: map[string]string{}}
+		for _, action := range []string{"Actions/Checkout@v4", "actions/checkout@v4", "ACTIONS/checkout@v4"} {
+			sha, err := resolver.Resolve(action)
+			if err != nil || sha != "sha-v4" {
+				t.Fatalf("Resolve(%s) = %q, %v; want sha-v4", action, sha, err)
+			}
+		}
+		// Refs are case-sensitive
+		if sha, err := resolver.Resolve("Actions/Checkout@V5"); err != nil || sha != "sha-V5" {
+			t.Fatalf("Resolve(Actions/Checkout@V5) = %q, %v; want sha-V5", sha, err)
+		}
+
+		// The resolution log keeps each reference as written
+		if got := resolver.Resolutions()[0].Action; got != "Actions/Checkout" {
+			t.Errorf("first resolution = %q; want the reference as written", got)
+		}
+	})
+
+	// One lookup per ref, made with the normalized owner/repo
+	want := []string{"/repos/actions/checkout/tags", "/repos/actions/checkout/tags"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("looked up %v; want %v", paths, want)
+	}
+	if c, err := actcache.GetCache(scharfDir); err != nil || c["actions/checkout@v4"].SHA != "sha-v4" {
+		t.Fatalf("expected the normalized reference in the cache file, got %v, %v", c, err)
+	}
+}
+
+func TestNormalizeAction(t *testing.T) {
+	tests := map[string]string{
+		"Actions/Checkout@v4":          "actions/checkout@v4",
+		"Owner/Repo@Release/V1":        "owner/repo@Release/V1",
+		"GitHub/CodeQL-Action/Init@v3": "github/codeql-action/init@v3",
+		"Owner/Repo":                   "owner/repo",
+	}
+	for in, want := range tests {
+		if got := normalizeAction(in); got != want {
+			t.Errorf("normalizeAction(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
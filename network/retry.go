@@ -0,0 +1,63 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultMaxAttempts is the number of tries NewSHAResolver makes per GitHub API lookup
+const DefaultMaxAttempts = 3
+
+// retryBaseDelay is the backoff before the first retry. It doubles on every retry.
+var retryBaseDelay = 500 * time.Millisecond
+
+// isRetryable reports whether a failed API call is worth another try. Only
+// transport failures, 5xx and 429 are transient; a 404 will never change.
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		var ue *url.Error
+		return errors.As(err, &ue)
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoffDelay returns the exponential backoff with jitter before the given retry (1-based)
+func backoffDelay(retry int) time.Duration {
+	d := retryBaseDelay << (retry - 1)
+	if d <= 0 {
+		return 0
+	}
+
+	// Jitter spreads out retries of concurrent lookups hitting the same failure
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// getWithRetries calls get up to attempts times while it fails transiently.
+// The last response or error is returned as is, so callers report it normally.
+func getWithRetries(attempts int, get func() (*http.Response, string, error)) (*http.Response, string, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, movedTo, err := get()
+		if attempt >= attempts || !isRetryable(resp, err) {
+			return resp, movedTo, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		time.Sleep(backoffDelay(attempt))
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// withoutRetryDelay makes retries immediate for the duration of a test
+func withoutRetryDelay(t *testing.T) {
+	t.Helper()
+	orig := retryBaseDelay
+	retryBaseDelay = 0
+	t.Cleanup(func() { retryBaseDelay = orig })
+}
+
+func statusResponse(status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Header:     make(http.Header),
+	}
+}
+
+func TestSHAResolver_Resolve_Retries(t *testing.T) {
+	withoutRetryDelay(t)
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	tags, err := json.Marshal([]BranchOrTag{{Name: "v1", Commit: Commit{Sha: "sha-v1"}}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		respond     func(call int) (*http.Response, error)
+		wantCalls   int
+		wantErr     bool
+	}{
+		{
+			name:        "recovers after transient 5xx",
+			maxAttempts: 3,
+			respond: func(call int) (*http.Response, error) {
+				if call < 3 {
+					return statusResponse(http.StatusBadGateway, nil), nil
+				}
+				return statusResponse(http.StatusOK, tags), nil
+			},
+			wantCalls: 3,
+		},
+		{
+			name:        "gives up after max attempts",
+			maxAttempts: 3,
+			respond: func(call int) (*http.Response, error) {
+				return statusResponse(http.StatusServiceUnavailable, nil), nil
+			},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:        "retries network errors",
+			maxAttempts: 2,
+			respond: func(call int) (*http.Response, error) {
+				return nil, errors.New("connection reset by peer")
+			},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+		{
+			name:        "retries 429",
+			maxAttempts: 3,
+			respond: func(call int) (*http.Response, error) {
+				if call == 1 {
+					return statusResponse(http.StatusTooManyRequests, nil), nil
+				}
+				return statusResponse(http.StatusOK, tags), nil
+			},
+			wantCalls: 2,
+		},
+		{
+			name:        "never retries 404",
+			maxAttempts: 3,
+			respond: func(call int) (*http.Response, error) {
+				return statusResponse(http.StatusNotFound, []byte(`{"message":"Not Found"}`)), nil
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:        "zero attempts means a single try",
+			maxAttempts: 0,
+			respond: func(call int) (*http.Response, error) {
+				return statusResponse(http.StatusInternalServerError, nil), nil
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return tt.respond(calls)
+			})
+
+			withHTTPClientTransport(customTransport, func() {
+				resolver := SHAResolver{cache: map[string]string{}, MaxAttempts: tt.maxAttempts}
+				sha, err := resolver.Resolve("owner/repo@v1")
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Resolve error = %v; wantErr %v", err, tt.wantErr)
+				}
+				if !tt.wantErr && sha != "sha-v1" {
+					t.Fatalf("sha = %q; want %q", sha, "sha-v1")
+				}
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("made %d calls; want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNewSHAResolver_DefaultMaxAttempts(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	if got := NewSHAResolver().MaxAttempts; got != DefaultMaxAttempts {
+		t.Errorf("MaxAttempts = %d; want %d", got, DefaultMaxAttempts)
+	}
+}
+
+func TestBackoffDelayGrows(t *testing.T) {
+	for retry := 1; retry <= 3; retry++ {
+		base := retryBaseDelay << (retry - 1)
+		d := backoffDelay(retry)
+		if d < base || d > base+base/2 {
+			t.Errorf("backoffDelay(%d) = %v; want within [%v, %v]", retry, d, base, base+base/2)
+		}
+	}
+}
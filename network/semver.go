@@ -0,0 +1,146 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// semverRegex matches version tags, Ex: v2.1.0, 2.1 or v2.0.0-beta.1+build.5,
+// capturing the numbers and the pre-release
+var semverRegex = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// version is a parsed version tag
+type version struct {
+	numbers    []int  // Ex: [2 1 0] of v2.1.0
+	prerelease string // Ex: beta.1 of v2.0.0-beta.1, empty for a release
+}
+
+// parseVersion parses a version tag, Ex: v2.1.0 -> [2 1 0]
+func parseVersion(name string) (version, bool) {
+	m := semverRegex.FindStringSubmatch(name)
+	if m == nil {
+		return version{}, false
+	}
+
+	var v version
+	for _, part := range strings.Split(m[1], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return version{}, false
+		}
+		v.numbers = append(v.numbers, n)
+	}
+	v.prerelease = m[2]
+	return v, true
+}
+
+// comparePrereleases orders pre-releases like semver: a release is greater than
+// its pre-releases, and identifiers compare numerically when both are numbers
+// (beta.2 < beta.10)
+func comparePrereleases(a string, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(pa), len(pb)) {
+		x, errX := strconv.Atoi(pa[i])
+		y, errY := strconv.Atoi(pb[i])
+		var c int
+		switch {
+		case errX == nil && errY == nil:
+			c = cmp.Compare(x, y)
+		case errX == nil:
+			c = -1 // numeric identifiers sort first
+		case errY == nil:
+			c = 1
+		default:
+			c = strings.Compare(pa[i], pb[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(pa), len(pb))
+}
+
+// compareVersions orders two version tags, Ex: v2.0.0-beta < v2.0.0 < v2.1.0 < v10.
+// A tag of the same version with more numbers is greater (v2 < v2.0.0), and
+// names break the remaining ties so the API ordering never changes the outcome.
+func compareVersions(a string, b string) int {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	for i := range max(len(va.numbers), len(vb.numbers)) {
+		var x, y int
+		if i < len(va.numbers) {
+			x = va.numbers[i]
+		}
+		if i < len(vb.numbers) {
+			y = vb.numbers[i]
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Or(
+		comparePrereleases(va.prerelease, vb.prerelease),
+		cmp.Compare(len(va.numbers), len(vb.numbers)),
+		strings.Compare(a, b),
+	)
+}
+
+// searchLatestTag returns the SHA and name of the highest release tag of tags
+func searchLatestTag(tags []BranchOrTag) (bool, string, string) {
+	var releases []BranchOrTag
+	for _, t := range tags {
+		// Pre-releases are never picked
+		if v, ok := parseVersion(t.Name); ok && v.prerelease == "" && t.Commit.Sha != "" {
+			releases = append(releases, t)
+		}
+	}
+	if len(releases) == 0 {
+		return false, "", ""
+	}
+
+	latest := slices.MaxFunc(releases, func(a, b BranchOrTag) int {
+		return compareVersions(a.Name, b.Name)
+	})
+	return true, latest.Commit.Sha, latest.Name
+}
+
+// SortRefs orders refs by version, ascending or descending. Refs that aren't
+// version tags, Ex: main or latest, sort after the version tags either way, by name.
+func SortRefs(refs []BranchOrTag, descending bool) {
+	slices.SortStableFunc(refs, func(a, b BranchOrTag) int {
+		_, okA := parseVersion(a.Name)
+		_, okB := parseVersion(b.Name)
+		switch {
+		case okA && !okB:
+			return -1
+		case !okA && okB:
+			return 1
+		case !okA && !okB:
+			return strings.Compare(a.Name, b.Name)
+		}
+
+		if descending {
+			return compareVersions(b.Name, a.Name)
+		}
+		return compareVersions(a.Name, b.Name)
+	})
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v2.0.0", "v2.1.0", -1},
+		{"v10", "v9.9.9", 1},
+		{"v2", "v2.0.0", -1},
+		{"v1.2.3", "v1.2.3", 0},
+		{"1.2.3", "v1.2.3", -1},
+		{"v2.0.0-beta", "v2.0.0", -1},
+		{"v2.0.0-beta", "v1.2.3", 1},
+		{"v2.0.0-beta.2", "v2.0.0-beta.10", -1},
+		{"v2.0.0-alpha", "v2.0.0-beta", -1},
+	}
+
+	for _, tc := range tests {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d; want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestSearchLatestTag(t *testing.T) {
+	tags := []BranchOrTag{
+		{Name: "v1.0.0", Commit: Commit{Sha: "sha-1.0.0"}},
+		{Name: "v2.1.0", Commit: Commit{Sha: "sha-2.1.0"}},
+		{Name: "v2.0.0", Commit: Commit{Sha: "sha-2.0.0"}},
+		{Name: "v3.0.0-beta", Commit: Commit{Sha: "sha-beta"}},
+		{Name: "latest", Commit: Commit{Sha: "sha-latest"}},
+	}
+
+	found, sha, name := searchLatestTag(tags)
+	if !found || sha != "sha-2.1.0" || name != "v2.1.0" {
+		t.Fatalf("searchLatestTag() = %v, %q, %q; want v2.1.0", found, sha, name)
+	}
+
+	if found, _, _ := searchLatestTag([]BranchOrTag{{Name: "nightly", Commit: Commit{Sha: "sha"}}}); found {
+		t.Fatal("expected no release tag")
+	}
+}
+
+func TestSortRefs(t *testing.T) {
+	refs := func() []BranchOrTag {
+		var r []BranchOrTag
+		for _, name := range []string{"latest", "v1.2.3", "v2.0.0-beta", "v1"} {
+			r = append(r, BranchOrTag{Name: name})
+		}
+		return r
+	}
+	names := func(r []BranchOrTag) []string {
+		var n []string
+		for _, ref := range r {
+			n = append(n, ref.Name)
+		}
+		return n
+	}
+
+	asc := refs()
+	SortRefs(asc, false)
+	if got, want := names(asc), []string{"v1", "v1.2.3", "v2.0.0-beta", "latest"}; !slices.Equal(got, want) {
+		t.Errorf("ascending = %v; want %v", got, want)
+	}
+
+	// Non-version tags stay last, so the newest releases lead
+	desc := refs()
+	SortRefs(desc, true)
+	if got, want := names(desc), []string{"v2.0.0-beta", "v1.2.3", "v1", "latest"}; !slices.Equal(got, want) {
+		t.Errorf("descending = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const annotatedTagType = "tag"
+
+// maxTagDepth bounds how many annotated tags pointing at tags are followed
+const maxTagDepth = 5
+
+type gitTagResponse struct {
+	Object struct {
+		Sha  string `json:"sha"`
+		Type string `json:"type"`
+	} `json:"object"`
+}
+
+// dereferenceTag follows annotated tag objects through the /git/tags endpoint until
+// it reaches the commit they point to. Lightweight tags and branches are returned as is.
+func dereferenceTag(client *http.Client, base string, action string, c Commit) (string, error) {
+	sha, objType := c.Sha, c.Type
+	for depth := 0; objType == annotatedTagType; depth++ {
+		if depth >= maxTagDepth {
+			return "", fmt.Errorf("annotated tag %s of action %s nests more than %d tags", c.Sha, action, maxTagDepth)
+		}
+
+		lookupURL := fmt.Sprintf("%s/%s/git/tags/%s", reposURL(base), escapeAction(action), url.PathEscape(sha))
+		resp, err := githubAPIGet(client, lookupURL)
+		if err != nil {
+			return "", fmt.Errorf("http: %w", err)
+		}
+
+		var payload gitTagResponse
+		err = func() error {
+			defer resp.Body.Close()
+
+			if err := rateLimitError(resp); err != nil {
+				return err
+			}
+			if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+				return fmt.Errorf("http status %d dereferencing tag %s of action %s", resp.StatusCode, sha, action)
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+				return fmt.Errorf("json: %w", err)
+			}
+			return nil
+		}()
+		if err != nil {
+			return "", err
+		}
+
+		if payload.Object.Sha == "" {
+			return "", fmt.Errorf("annotated tag %s of action %s points to nothing", sha, action)
+		}
+		sha, objType = payload.Object.Sha, payload.Object.Type
+	}
+
+	return sha, nil
+}
+
+// TagsAt returns the tags of action pointing at the commit sha, newest version
+// first, Ex: to name the version of a pin without a comment. An abbreviated SHA
+// matches the commits it is a prefix of. Annotated tags are compared by the
+// commit they point to.
+func (s *SHAResolver) TagsAt(action string, sha string) ([]string, error) {
+	if !isCommitVersion(sha) {
+		return nil, fmt.Errorf("invalid commit SHA: %q. Expected 7 to 40 hex characters", sha)
+	}
+
+	tags, err := s.commitTags(actionRepository(action))
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []BranchOrTag
+	for _, t := range tags {
+		if strings.HasPrefix(t.Commit.Sha, strings.ToLower(sha)) {
+			matched = append(matched, t)
+		}
+	}
+
+	SortRefs(matched, true)
+	names := make([]string, 0, len(matched))
+	for _, t := range matched {
+		names = append(names, t.Name)
+	}
+	return names, nil
+}
+
+// commitTags lists the tags of repo with annotated tags dereferenced to the
+// commit they point to. The list is kept for the run, so naming the pins of an
+// action lists its tags once rather than once per pin.
+func (s *SHAResolver) commitTags(repo string) ([]BranchOrTag, error) {
+	s.mu.RLock()
+	tags, ok := s.commitTagsOf[repo]
+	s.mu.RUnlock()
+	if ok {
+		return tags, nil
+	}
+
+	tags, err := s.ListTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range tags {
+		commit, err := dereferenceTag(s.httpClient(), s.APIURL, repo, t.Commit)
+		if err != nil {
+			return nil, err
+		}
+		tags[i].Commit = Commit{Sha: commit}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.commitTagsOf == nil {
+		s.commitTagsOf = make(map[string][]BranchOrTag)
+	}
+	s.commitTagsOf[repo] = tags
+	return tags, nil
+}
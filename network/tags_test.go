@@ -0,0 +1,155 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestSHAResolver_Resolve_TagTypes(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	tags := []BranchOrTag{
+		{Name: "v1.0.0", Commit: Commit{Sha: "commit-lightweight"}},
+		{Name: "v2.0.0", Commit: Commit{Sha: "tag-object-v2", Type: "tag"}},
+		{Name: "v3.0.0", Commit: Commit{Sha: "tag-object-v3", Type: "tag"}},
+	}
+	gitTags := map[string]string{
+		// v2 is an annotated tag pointing at a commit
+		"/repos/owner/repo/git/tags/tag-object-v2": `{"object":{"sha":"commit-v2","type":"commit"}}`,
+		// v3 is an annotated tag pointing at another annotated tag
+		"/repos/owner/repo/git/tags/tag-object-v3":       `{"object":{"sha":"tag-object-v3-inner","type":"tag"}}`,
+		"/repos/owner/repo/git/tags/tag-object-v3-inner": `{"object":{"sha":"commit-v3","type":"commit"}}`,
+	}
+
+	tests := []struct {
+		action    string
+		wantSHA   string
+		wantCalls int
+	}{
+		{"owner/repo@v1.0.0", "commit-lightweight", 1},
+		{"owner/repo@v2.0.0", "commit-v2", 2},
+		{"owner/repo@v3.0.0", "commit-v3", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			calls := 0
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				if req.URL.Path == "/repos/owner/repo/tags" {
+					b, err := json.Marshal(tags)
+					if err != nil {
+						return nil, err
+					}
+					return statusResponse(http.StatusOK, b), nil
+				}
+				if body, ok := gitTags[req.URL.Path]; ok {
+					return statusResponse(http.StatusOK, []byte(body)), nil
+				}
+				t.Fatalf("unexpected request: %s", req.URL.String())
+				return nil, nil
+			})
+
+			withHTTPClientTransport(customTransport, func() {
+				resolver := SHAResolver{cache: map[string]string{}}
+				sha, err := resolver.Resolve(tt.action)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if sha != tt.wantSHA {
+					t.Errorf("Resolve(%q) = %q; want %q", tt.action, sha, tt.wantSHA)
+				}
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("made %d calls; want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDereferenceTag_Error(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return statusResponse(http.StatusNotFound, []byte(`{"message":"Not Found"}`)), nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if _, err := dereferenceTag(apiClient, "", "owner/repo", Commit{Sha: "tag-object", Type: "tag"}); err == nil {
+			t.Fatal("expected error when the tag object cannot be read")
+		}
+	})
+}
+
+func TestSHAResolver_TagsAt(t *testing.T) {
+	const (
+		release = "11bd71901bbe5b1630ceea73d27597364c9af683"
+		older   = "a5ac7e51b41094c92402da3b24376905380afc29"
+	)
+	tags := []BranchOrTag{
+		{Name: "v4.2.2", Commit: Commit{Sha: release}},
+		{Name: "v4.2.1", Commit: Commit{Sha: older}},
+		{Name: "v4", Commit: Commit{Sha: "tag-object-v4", Type: "tag"}},
+		{Name: "latest", Commit: Commit{Sha: release}},
+	}
+
+	requests := map[string]int{}
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests[req.URL.Path]++
+		switch req.URL.Path {
+		case "/repos/actions/checkout/tags":
+			b, err := json.Marshal(tags)
+			if err != nil {
+				return nil, err
+			}
+			return statusResponse(http.StatusOK, b), nil
+		case "/repos/actions/checkout/git/tags/tag-object-v4":
+			return statusResponse(http.StatusOK, []byte(`{"object":{"sha":"`+release+`","type":"commit"}}`)), nil
+		}
+		t.Fatalf("unexpected request: %s", req.URL.String())
+		return nil, nil
+	})
+
+	tests := []struct {
+		sha  string
+		want []string
+	}{
+		{release, []string{"v4.2.2", "v4", "latest"}},
+		{"11BD719", []string{"v4.2.2", "v4", "latest"}},
+		{older, []string{"v4.2.1"}},
+		{"0000000", []string{}},
+	}
+
+	withHTTPClientTransport(customTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}}
+		for _, tc := range tests {
+			got, err := resolver.TagsAt("actions/checkout", tc.sha)
+			if err != nil {
+				t.Fatalf("TagsAt(%q) returned error: %v", tc.sha, err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("TagsAt(%q) = %v; want %v", tc.sha, got, tc.want)
+			}
+		}
+
+		if _, err := resolver.TagsAt("actions/checkout", "v4"); err == nil {
+			t.Error("expected an error for a SHA that isn't hex")
+		}
+	})
+
+	// Every SHA is looked up in the tags listed and dereferenced by the first call
+	for path, n := range requests {
+		if n != 1 {
+			t.Errorf("%s requested %d times; want once", path, n)
+		}
+	}
+}
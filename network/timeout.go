@@ -0,0 +1,67 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPTimeoutEnv overrides the timeout of GitHub API calls, Ex: 30s
+const HTTPTimeoutEnv = "SCHARF_HTTP_TIMEOUT"
+
+// DefaultHTTPTimeout bounds a GitHub API call, so a hung connection fails the
+// lookup instead of blocking audit or find forever
+const DefaultHTTPTimeout = 10 * time.Second
+
+// apiClient makes the GitHub API calls of new resolvers and of the package level
+// lookups, Ex: GetRefList
+var apiClient = newAPIClient(DefaultHTTPTimeout)
+
+// apiRoundTripper sends requests through the per-host limits and the response
+// cache when enabled, then apiTransport. The layers are looked up per request
+// and always stacked in that order, so the settings apply whatever order they
+// are made in at startup, and never to other users of http.DefaultClient.
+type apiRoundTripper struct{}
+
+func (apiRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if apiHostLimit != nil {
+		return apiHostLimit.RoundTrip(req)
+	}
+	return belowHostLimit().RoundTrip(req)
+}
+
+// belowHostLimit is the transport requests take once they hold their host's slot
+func belowHostLimit() http.RoundTripper {
+	if apiCache != nil {
+		return apiCache
+	}
+	return apiTransport
+}
+
+// newAPIClient returns a client of the GitHub API giving up after timeout.
+// A timeout of 0 means none.
+func newAPIClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: apiRoundTripper{}, Timeout: timeout}
+}
+
+// ParseHTTPTimeout parses the timeout of GitHub API calls, Ex: 30s or 2m. 0 disables it.
+func ParseHTTPTimeout(s string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(s)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid HTTP timeout %q: want a duration, Ex: 30s or 2m. 0 disables the timeout", s)
+	}
+
+	return timeout, nil
+}
+
+// SetHTTPTimeout sets the timeout of GitHub API calls, Ex: from --http-timeout.
+// It applies to resolvers created afterwards and to package level lookups.
+func SetHTTPTimeout(timeout time.Duration) {
+	apiClient = newAPIClient(timeout)
+}
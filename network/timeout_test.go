@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// hangingTransport stalls like a hung GitHub connection, until the request is canceled
+var hangingTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-time.After(5 * time.Second):
+		return statusResponse(http.StatusOK, []byte("[]")), nil
+	}
+})
+
+func assertTimeout(t *testing.T, err error, start time.Time) {
+	t.Helper()
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("the call took %s despite the timeout", elapsed)
+	}
+}
+
+func TestSHAResolver_Resolve_Timeout(t *testing.T) {
+	origDir := scharfDir
+	scharfDir = t.TempDir()
+	t.Cleanup(func() { scharfDir = origDir })
+
+	withHTTPClientTransport(hangingTransport, func() {
+		resolver := SHAResolver{cache: map[string]string{}, Client: newAPIClient(20 * time.Millisecond)}
+		start := time.Now()
+		_, err := resolver.Resolve("owner/repo@v1")
+		assertTimeout(t, err, start)
+	})
+}
+
+func TestGetRefList_Timeout(t *testing.T) {
+	orig := apiClient
+	SetHTTPTimeout(20 * time.Millisecond)
+	t.Cleanup(func() { apiClient = orig })
+
+	withHTTPClientTransport(hangingTransport, func() {
+		start := time.Now()
+		_, err := GetRefList("owner/repo")
+		assertTimeout(t, err, start)
+	})
+}
+
+func TestParseHTTPTimeout(t *testing.T) {
+	valid := map[string]time.Duration{"30s": 30 * time.Second, "2m": 2 * time.Minute, "0": 0}
+	for s, want := range valid {
+		got, err := ParseHTTPTimeout(s)
+		if err != nil || got != want {
+			t.Errorf("ParseHTTPTimeout(%q) = %s, %v; want %s", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "10", "-1s", "soon"} {
+		if _, err := ParseHTTPTimeout(s); err == nil {
+			t.Errorf("ParseHTTPTimeout(%q) returned no error", s)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// CACertEnv names a PEM file of extra root CAs, Ex: of a TLS-inspecting proxy
+const CACertEnv = "SCHARF_CA_CERT"
+
+// apiTransport sends the GitHub API calls of apiClient, with the proxy and CA
+// settings. It is swapped in tests to answer without network access.
+var apiTransport http.RoundTripper = http.DefaultTransport.(*http.Transport).Clone()
+
+// baseTransport returns a copy of apiTransport to be customized, so the proxy
+// and CA settings compose instead of replacing each other
+func baseTransport() *http.Transport {
+	if tr, ok := apiTransport.(*http.Transport); ok {
+		return tr.Clone()
+	}
+
+	return http.DefaultTransport.(*http.Transport).Clone()
+}
+
+// SetProxy sends GitHub API requests through the HTTP proxy at rawURL.
+// Without it, the HTTPS_PROXY and NO_PROXY environment variables apply.
+func SetProxy(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %w", rawURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: expected scheme://host[:port]", rawURL)
+	}
+
+	tr := baseTransport()
+	tr.Proxy = http.ProxyURL(u)
+	apiTransport = tr
+	return nil
+}
+
+// SetCACert trusts the root CAs of the PEM file at path in addition to the system
+// ones. TLS-inspecting proxies re-sign GitHub's certificate with such a CA.
+func SetCACert(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no PEM certificates found in %s", path)
+	}
+
+	tr := baseTransport()
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tr.TLSClientConfig.RootCAs = pool
+	apiTransport = tr
+	return nil
+}
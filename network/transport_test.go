@@ -0,0 +1,128 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// restoreDefaultTransport undoes transport customizations of a test
+func restoreDefaultTransport(t *testing.T) {
+	orig := apiTransport
+	t.Cleanup(func() { apiTransport = orig })
+}
+
+func TestSetProxy_RejectsInvalidURL(t *testing.T) {
+	restoreDefaultTransport(t)
+
+	if err := SetProxy("proxy.internal"); err == nil {
+		t.Fatal("expected an error for a proxy URL without scheme")
+	}
+	if err := SetProxy("http://proxy.internal:3128"); err != nil {
+		t.Fatalf("SetProxy returned error: %v", err)
+	}
+}
+
+func TestSetCACert_TrustsCustomCA(t *testing.T) {
+	restoreDefaultTransport(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	if _, err := apiClient.Get(server.URL); err == nil {
+		t.Fatal("expected the test server's certificate to be untrusted by default")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, data, 0o600); err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+
+	if err := SetCACert(caFile); err != nil {
+		t.Fatalf("SetCACert returned error: %v", err)
+	}
+	tr, ok := apiTransport.(*http.Transport)
+	if !ok || tr.TLSClientConfig == nil || tr.TLSClientConfig.RootCAs == nil {
+		t.Fatalf("expected a CA pool on the API transport, got %#v", apiTransport)
+	}
+
+	resp, err := apiClient.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the custom CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestSetCACert_KeepsProxy(t *testing.T) {
+	restoreDefaultTransport(t)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, data, 0o600); err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+
+	if err := SetProxy("http://proxy.internal:3128"); err != nil {
+		t.Fatalf("SetProxy returned error: %v", err)
+	}
+	if err := SetCACert(caFile); err != nil {
+		t.Fatalf("SetCACert returned error: %v", err)
+	}
+
+	tr := apiTransport.(*http.Transport)
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com", nil)
+	if u, err := tr.Proxy(req); err != nil || u == nil || u.Host != "proxy.internal:3128" {
+		t.Fatalf("expected the proxy to be kept, got %v, %v", u, err)
+	}
+}
+
+func TestSetCACert_RejectsFileWithoutCertificates(t *testing.T) {
+	restoreDefaultTransport(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+
+	if err := SetCACert(caFile); err == nil {
+		t.Fatal("expected an error for a file without certificates")
+	}
+}
+
+func TestSetProxyAndCACert_LeaveDefaultClientAlone(t *testing.T) {
+	restoreDefaultTransport(t)
+	orig := http.DefaultClient.Transport
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, data, 0o600); err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+
+	if err := SetProxy("http://proxy.internal:3128"); err != nil {
+		t.Fatalf("SetProxy returned error: %v", err)
+	}
+	if err := SetCACert(caFile); err != nil {
+		t.Fatalf("SetCACert returned error: %v", err)
+	}
+	if http.DefaultClient.Transport != orig {
+		t.Fatalf("expected http.DefaultClient.Transport to be untouched, got %#v", http.DefaultClient.Transport)
+	}
+	if _, err := http.DefaultClient.Get(server.URL); err == nil {
+		t.Fatal("expected the custom CA to be trusted by the API client only")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+const (
+	// AdvisoryRemoteScriptPipe flags remote scripts piped straight into a shell
+	AdvisoryRemoteScriptPipe = "remote-script-pipe"
+	// AdvisoryUnpinnedRemoteContent flags raw GitHub files and gists fetched at a
+	// branch, tag or their latest revision rather than at a commit SHA
+	AdvisoryUnpinnedRemoteContent = "unpinned-remote-content"
+)
+
+var (
+	// runKeyRegex matches a run: key, capturing its indentation and value
+	runKeyRegex = regexp.MustCompile(`^(\s*)(?:-\s+)?run:\s*(.*)$`)
+	// remoteScriptPipeRegex matches Ex: curl -sL https://x/install.sh | sudo bash
+	remoteScriptPipeRegex = regexp.MustCompile(`\b(?:curl|wget)\b[^|]*\|\s*(?:sudo\s+(?:-\S+\s+)*)?(?:ba|z|k|da)?sh\b`)
+	// remoteContentRegex matches raw GitHub file and gist URLs, capturing the host
+	// and path, Ex: https://raw.githubusercontent.com/owner/repo/main/install.sh
+	remoteContentRegex = regexp.MustCompile(`https?://(raw\.githubusercontent\.com|gist\.githubusercontent\.com|gist\.github\.com)/([^\s'"|;)<>]+)`)
+)
+
+// Advisory is a risky pattern in a workflow or action that is not about pinning
+type Advisory struct {
+	FilePath string // workflow or action file containing the pattern
+	Line     int    // 1-based line number
+	Rule     string // Ex: remote-script-pipe
+	Text     string // offending line, trimmed
+}
+
+func (a Advisory) String() string {
+	return fmt.Sprintf("%s at %s:%d: %s", a.Rule, a.FilePath, a.Line, a.Text)
+}
+
+// runLines returns the 1-based numbers and text of the lines belonging to run:
+// steps, including the lines of multi-line (| or >) scripts
+func runLines(content []byte) ([]int, []string) {
+	var numbers []int
+	var texts []string
+
+	blockIndent := -1
+	for i, line := range strings.Split(string(content), "\n") {
+		if blockIndent >= 0 {
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			if strings.TrimSpace(line) == "" || indent > blockIndent {
+				numbers, texts = append(numbers, i+1), append(texts, line)
+				continue
+			}
+			blockIndent = -1
+		}
+
+		m := runKeyRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if strings.HasPrefix(m[2], "|") || strings.HasPrefix(m[2], ">") {
+			blockIndent = len(m[1])
+			continue
+		}
+		numbers, texts = append(numbers, i+1), append(texts, m[2])
+	}
+
+	return numbers, texts
+}
+
+// isPinnedRemoteContent reports whether a URL matched by remoteContentRegex
+// names a commit SHA. Raw files carry the ref right after owner/repo; gists
+// (user/id) carry a revision SHA in one of the following segments.
+func isPinnedRemoteContent(host string, path string) bool {
+	segments := strings.Split(path, "/")
+	if len(segments) < 3 {
+		return false
+	}
+	if host == "raw.githubusercontent.com" {
+		return commitSHARegex.MatchString(segments[2])
+	}
+	return slices.ContainsFunc(segments[2:], commitSHARegex.MatchString)
+}
+
+// hasUnpinnedRemoteContent reports whether text fetches a raw GitHub file or a
+// gist that can change under the same URL
+func hasUnpinnedRemoteContent(text string) bool {
+	for _, m := range remoteContentRegex.FindAllStringSubmatch(text, -1) {
+		if !isPinnedRemoteContent(m[1], m[2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAdvisories lists the risky run: steps of content, Ex: curl ... | bash
+func FindAdvisories(content []byte, filePath string) []Advisory {
+	var advisories []Advisory
+	numbers, texts := runLines(content)
+	for i, text := range texts {
+		if remoteScriptPipeRegex.MatchString(text) {
+			advisories = append(advisories, Advisory{
+				FilePath: filePath,
+				Line:     numbers[i],
+				Rule:     AdvisoryRemoteScriptPipe,
+				Text:     strings.TrimSpace(text),
+			})
+		}
+		// Not pinnable like uses:, but the same supply-chain risk as a mutable tag
+		if hasUnpinnedRemoteContent(text) {
+			advisories = append(advisories, Advisory{
+				FilePath: filePath,
+				Line:     numbers[i],
+				Rule:     AdvisoryUnpinnedRemoteContent,
+				Text:     strings.TrimSpace(text),
+			})
+		}
+	}
+
+	return advisories
+}
+
+// CheckAdvisories lists the advisories of every workflow and action metadata
+// file in the repository
+func CheckAdvisories(path FilePath) ([]Advisory, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	workflows, actions, err := listAuditFiles(abs)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	var advisories []Advisory
+	for _, f := range slices.Concat(workflows, actions) {
+		content, err := ReadFile(FilePath(f))
+		if err != nil {
+			if errors.Is(err, syscall.EISDIR) {
+				continue
+			}
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+
+		advisories = append(advisories, FindAdvisories(content, f)...)
+	}
+
+	return advisories, nil
+}
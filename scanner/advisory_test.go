@@ -0,0 +1,129 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const remoteContentSHA = "0123456789abcdef0123456789abcdef01234567"
+
+const pipedScriptWorkflow = `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: curl -fsSL https://example.com/install.sh | bash
+      - name: Multi-line script
+        run: |
+          echo "setting up"
+          wget -qO- https://example.com/setup.sh | sudo -E sh
+          curl -o tool.tgz https://example.com/tool.tgz
+      - name: Mentions curl | bash only in its name
+        run: echo done
+      - run: curl https://example.com/data.json | jq .
+`
+
+const pipedScriptAction = `name: setup
+runs:
+  using: composite
+  steps:
+    - shell: bash
+      run: >
+        curl -s https://example.com/get | zsh
+`
+
+func TestFindAdvisories(t *testing.T) {
+	advisories := FindAdvisories([]byte(pipedScriptWorkflow), "ci.yml")
+	if len(advisories) != 2 {
+		t.Fatalf("expected 2 advisories, got %d: %v", len(advisories), advisories)
+	}
+
+	want := []Advisory{
+		{FilePath: "ci.yml", Line: 8, Rule: AdvisoryRemoteScriptPipe, Text: "curl -fsSL https://example.com/install.sh | bash"},
+		{FilePath: "ci.yml", Line: 12, Rule: AdvisoryRemoteScriptPipe, Text: "wget -qO- https://example.com/setup.sh | sudo -E sh"},
+	}
+	for i, a := range want {
+		if advisories[i] != a {
+			t.Errorf("advisory %d = %+v; want %+v", i, advisories[i], a)
+		}
+	}
+}
+
+func TestCheckAdvisoriesIncludesActionFiles(t *testing.T) {
+	root := t.TempDir()
+	workflow := writeWorkflow(t, root, pipedScriptWorkflow)
+	action := filepath.Join(root, ".github", "actions", "setup", "action.yml")
+	writeFileAt(t, action, pipedScriptAction)
+
+	advisories, err := CheckAdvisories(FilePath(root))
+	if err != nil {
+		t.Fatalf("CheckAdvisories returned error: %v", err)
+	}
+	if len(advisories) != 3 {
+		t.Fatalf("expected 3 advisories, got %d: %v", len(advisories), advisories)
+	}
+
+	last := advisories[2]
+	if last.FilePath != action || last.Line != 7 || last.Text != "curl -s https://example.com/get | zsh" {
+		t.Fatalf("unexpected action advisory: %+v", last)
+	}
+	if advisories[0].FilePath != workflow {
+		t.Fatalf("expected workflow advisories first: %+v", advisories[0])
+	}
+}
+
+const remoteContentWorkflow = `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: curl -fsSLO https://raw.githubusercontent.com/owner/tools/main/lint.sh
+      - run: curl -fsSLO https://raw.githubusercontent.com/owner/tools/` + remoteContentSHA + `/lint.sh
+      - run: curl -fsSLO https://raw.githubusercontent.com/owner/tools/refs/tags/v1/lint.sh
+      - name: Gists
+        run: |
+          wget "https://gist.githubusercontent.com/someone/5e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b/raw/setup.sh"
+          wget "https://gist.githubusercontent.com/someone/5e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b/raw/` + remoteContentSHA + `/setup.sh"
+          git clone https://gist.github.com/someone/5e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b.git
+      - uses: owner/tools@main
+        with:
+          url: https://raw.githubusercontent.com/owner/tools/main/config.json
+`
+
+func TestFindAdvisoriesUnpinnedRemoteContent(t *testing.T) {
+	advisories := FindAdvisories([]byte(remoteContentWorkflow), "ci.yml")
+
+	var lines []int
+	for _, a := range advisories {
+		if a.Rule != AdvisoryUnpinnedRemoteContent {
+			t.Fatalf("unexpected advisory: %+v", a)
+		}
+		lines = append(lines, a.Line)
+	}
+	// SHA pins and URLs outside run: steps are not flagged
+	want := []int{7, 9, 12, 14}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("flagged lines %v; want %v: %v", lines, want, advisories)
+	}
+	if advisories[0].Text != "curl -fsSLO https://raw.githubusercontent.com/owner/tools/main/lint.sh" {
+		t.Errorf("unexpected text: %q", advisories[0].Text)
+	}
+}
+
+func TestFindAdvisoriesPipedRemoteContent(t *testing.T) {
+	// A piped, unpinned raw script breaks both rules
+	advisories := FindAdvisories([]byte("steps:\n  - run: curl -sL https://raw.githubusercontent.com/owner/tools/v1/install.sh | bash\n"), "ci.yml")
+	if len(advisories) != 2 || advisories[0].Rule != AdvisoryRemoteScriptPipe || advisories[1].Rule != AdvisoryUnpinnedRemoteContent {
+		t.Fatalf("unexpected advisories: %v", advisories)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// Caps of an archive extraction, so a decompression bomb fails the audit
+// instead of filling the disk of the CI runner
+var (
+	maxArchiveEntries       = 100_000
+	maxArchiveBytes   int64 = 2 << 30
+)
+
+// extractBudget counts the entries and bytes extracted from an archive
+// against maxArchiveEntries and maxArchiveBytes
+type extractBudget struct {
+	entries int
+	bytes   int64
+}
+
+// entry counts one more entry of the archive
+func (b *extractBudget) entry() error {
+	b.entries++
+	if b.entries > maxArchiveEntries {
+		return fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+	}
+	return nil
+}
+
+// IsArchivePath detects if a given path points to a supported repository archive
+func IsArchivePath(p string) bool {
+	lower := strings.ToLower(p)
+	return strings.HasSuffix(lower, ".tar.gz") ||
+		strings.HasSuffix(lower, ".tgz") ||
+		strings.HasSuffix(lower, ".zip")
+}
+
+// AuditArchive extracts a .tar.gz/.zip of a repository into a temporary directory,
+// audits its workflows and removes the extracted files afterwards.
+// No Git metadata is required as archives usually don't carry any. Warnings are printed.
+func AuditArchive(archivePath string, res network.Resolver) (*[]Workflow, error) {
+	report, err := AuditArchiveReport(archivePath, res, AuditOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print(report.Summary())
+	return &report.Workflows, nil
+}
+
+// AuditArchiveReport audits an archive like AuditArchive, but prints nothing and
+// returns the warnings in the report
+func AuditArchiveReport(archivePath string, res network.Resolver, opts AuditOptions) (*AuditReport, error) {
+	tmpDir, err := os.MkdirTemp("", "scharf-archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractArchive(archivePath, tmpDir); err != nil {
+		return nil, err
+	}
+
+	root, err := locateWorkflowRoot(tmpDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w in archive %s", err, archivePath)
+	}
+
+	return auditWorkflows(root, res, opts)
+}
+
+func extractArchive(archivePath string, dest string) error {
+	lower := strings.ToLower(archivePath)
+	if strings.HasSuffix(lower, ".zip") {
+		return extractZip(archivePath, dest)
+	}
+
+	return extractTarGz(archivePath, dest)
+}
+
+// safeJoin joins an archive entry name to dest and refuses entries that
+// would escape dest (Ex: ../../etc/passwd)
+func safeJoin(dest string, name string) (string, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if target != dest && !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+		return "", fmt.Errorf("illegal file path in archive: %s", name)
+	}
+
+	return target, nil
+}
+
+func writeArchiveFile(target string, r io.Reader, budget *extractBudget) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("creating dir: %w", err)
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", target, err)
+	}
+	defer f.Close()
+
+	// Reading one byte past the budget tells a file at the cap from a larger one
+	n, err := io.Copy(f, io.LimitReader(r, maxArchiveBytes-budget.bytes+1))
+	budget.bytes += n
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", target, err)
+	}
+	if budget.bytes > maxArchiveBytes {
+		return fmt.Errorf("archive extracts to more than %d bytes", maxArchiveBytes)
+	}
+
+	return nil
+}
+
+func extractTarGz(archivePath string, dest string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("gzip: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	budget := &extractBudget{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("tar: %w", err)
+		}
+		if err := budget.entry(); err != nil {
+			return err
+		}
+
+		target, err := safeJoin(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		// Symlinks and other special entries are skipped on purpose; workflows are plain files
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return fmt.Errorf("creating dir: %w", err)
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, tr, budget); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(archivePath string, dest string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+	defer zr.Close()
+
+	budget := &extractBudget{}
+	for _, zf := range zr.File {
+		if err := budget.entry(); err != nil {
+			return err
+		}
+
+		target, err := safeJoin(dest, zf.Name)
+		if err != nil {
+			return err
+		}
+
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return fmt.Errorf("creating dir: %w", err)
+			}
+			continue
+		}
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("zip: %w", err)
+		}
+		err = writeArchiveFile(target, rc, budget)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// locateWorkflowRoot finds the repository root inside an extracted archive.
+// GitHub source archives wrap everything in a single top-level directory
+// (Ex: repo-main/), so that directory is checked as well.
+func locateWorkflowRoot(dir string) (string, error) {
+	if hasWorkflowDir(dir) {
+		return dir, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("os: %w", err)
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		nested := filepath.Join(dir, entries[0].Name())
+		if hasWorkflowDir(nested) {
+			return nested, nil
+		}
+	}
+
+	return "", fmt.Errorf("no workflow directory (%s) found", strings.Join(WorkflowDirs(), ", "))
+}
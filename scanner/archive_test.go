@@ -0,0 +1,158 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var archiveFixture = map[string]string{
+	"repo-main/README.md":                  "# repo",
+	"repo-main/.github/workflows/ci.yml":   "steps:\n  - uses: actions/checkout@v4\n",
+	"repo-main/.github/workflows/lint.yml": "steps:\n  - uses: actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v4\n",
+}
+
+func writeTarGzFixture(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	CheckIfError(err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		CheckIfError(tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(content))
+		CheckIfError(err)
+	}
+	CheckIfError(tw.Close())
+	CheckIfError(gz.Close())
+}
+
+func writeZipFixture(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	CheckIfError(err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		CheckIfError(err)
+		_, err = w.Write([]byte(content))
+		CheckIfError(err)
+	}
+	CheckIfError(zw.Close())
+}
+
+func TestIsArchivePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"repo.tar.gz", true},
+		{"repo.TGZ", true},
+		{"/tmp/repo.zip", true},
+		{"repo", false},
+		{"https://github.com/owner/repo.git", false},
+	}
+	for _, tc := range tests {
+		if got := IsArchivePath(tc.path); got != tc.expected {
+			t.Errorf("IsArchivePath(%q) = %v; want %v", tc.path, got, tc.expected)
+		}
+	}
+}
+
+func TestAuditArchive(t *testing.T) {
+	fixtures := map[string]func(*testing.T, string, map[string]string){
+		"repo.tar.gz": writeTarGzFixture,
+		"repo.zip":    writeZipFixture,
+	}
+
+	for name, write := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			// Point temp dirs at a private location to observe the cleanup
+			tmpRoot := t.TempDir()
+			t.Setenv("TMPDIR", tmpRoot)
+
+			archive := filepath.Join(t.TempDir(), name)
+			write(t, archive, archiveFixture)
+
+			var wfs *[]Workflow
+			captureStdout(t, func() {
+				var err error
+				wfs, err = AuditArchive(archive, staticResolver{sha: "sha"})
+				if err != nil {
+					t.Fatalf("AuditArchive returned error: %v", err)
+				}
+			})
+
+			if len(*wfs) != 1 {
+				t.Fatalf("expected 1 workflow with findings, got %d", len(*wfs))
+			}
+			issues := (*wfs)[0].Issues
+			if len(issues) != 1 || issues[0].Original != "actions/checkout@v4" {
+				t.Fatalf("unexpected issues: %+v", issues)
+			}
+
+			left, err := os.ReadDir(tmpRoot)
+			CheckIfError(err)
+			if len(left) != 0 {
+				t.Fatalf("expected extracted files to be cleaned up, found %d entries", len(left))
+			}
+		})
+	}
+}
+
+func TestAuditArchiveWithoutWorkflows(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "repo.tar.gz")
+	writeTarGzFixture(t, archive, map[string]string{"repo/README.md": "# repo"})
+
+	if _, err := AuditArchive(archive, staticResolver{sha: "sha"}); err == nil {
+		t.Fatal("expected error for archive without workflows, got nil")
+	}
+}
+
+func TestAuditArchiveRejectsPathTraversal(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil.zip")
+	writeZipFixture(t, archive, map[string]string{"../escape.yml": "uses: actions/checkout@v4"})
+
+	if _, err := AuditArchive(archive, staticResolver{sha: "sha"}); err == nil {
+		t.Fatal("expected error for path traversal entry, got nil")
+	}
+}
+
+func TestAuditArchiveCapsExtraction(t *testing.T) {
+	origEntries, origBytes := maxArchiveEntries, maxArchiveBytes
+	t.Cleanup(func() { maxArchiveEntries, maxArchiveBytes = origEntries, origBytes })
+
+	for _, ext := range []string{"tar.gz", "zip"} {
+		archive := filepath.Join(t.TempDir(), "repo."+ext)
+		if ext == "zip" {
+			writeZipFixture(t, archive, archiveFixture)
+		} else {
+			writeTarGzFixture(t, archive, archiveFixture)
+		}
+
+		maxArchiveEntries, maxArchiveBytes = 2, origBytes
+		if _, err := AuditArchiveReport(archive, staticResolver{sha: "sha"}, AuditOptions{}); err == nil || !strings.Contains(err.Error(), "more than 2 entries") {
+			t.Errorf("%s: err = %v; want the entry cap error", ext, err)
+		}
+
+		maxArchiveEntries, maxArchiveBytes = origEntries, 40
+		if _, err := AuditArchiveReport(archive, staticResolver{sha: "sha"}, AuditOptions{}); err == nil || !strings.Contains(err.Error(), "more than 40 bytes") {
+			t.Errorf("%s: err = %v; want the size cap error", ext, err)
+		}
+	}
+}
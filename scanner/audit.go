@@ -7,12 +7,18 @@
 package scanner
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"syscall"
 
+	"github.com/cybrota/scharf/config"
 	"github.com/cybrota/scharf/git"
 	"github.com/cybrota/scharf/logging"
 	"github.com/cybrota/scharf/network"
@@ -22,42 +28,246 @@ var logger = logging.GetLogger(0)
 
 const SHA256NotAvailable = "N/A"
 
-// AssembleWorkflow builds printable workflows with structure suitable for formatting
+// movedResolver is implemented by resolvers that detect renamed or transferred action repositories
+type movedResolver interface {
+	MovedTo(action string) (string, bool)
+}
+
+// concreteVersionResolver is implemented by resolvers that resolve partial versions
+// (Ex: v4.2) to a concrete tag (Ex: v4.2.3)
+type concreteVersionResolver interface {
+	ResolvedVersion(action string) (string, bool)
+}
+
+// AuditOptions are the settings of an audit given by its caller, Ex: from flags.
+// They add to the repository's .scharf.yml and .scharfignore.
+type AuditOptions struct {
+	// TrustedOwners are owner globs (Ex: actions, my-org-*) whose actions are never
+	// flagged, nor resolved. See ValidateTrustedOwners.
+	TrustedOwners []string
+	// IgnoreLines are the locations whose findings are excluded. See ValidateIgnoreLines.
+	IgnoreLines []string
+}
+
+// AutoFixOptions controls how AutoFixRepository applies fixes
+type AutoFixOptions struct {
+	DryRun bool // preview fixes without writing files
+	// RewriteMoved rewrites references of renamed action repositories to their new owner/repo
+	RewriteMoved bool
+	// RequireClean aborts when the worktree has uncommitted changes, keeping pin changes isolated
+	RequireClean bool
+	// CommentStyle decides the version comment written after a pinned SHA
+	CommentStyle CommentStyle
+	// SkipBranches leaves branch references (Ex: @main) as they are. Otherwise they
+	// are pinned to the branch's current head, a snapshot needing periodic refresh.
+	SkipBranches bool
+	// ExitNonzeroOnChanges makes AutoFixExitCode signal that fixes were applied, or would be
+	ExitNonzeroOnChanges bool
+	// Normalize rewrites already pinned references to owner/repo@<sha> # <version> too
+	Normalize bool
+	// VerifyAfterFix re-parses each fixed file as YAML and refuses to write it
+	// when the fixes broke it
+	VerifyAfterFix bool
+}
+
+var commitSHARegex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// isPinnedReference reports whether the reference starting at start is pinned to a
+// full commit SHA. The action regex has no end anchor, so pinned references are
+// excluded here explicitly instead of relying on it never matching a SHA.
+func isPinnedReference(content []byte, start int) bool {
+	at := bytes.IndexByte(content[start:], '@')
+	if at < 0 {
+		return false
+	}
+
+	refStart := start + at + 1
+	refEnd := refStart
+	for refEnd < len(content) {
+		c := content[refEnd]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '"' || c == '\'' || c == '#' {
+			break
+		}
+		refEnd++
+	}
+
+	return commitSHARegex.Match(content[refStart:refEnd])
+}
+
+// dynamicReference returns the whole value of the reference at start..end when it
+// is built from an expression, Ex: owner/action@v1-${{ matrix.suffix }}. The regex
+// only matches its static part; the reference is known at run time only, so
+// pinning that part would break the workflow.
+func dynamicReference(content []byte, start int, end int) (string, bool) {
+	isBoundary := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '"' || c == '\''
+	}
+	tokenStart := start
+	for tokenStart > 0 && !isBoundary(content[tokenStart-1]) {
+		tokenStart--
+	}
+	tokenEnd := end
+	for tokenEnd < len(content) && !isBoundary(content[tokenEnd]) {
+		tokenEnd++
+	}
+
+	token := content[tokenStart:tokenEnd]
+	if !bytes.Contains(token, []byte("${{")) && !bytes.Contains(token, []byte("}}")) {
+		return "", false
+	}
+	// The expression may hold spaces, Ex: ${{ matrix.suffix }}; report it whole
+	if open := bytes.LastIndex(token, []byte("${{")); open >= 0 && !bytes.Contains(token[open:], []byte("}}")) {
+		rest := content[tokenEnd:]
+		if nl := bytes.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[:nl]
+		}
+		if i := bytes.Index(rest, []byte("}}")); i >= 0 {
+			tokenEnd += i + len("}}")
+		}
+	}
+
+	return string(content[tokenStart:tokenEnd]), true
+}
+
+// splitActionPath splits an action into its owner/repo and the path of a
+// sub-action or reusable workflow, Ex: owner/repo/.github/workflows/ci.yml ->
+// owner/repo, /.github/workflows/ci.yml
+func splitActionPath(action string) (string, string) {
+	segments := strings.SplitN(action, "/", 3)
+	if len(segments) < 3 {
+		return action, ""
+	}
+
+	return segments[0] + "/" + segments[1], "/" + segments[2]
+}
+
+// AssembleWorkflow builds printable workflows with structure suitable for formatting.
 func AssembleWorkflow(res network.Resolver, content []byte, fileName string, filePath string) (*Workflow, error) {
-	matches, err := ScanContentWithPosition(content, findRegex)
+	return assembleWorkflow(res, content, fileName, filePath, nil, nil)
+}
+
+// workflowMatches returns the matches of regex in a workflow, from its uses:
+// keys when YAML parsing is on and the content parses, else from its raw lines
+func workflowMatches(content []byte, regex *regexp.Regexp) ([]Match, error) {
+	if yamlParse {
+		if matches, parsed := ScanWorkflowUses(content, regex); parsed {
+			return matches, nil
+		}
+	}
+
+	return ScanContentWithPosition(content, regex)
+}
+
+// assembleWorkflow is AssembleWorkflow skipping the actions of the given trusted owner globs
+func assembleWorkflow(res network.Resolver, content []byte, fileName string, filePath string, trusted []string, ignores []string) (*Workflow, error) {
+	matches, err := workflowMatches(content, findRegex)
 	if err != nil {
 		return nil, fmt.Errorf("%sThere is a problem scanning the given file%s%s", Yellow, fileName, Reset)
 	}
 	// 4) Map matches -> findings
-	var issues []Finding
+	var issues, ignored []Finding
 	for _, m := range matches {
+		// Same-repository references are immutable with the repository itself
+		if isInLocalReference(content, m.StartOffset) {
+			continue
+		}
+		// Already pinned references are left alone, so autofix can be re-run safely
+		if isPinnedReference(content, m.StartOffset) {
+			continue
+		}
+
 		var fm string
 		// m.Text is something like "actions/checkout@v1.2"
 		parts := strings.SplitN(m.Text, "@", 2)
 		action := parts[0]
 		version := parts[1]
 
+		// Trusted owners are never flagged, nor resolved
+		if isTrustedOwner(trusted, action) {
+			continue
+		}
+
 		original := fmt.Sprintf("%s@%s", action, version)
+		// Actions ignored by .scharfignore are kept aside, unresolved, so unused
+		// ignore patterns can still be reported
+		if pattern, ok := ignoredActionBy(ignores, action); ok {
+			ignored = append(ignored, Finding{
+				Line:        m.Line,
+				Column:      m.Col,
+				StartOffset: m.StartOffset,
+				EndOffset:   m.EndOffset,
+				Description: fmt.Sprintf("Unpinned GitHub Action: uses `%s`", m.Text),
+				Version:     version,
+				Action:      action,
+				Original:    original,
+				Severity:    ClassifySeverity(version),
+				IgnoredBy:   scharfIgnoreRule(pattern),
+			})
+			continue
+		}
+
+		// Never resolved: a SHA of the static part would make a bad fix
+		if ref, dynamic := dynamicReference(content, m.StartOffset, m.EndOffset); dynamic {
+			issues = append(issues, Finding{
+				Line:        m.Line,
+				Column:      m.Col,
+				StartOffset: m.StartOffset,
+				EndOffset:   m.EndOffset,
+				Description: fmt.Sprintf("Dynamic GitHub Action reference: uses `%s`", ref),
+				FixMsg:      fmt.Sprintf("Dynamic reference '%s' (cannot pin): it is built from an expression only known at run time. Use a static reference per matrix entry instead.", ref),
+				FixSHA:      SHA256NotAvailable,
+				Version:     version,
+				Action:      action,
+				Original:    original,
+				Severity:    ClassifySeverity(version),
+			})
+			continue
+		}
+
 		msg := fmt.Sprintf("Unpinned GitHub Action: uses `%s`", m.Text)
 		resolvedSHA, err := res.Resolve(original)
 
-		if err != nil {
+		movedTo := ""
+		resolvedVersion := ""
+		if errors.Is(err, network.ErrRateLimited) {
+			// The reference may well exist; GitHub just refused to tell
+			fm = fmt.Sprintf("Could not resolve '%s': %s", original, err.Error())
+			resolvedSHA = SHA256NotAvailable
+		} else if err != nil {
 			fm = fmt.Sprintf("Reference '%s' is not found on GitHub. Try 'scharf list %s' to see available versions.", version, action)
 			resolvedSHA = SHA256NotAvailable
 		} else {
 			// Build a human-readable message & a suggested fix
 			fm = fmt.Sprintf("Pin `%s` to %s", action, resolvedSHA)
+			if cr, ok := res.(concreteVersionResolver); ok {
+				if v, partial := cr.ResolvedVersion(original); partial {
+					resolvedVersion = v
+					fm = fmt.Sprintf("Pin `%s` to %s (%s, latest patch of %s)", action, resolvedSHA, v, version)
+				}
+			}
+			if mr, ok := res.(movedResolver); ok {
+				repo, path := splitActionPath(action)
+				if to, moved := mr.MovedTo(repo); moved {
+					movedTo = to + path
+					fm = fmt.Sprintf("action moved: %s → %s. Pin `%s` to %s", action, movedTo, movedTo, resolvedSHA)
+				}
+			}
 		}
 
 		issues = append(issues, Finding{
-			Line:        m.Line,
-			Column:      m.Col,
-			Description: msg,
-			FixMsg:      fm,
-			FixSHA:      resolvedSHA,
-			Version:     version,
-			Action:      action,
-			Original:    original,
+			Line:            m.Line,
+			Column:          m.Col,
+			StartOffset:     m.StartOffset,
+			EndOffset:       m.EndOffset,
+			Description:     msg,
+			FixMsg:          fm,
+			FixSHA:          resolvedSHA,
+			Version:         version,
+			Action:          action,
+			Original:        original,
+			Severity:        ClassifySeverity(version),
+			MovedTo:         movedTo,
+			ResolvedVersion: resolvedVersion,
 		})
 	}
 
@@ -66,11 +276,25 @@ func AssembleWorkflow(res network.Resolver, content []byte, fileName string, fil
 		Name:     filePath,
 		FilePath: filePath,
 		Issues:   issues,
+		Ignored:  ignored,
 	}, nil
 }
 
 // AuditRepository collects inventory details from current Git repository.
-func AuditRepository(path FilePath) (*[]Workflow, error) {
+// res is used to resolve each mutable reference to its SHA. Warnings are printed.
+func AuditRepository(path FilePath, res network.Resolver) (*[]Workflow, error) {
+	report, err := AuditRepositoryReport(path, res, AuditOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print(report.Summary())
+	return &report.Workflows, nil
+}
+
+// AuditRepositoryReport audits a Git repository like AuditRepository, but prints
+// nothing and returns the warnings in the report
+func AuditRepositoryReport(path FilePath, res network.Resolver, opts AuditOptions) (*AuditReport, error) {
 	abs, err := filepath.Abs(filepath.Join(string(path)))
 	if err != nil {
 		logger.Error("failed to find absolute path", "err", err)
@@ -81,90 +305,383 @@ func AuditRepository(path FilePath) (*[]Workflow, error) {
 		return nil, fmt.Errorf("The directory: %s is not a Git repository", abs)
 	}
 
-	// paths := strings.Split(abs, "/")
-	loc := filepath.Join(abs, ".github", "workflows")
+	return auditWorkflows(abs, res, opts)
+}
+
+// AuditStagedReport audits only the workflow files staged for the next commit
+// of the Git repository at path, as a pre-commit hook does. Their staged content
+// is read, not the working tree's, so the audit sees exactly what is committed.
+// Without staged workflow files the report is empty.
+func AuditStagedReport(path FilePath, res network.Resolver, opts AuditOptions) (*AuditReport, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	if !git.IsGitRepo(abs) {
+		return nil, fmt.Errorf("The directory: %s is not a Git repository", abs)
+	}
+
+	settings, err := loadAuditRules(abs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := git.StagedFiles(abs, WorkflowDirs())
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+
+	contents := map[string][]byte{}
+	var files []string
+	for name, content := range staged {
+		if !strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		f := filepath.Join(abs, filepath.FromSlash(name))
+		contents[f] = content
+		files = append(files, f)
+	}
+	slices.Sort(files)
+
+	report := auditFiles(abs, files, func(f string) ([]byte, error) {
+		return contents[f], nil
+	}, settings, res)
+	report.WorkflowFiles = len(files)
+	return report, nil
+}
+
+// auditRules are the repository and caller settings that shape an audit
+type auditRules struct {
+	overrides map[string]Severity
+	pathRules map[string]PathRuleSet
+	ignores   []string
+	trusted   []string
+	lines     []lineIgnore
+}
+
+// loadAuditRules loads the audit settings of the repository root from .scharf.yml
+// and .scharfignore, and adds those of opts
+func loadAuditRules(abs string, opts AuditOptions) (*auditRules, error) {
+	cfg, err := config.LoadFromRepo(abs)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	overrides, err := ParseSeverityOverrides(cfg.SeverityOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	pathRules, err := ParsePathRules(cfg.PathRules)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	ignores, err := LoadScharfIgnore(abs)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	if err := ValidateTrustedOwners(cfg.TrustedOwners); err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+	if err := ValidateTrustedOwners(opts.TrustedOwners); err != nil {
+		return nil, err
+	}
 
-	fileNames, err := ListFiles(FilePath(loc))
+	lines, err := parseIgnoreLines(opts.IgnoreLines)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auditRules{overrides: overrides, pathRules: pathRules, ignores: ignores, trusted: slices.Concat(opts.TrustedOwners, cfg.TrustedOwners), lines: lines}, nil
+}
+
+// auditWorkflows audits the workflows and action metadata files of an already
+// located repository root
+func auditWorkflows(abs string, res network.Resolver, opts AuditOptions) (*AuditReport, error) {
+	settings, err := loadAuditRules(abs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	workflows, actions, err := listAuditFiles(abs)
 	if err != nil {
 		return nil, fmt.Errorf("file error: %w", err)
 	}
 
-	fmt.Printf("No of workflows: %s%d%s\n\n", Blue, len(fileNames), Reset)
+	report := auditFiles(abs, slices.Concat(workflows, actions), func(f string) ([]byte, error) {
+		return ReadFile(FilePath(f))
+	}, settings, res)
+	report.WorkflowFiles, report.ActionFiles = len(workflows), len(actions)
+	return report, nil
+}
+
+// auditFiles audits the given files of the repository root abs, reading their
+// content with read, Ex: from disk or from the Git index
+func auditFiles(abs string, files []string, read func(f string) ([]byte, error), settings *auditRules, res network.Resolver) *AuditReport {
+	type fileContent struct {
+		content []byte
+		err     error
+	}
+	// Files are read up front, so the references of all of them are resolved in parallel
+	contents := make(map[string]fileContent, len(files))
+	var refs []string
+	for _, f := range files {
+		if rel, err := filepath.Rel(abs, f); err == nil {
+			if _, ok := ignoredPathBy(settings.ignores, filepath.ToSlash(rel)); ok {
+				continue
+			}
+		}
+		content, err := read(f)
+		contents[f] = fileContent{content: content, err: err}
+		if err == nil {
+			refs = append(refs, resolvableRefs(content, settings.trusted, settings.ignores)...)
+		}
+	}
+	prefetched := prefetchResolutions(res, refs)
+	defer progress.finish()
+
+	report := AuditReport{Root: abs}
+	// Process each workflow file, then the action metadata files of composite actions
+	for _, f := range files {
+		rel, relErr := filepath.Rel(abs, f)
+		rel = filepath.ToSlash(rel)
+		// Ignored files are never read, so their actions are never resolved
+		if relErr == nil {
+			if pattern, ok := ignoredPathBy(settings.ignores, rel); ok {
+				report.Workflows = append(report.Workflows, Workflow{Name: f, FilePath: f, IgnoredBy: scharfIgnoreRule(pattern)})
+				continue
+			}
+		}
 
-	var wfs []Workflow
-	res := network.NewSHAResolver()
-	// Process each file found in the directory.
-	for _, fileName := range fileNames {
-		f := filepath.Join(loc, string(*fileName))
-		content, err := ReadFile(FilePath(f))
+		content, err := contents[f].content, contents[f].err
 		if err != nil {
 			if errors.Is(err, syscall.EISDIR) {
 				continue // This is an accidental directory. Move to the next file
-			} else {
-				return nil, fmt.Errorf("file error: %w", err)
 			}
+			// Keep auditing the other files, but tell consumers the result is partial
+			report.Warnings = append(report.Warnings, Warning{
+				Kind:    WarningUnreadableFile,
+				File:    f,
+				Message: fmt.Sprintf("could not read workflow file: %s", err.Error()),
+			})
+			continue
 		}
 
-		wf, _ := AssembleWorkflow(res, content, string(*fileName), f)
-		if len(wf.Issues) > 0 {
-			wfs = append(wfs, *wf)
+		wf, _ := assembleWorkflow(prefetched, content, filepath.Base(f), f, settings.trusted, settings.ignores)
+		var kept []Finding
+		for _, issue := range wf.Issues {
+			if spec, ok := ignoredLineBy(settings.lines, rel, issue.Line); relErr == nil && ok {
+				issue.IgnoredBy = IgnoreRule{Source: IgnoreLineSource, Pattern: spec}
+				wf.Ignored = append(wf.Ignored, issue)
+				continue
+			}
+			kept = append(kept, issue)
+		}
+		wf.Issues = kept
+
+		for i := range wf.Issues {
+			wf.Issues[i].Severity = SeverityFor(wf.Issues[i].Action, wf.Issues[i].Version, settings.overrides)
+			if wf.Issues[i].FixSHA == SHA256NotAvailable {
+				report.Warnings = append(report.Warnings, Warning{
+					Kind:    WarningUnresolvedReference,
+					File:    f,
+					Line:    wf.Issues[i].Line,
+					Action:  wf.Issues[i].Original,
+					Message: wf.Issues[i].FixMsg,
+				})
+			}
+		}
+
+		if relErr == nil {
+			if glob, ok := mostSpecificMatch(settings.pathRules, rel, matchesPath); ok {
+				rules := settings.pathRules[glob]
+				var kept []Finding
+				for _, issue := range wf.Issues {
+					// Ignored findings are kept aside so unused ignore patterns can be reported
+					if pattern, ignored := rules.IgnoredBy(issue); ignored {
+						issue.IgnoredBy = IgnoreRule{Source: pathRuleSource(glob), Pattern: pattern}
+						wf.Ignored = append(wf.Ignored, issue)
+						continue
+					}
+					if rules.Allows(issue) {
+						kept = append(kept, issue)
+					}
+				}
+				wf.Issues = kept
+			}
+		}
+		if len(wf.Issues) > 0 || len(wf.Ignored) > 0 {
+			report.Workflows = append(report.Workflows, *wf)
 		}
 	}
 
-	return &wfs, nil
+	return &report
 }
 
 // AutoFixRepository tries to match and replace third-party action references with SHA
-// It uses SHA resolution to find accurate SHA
-func AutoFixRepository(path FilePath, isDryRun bool) error {
-	wfs, err := AuditRepository(path)
+// It uses SHA resolution to find accurate SHA. It returns the number of fixes
+// applied, or that would be applied in a dry run, so callers can stay quiet on
+// clean repositories.
+func AutoFixRepository(path FilePath, res network.Resolver, opts AutoFixOptions) (int, error) {
+	if opts.RequireClean && git.IsGitRepo(string(path)) {
+		clean, err := git.IsWorktreeClean(string(path))
+		if err != nil {
+			return 0, fmt.Errorf("git error: %w", err)
+		}
+		if !clean {
+			return 0, fmt.Errorf("The repository: %s has uncommitted changes. Commit or stash them before running autofix", path)
+		}
+	}
+
+	wfs, err := AuditRepository(path, res)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	total := 0
+	for _, wf := range *wfs {
+		total += len(wf.Issues)
+	}
+
+	// With normalization, pinned references may still be rewritten
+	if total == 0 && !opts.Normalize {
+		fmt.Println("No actions to fix")
+		return 0, nil
+	}
+
+	if opts.CommentStyle == CommentStyleSemver {
+		resolveSemverComments(*wfs, res)
 	}
 
+	// Files are written once all are fixed, so a failed verification leaves every file unchanged
+	applied := 0
+	pending := map[string][]byte{}
 	for _, wf := range *wfs {
+		// Headers are only useful when there is something to report for the file
+		if len(wf.Issues) == 0 {
+			continue
+		}
 		fmt.Printf("🪄 Fixing %s%s%s: \n", Cyan, wf.FilePath, Reset)
-		ApplyFixesInFile(wf, isDryRun)
+		fix, err := rewriteInMemory(wf, fixReplacer(opts, os.Stdout))
+		if err != nil {
+			return 0, fmt.Errorf("file error: %w", err)
+		}
+		if fix.Applied > 0 {
+			pending[wf.FilePath] = fix.After
+		}
+		applied += fix.Applied
+	}
+
+	if opts.Normalize {
+		n, err := normalizeInPlace(path, newPinNormalizer(res, opts.CommentStyle, os.Stdout), pending, opts.VerifyAfterFix, opts.DryRun)
+		if err != nil {
+			return 0, err
+		}
+		applied += n
+		if applied == 0 {
+			fmt.Println("No actions to fix")
+		}
+	} else if err := writeFixedFiles(pending, opts.VerifyAfterFix, opts.DryRun); err != nil {
+		return 0, err
 	}
 
-	if isDryRun {
+	if opts.DryRun {
 		fmt.Println("The displayed fixes are not staged. Re-run 'scharf autofix' and omit the flag '--dry-run' to apply fixes.")
 	}
-	return nil
+	return applied, nil
+}
+
+// AutoFixFile pins the mutable references of a single workflow file in place. No Git
+// repository or .scharf.yml is needed, which suits editor "format on save" hooks.
+// It returns the number of fixes applied, or that would be applied in a dry run.
+func AutoFixFile(path FilePath, res network.Resolver, opts AutoFixOptions) (int, error) {
+	content, err := ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("file error: %w", err)
+	}
+
+	wf, err := AssembleWorkflow(res, content, filepath.Base(string(path)), string(path))
+	if err != nil {
+		return 0, err
+	}
+	if len(wf.Issues) == 0 {
+		fmt.Println("No actions to fix")
+		return 0, nil
+	}
+
+	if opts.CommentStyle == CommentStyleSemver {
+		resolveSemverComments([]Workflow{*wf}, res)
+	}
+
+	fmt.Printf("🪄 Fixing %s%s%s: \n", Cyan, wf.FilePath, Reset)
+	applied, err := ApplyFixesInFile(*wf, opts)
+	if err != nil {
+		return 0, fmt.Errorf("file error: %w", err)
+	}
+
+	return applied, nil
+}
+
+// AutoFixExitCode is the process exit code after autofix applied the given number
+// of fixes. With ExitNonzeroOnChanges, pre-commit hooks and CI gates fail when
+// fixes were applied, or would be in a dry run.
+func AutoFixExitCode(applied int, opts AutoFixOptions) int {
+	if opts.ExitNonzeroOnChanges && applied > 0 {
+		return 1
+	}
+
+	return 0
 }
 
+// cloneRepoToTemp is swapped in tests to clone without network access
+var cloneRepoToTemp = git.CloneRepoToTemp
+
+// noCleanup is the cleanup of paths scharf didn't create
+func noCleanup() {}
+
 // BuildRepoPath builds a repo path from arguments
 // If repo is a local path, absolute path is returned
 // If repo is a cloud URL, repository is cloned into a temporary directory for operation.
-func BuildRepoPath(action string, args []string) (*FilePath, error) {
+// The returned cleanup removes that clone and is a no-op for local paths, so callers
+// can always defer it. Cloning progress is written to w.
+func BuildRepoPath(action string, args []string, w io.Writer) (*FilePath, func(), error) {
 	if len(args) > 0 {
 		repo := args[0]
 
 		if strings.HasPrefix(repo, "https://") || strings.HasPrefix(repo, "git@") ||
 			strings.HasPrefix(repo, "ssh://") {
-			if action == "audit" || action == "autofix" || action == "upgrade-all-sha" {
-				fmt.Printf("Cloning repository: %s%s%s\n", Blue, repo, Reset)
-				tmp_path, err := git.CloneRepoToTemp(repo)
+			if action == "audit" || action == "autofix" || action == "upgrade-all-sha" || action == "diff-pins" ||
+				action == "verify" {
+				fmt.Fprintf(w, "Cloning repository: %s%s%s\n", Blue, repo, Reset)
+				tmp_path, err := cloneRepoToTemp(repo)
 				if err != nil {
 					if strings.HasPrefix(repo, "https://") {
-						return nil, fmt.Errorf("%sProblem encountered while cloning: %s.%s Use SSH instead of HTTPS, Ex: git@github.com:psf/requests.git", Red, repo, Reset)
+						return nil, noCleanup, fmt.Errorf("%sProblem encountered while cloning: %s.%s Set GITHUB_TOKEN for private repositories, or use SSH instead of HTTPS, Ex: git@github.com:psf/requests.git", Red, repo, Reset)
 					}
-					return nil, fmt.Errorf("Problem encountered while cloning: %s. Maybe the repository is private ?", repo)
+					return nil, noCleanup, fmt.Errorf("Problem encountered while cloning: %s. Maybe the repository is private ?", repo)
 				}
 
 				res := FilePath(tmp_path)
-				fmt.Printf("Cloned %s%s%s into %s%s%s\n", Blue, repo, Reset, Blue, tmp_path, Reset)
-				return &res, nil
+				fmt.Fprintf(w, "Cloned %s%s%s into %s%s%s\n", Blue, repo, Reset, Blue, tmp_path, Reset)
+				cleanup := func() {
+					if err := os.RemoveAll(tmp_path); err != nil {
+						logger.Warn("Couldn't remove cloned repository", "path", tmp_path, "error", err)
+					}
+				}
+				return &res, cleanup, nil
 			} else {
-				return nil, fmt.Errorf("%sUnsupported action:%s %s", Red, repo, Reset)
+				return nil, noCleanup, fmt.Errorf("%sUnsupported action:%s %s", Red, repo, Reset)
 			}
 		} else {
 			res := FilePath(repo)
-			return &res, nil
+			return &res, noCleanup, nil
 		}
 	}
 
 	res := FilePath(".")
 	// Default to current directory
-	return &res, nil
+	return &res, noCleanup, nil
 }
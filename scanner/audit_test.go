@@ -12,9 +12,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cybrota/scharf/network"
 	gitlib "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 type fakeUpgradeResolver struct {
@@ -62,7 +64,7 @@ func captureStdout(t *testing.T, fn func()) string {
 	return string(data)
 }
 
-func writeWorkflow(t *testing.T, repo string, content string) string {
+func writeWorkflow(t testing.TB, repo string, content string) string {
 	t.Helper()
 	workflowDir := filepath.Join(repo, ".github", "workflows")
 	if err := os.MkdirAll(workflowDir, 0o755); err != nil {
@@ -142,7 +144,7 @@ func TestUpgradePinnedSHAsDryRun(t *testing.T) {
 	workflowFile := writeWorkflow(t, tmp, workflow)
 
 	originalResolver := newUpgradeResolver
-	newUpgradeResolver = func() upgradeResolver {
+	newUpgradeResolver = func(*network.SHAResolver) upgradeResolver {
 		return fakeUpgradeResolver{results: map[string]*network.UpgradeResult{
 			"actions/checkout@v4": {
 				Action:         "actions/checkout",
@@ -156,7 +158,7 @@ func TestUpgradePinnedSHAsDryRun(t *testing.T) {
 	t.Cleanup(func() { newUpgradeResolver = originalResolver })
 
 	output := captureStdout(t, func() {
-		if err := UpgradePinnedSHAs(FilePath(tmp), 24, true); err != nil {
+		if err := UpgradePinnedSHAs(FilePath(tmp), nil, 24, true); err != nil {
 			t.Fatalf("UpgradePinnedSHAs returned error: %v", err)
 		}
 	})
@@ -195,7 +197,7 @@ func TestUpgradePinnedSHAsWritesFileWhenNotDryRun(t *testing.T) {
 	workflowFile := writeWorkflow(t, tmp, workflow)
 
 	originalResolver := newUpgradeResolver
-	newUpgradeResolver = func() upgradeResolver {
+	newUpgradeResolver = func(*network.SHAResolver) upgradeResolver {
 		return fakeUpgradeResolver{results: map[string]*network.UpgradeResult{
 			"actions/checkout@v4": {
 				Action:         "actions/checkout",
@@ -208,7 +210,7 @@ func TestUpgradePinnedSHAsWritesFileWhenNotDryRun(t *testing.T) {
 	}
 	t.Cleanup(func() { newUpgradeResolver = originalResolver })
 
-	if err := UpgradePinnedSHAs(FilePath(tmp), 24, false); err != nil {
+	if err := UpgradePinnedSHAs(FilePath(tmp), nil, 24, false); err != nil {
 		t.Fatalf("UpgradePinnedSHAs returned error: %v", err)
 	}
 
@@ -235,7 +237,7 @@ func TestUpgradePinnedSHAsCooldownWarningStillUpgrades(t *testing.T) {
 	workflowFile := writeWorkflow(t, tmp, workflow)
 
 	originalResolver := newUpgradeResolver
-	newUpgradeResolver = func() upgradeResolver {
+	newUpgradeResolver = func(*network.SHAResolver) upgradeResolver {
 		return fakeUpgradeResolver{results: map[string]*network.UpgradeResult{
 			"actions/checkout@v4": {
 				Action:         "actions/checkout",
@@ -257,7 +259,7 @@ func TestUpgradePinnedSHAsCooldownWarningStillUpgrades(t *testing.T) {
 	t.Cleanup(func() { newUpgradeResolver = originalResolver })
 
 	output := captureStdout(t, func() {
-		if err := UpgradePinnedSHAs(FilePath(tmp), 24, false); err != nil {
+		if err := UpgradePinnedSHAs(FilePath(tmp), nil, 24, false); err != nil {
 			t.Fatalf("UpgradePinnedSHAs returned error: %v", err)
 		}
 	})
@@ -290,7 +292,7 @@ func TestUpgradePinnedSHAsInfersVersionFromBarePinnedSHA(t *testing.T) {
 	workflowFile := writeWorkflow(t, tmp, workflow)
 
 	originalResolver := newUpgradeResolver
-	newUpgradeResolver = func() upgradeResolver {
+	newUpgradeResolver = func(*network.SHAResolver) upgradeResolver {
 		return fakeUpgradeResolver{
 			results: map[string]*network.UpgradeResult{
 				"actions/checkout@v4": {
@@ -310,7 +312,7 @@ func TestUpgradePinnedSHAsInfersVersionFromBarePinnedSHA(t *testing.T) {
 	}
 	t.Cleanup(func() { newUpgradeResolver = originalResolver })
 
-	if err := UpgradePinnedSHAs(FilePath(tmp), 24, false); err != nil {
+	if err := UpgradePinnedSHAs(FilePath(tmp), nil, 24, false); err != nil {
 		t.Fatalf("UpgradePinnedSHAs returned error: %v", err)
 	}
 
@@ -337,7 +339,7 @@ func TestUpgradePinnedSHAsSkipsBarePinnedSHAWhenNoTagMatches(t *testing.T) {
 	writeWorkflow(t, tmp, workflow)
 
 	originalResolver := newUpgradeResolver
-	newUpgradeResolver = func() upgradeResolver {
+	newUpgradeResolver = func(*network.SHAResolver) upgradeResolver {
 		return fakeUpgradeResolver{tags: map[string][]network.BranchOrTag{
 			"actions/checkout": {
 				{Name: "v4", Commit: network.Commit{Sha: "cccccccccccccccccccccccccccccccccccccccc"}},
@@ -347,7 +349,7 @@ func TestUpgradePinnedSHAsSkipsBarePinnedSHAWhenNoTagMatches(t *testing.T) {
 	t.Cleanup(func() { newUpgradeResolver = originalResolver })
 
 	output := captureStdout(t, func() {
-		if err := UpgradePinnedSHAs(FilePath(tmp), 24, false); err != nil {
+		if err := UpgradePinnedSHAs(FilePath(tmp), nil, 24, false); err != nil {
 			t.Fatalf("UpgradePinnedSHAs returned error: %v", err)
 		}
 	})
@@ -371,7 +373,7 @@ func TestUpgradePinnedSHAsSkipsBarePinnedSHAWhenAmbiguous(t *testing.T) {
 	writeWorkflow(t, tmp, workflow)
 
 	originalResolver := newUpgradeResolver
-	newUpgradeResolver = func() upgradeResolver {
+	newUpgradeResolver = func(*network.SHAResolver) upgradeResolver {
 		return fakeUpgradeResolver{tags: map[string][]network.BranchOrTag{
 			"actions/checkout": {
 				{Name: "v4", Commit: network.Commit{Sha: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}},
@@ -382,7 +384,7 @@ func TestUpgradePinnedSHAsSkipsBarePinnedSHAWhenAmbiguous(t *testing.T) {
 	t.Cleanup(func() { newUpgradeResolver = originalResolver })
 
 	output := captureStdout(t, func() {
-		if err := UpgradePinnedSHAs(FilePath(tmp), 24, false); err != nil {
+		if err := UpgradePinnedSHAs(FilePath(tmp), nil, 24, false); err != nil {
 			t.Fatalf("UpgradePinnedSHAs returned error: %v", err)
 		}
 	})
@@ -391,3 +393,720 @@ func TestUpgradePinnedSHAsSkipsBarePinnedSHAWhenAmbiguous(t *testing.T) {
 		t.Fatalf("expected ambiguous-tag skip reason in output, got: %s", output)
 	}
 }
+
+func TestAutoFixRepositoryCleanRepoIsQuiet(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+
+	workflow := strings.Join([]string{
+		"jobs:",
+		"  test:",
+		"    steps:",
+		"      - uses: actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v4",
+	}, "\n")
+	writeWorkflow(t, tmp, workflow)
+
+	var total int
+	output := captureStdout(t, func() {
+		var err error
+		total, err = AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, AutoFixOptions{})
+		if err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+
+	if total != 0 {
+		t.Fatalf("AutoFixRepository total = %d; want 0", total)
+	}
+	if !strings.Contains(output, "No actions to fix") {
+		t.Fatalf("expected concise no-op message, got: %s", output)
+	}
+	if strings.Contains(output, "Fixing") {
+		t.Fatalf("did not expect per-file headers on a clean repo, got: %s", output)
+	}
+	if strings.Contains(output, "not staged") {
+		t.Fatalf("did not expect dry-run hint on a clean repo, got: %s", output)
+	}
+}
+
+// movedFakeResolver resolves every action and reports old/name as moved.
+type movedFakeResolver struct{}
+
+func (movedFakeResolver) Resolve(action string) (string, error) {
+	return "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil
+}
+
+func (movedFakeResolver) MovedTo(action string) (string, bool) {
+	if action == "old/name" {
+		return "new/name", true
+	}
+	return "", false
+}
+
+func TestAutoFixRepositoryMovedAction(t *testing.T) {
+	workflow := strings.Join([]string{
+		"jobs:",
+		"  test:",
+		"    steps:",
+		"      - uses: old/name@v1",
+		"      - uses: actions/checkout@v4",
+	}, "\n")
+
+	t.Run("reports without rewriting by default", func(t *testing.T) {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		workflowFile := writeWorkflow(t, tmp, workflow)
+
+		output := captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), movedFakeResolver{}, AutoFixOptions{}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		if !strings.Contains(output, "action moved: old/name → new/name") {
+			t.Fatalf("expected moved warning, got: %s", output)
+		}
+		updated, _ := os.ReadFile(workflowFile)
+		if !strings.Contains(string(updated), "old/name@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v1") {
+			t.Fatalf("expected old name to be kept, got: %s", string(updated))
+		}
+	})
+
+	t.Run("rewrites when asked", func(t *testing.T) {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		workflowFile := writeWorkflow(t, tmp, workflow)
+
+		captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), movedFakeResolver{}, AutoFixOptions{RewriteMoved: true}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		updated, _ := os.ReadFile(workflowFile)
+		if !strings.Contains(string(updated), "new/name@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v1") {
+			t.Fatalf("expected moved action to be rewritten, got: %s", string(updated))
+		}
+		if !strings.Contains(string(updated), "actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v4") {
+			t.Fatalf("expected other actions to keep their name, got: %s", string(updated))
+		}
+	})
+}
+
+func TestAutoFixRepositoryRequireClean(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	// The workflow is never committed, so the worktree is dirty
+	workflowFile := writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@v4\n")
+
+	_, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, AutoFixOptions{RequireClean: true})
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Fatalf("expected uncommitted changes error, got: %v", err)
+	}
+
+	content, readErr := os.ReadFile(workflowFile)
+	if readErr != nil {
+		t.Fatalf("reading workflow: %v", readErr)
+	}
+	if !strings.Contains(string(content), "actions/checkout@v4") {
+		t.Fatalf("expected workflow to stay untouched, got: %s", content)
+	}
+}
+
+// rateLimitedResolver fails every lookup as GitHub does once the API quota is exhausted
+type rateLimitedResolver struct{}
+
+func (rateLimitedResolver) Resolve(action string) (string, error) {
+	return "", &network.RateLimitedError{}
+}
+
+func TestAssembleWorkflowRateLimitedIsNotReportedAsMissing(t *testing.T) {
+	wf, err := AssembleWorkflow(rateLimitedResolver{}, []byte("uses: actions/checkout@v4\n"), "ci.yml", "ci.yml")
+	if err != nil {
+		t.Fatalf("AssembleWorkflow returned error: %v", err)
+	}
+
+	if len(wf.Issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d", len(wf.Issues))
+	}
+	f := wf.Issues[0]
+	if f.FixSHA != SHA256NotAvailable {
+		t.Fatalf("FixSHA = %q; want %q", f.FixSHA, SHA256NotAvailable)
+	}
+	if strings.Contains(f.FixMsg, "not found") || !strings.Contains(f.FixMsg, "rate limit") {
+		t.Fatalf("expected a rate limit message, got: %s", f.FixMsg)
+	}
+}
+
+// partialSemverResolver resolves actions/setup-go@v4.2 to its latest v4.2.3 patch
+type partialSemverResolver struct{}
+
+func (partialSemverResolver) Resolve(action string) (string, error) {
+	return "cccccccccccccccccccccccccccccccccccccccc", nil
+}
+
+func (partialSemverResolver) ResolvedVersion(action string) (string, bool) {
+	if action == "actions/setup-go@v4.2" {
+		return "v4.2.3", true
+	}
+	return "", false
+}
+
+func TestAutoFixRepositoryPartialSemverNotesConcreteVersion(t *testing.T) {
+	for style, want := range map[CommentStyle]string{
+		// The tag style keeps the ref as written, semver notes the concrete tag
+		CommentStyleTag:    "actions/setup-go@cccccccccccccccccccccccccccccccccccccccc # v4.2\n",
+		CommentStyleSemver: "actions/setup-go@cccccccccccccccccccccccccccccccccccccccc # v4.2.3\n",
+	} {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		workflowFile := writeWorkflow(t, tmp, "steps:\n  - uses: actions/setup-go@v4.2\n  - uses: actions/checkout@v4\n")
+
+		captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), partialSemverResolver{}, AutoFixOptions{CommentStyle: style}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		content, err := os.ReadFile(workflowFile)
+		if err != nil {
+			t.Fatalf("reading workflow: %v", err)
+		}
+		got := string(content)
+		if !strings.Contains(got, want) {
+			t.Fatalf("%s style: expected %q in the workflow, got:\n%s", style, want, got)
+		}
+		if !strings.Contains(got, "actions/checkout@cccccccccccccccccccccccccccccccccccccccc # v4\n") {
+			t.Fatalf("%s style: expected exact versions to keep their comment, got:\n%s", style, got)
+		}
+	}
+}
+
+func TestAssembleWorkflowSkipsPinnedActions(t *testing.T) {
+	content := strings.Join([]string{
+		"steps:",
+		"  - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # v4",
+		"  - uses: actions/setup-go@v5",
+		`  - uses: "docker/login-action@9780B0C442FBB1117ED29E0EFDFF1E18412F7567"`,
+		"  - uses: actions/cache@v4.2",
+		"  - uses: actions/upload-artifact@65c4c4a1ddee5b72f698fdd19549f0f0fb45cf08",
+	}, "\n")
+
+	wf, err := AssembleWorkflow(staticResolver{sha: "sha"}, []byte(content), "ci.yml", "ci.yml")
+	if err != nil {
+		t.Fatalf("AssembleWorkflow returned error: %v", err)
+	}
+
+	var got []string
+	for _, f := range wf.Issues {
+		got = append(got, f.Original)
+	}
+	want := []string{"actions/setup-go@v5", "actions/cache@v4.2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("findings = %v; want %v", got, want)
+	}
+}
+
+func TestAutoFixRepositoryLeavesPinnedActionsUnchanged(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	pinned := "  - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # v4"
+	workflowFile := writeWorkflow(t, tmp, "steps:\n"+pinned+"\n  - uses: actions/setup-go@v5\n")
+
+	for run := 1; run <= 2; run++ {
+		captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, AutoFixOptions{}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		updated, _ := os.ReadFile(workflowFile)
+		want := "steps:\n" + pinned + "\n  - uses: actions/setup-go@sha # v5\n"
+		if string(updated) != want {
+			t.Fatalf("run %d: got %q; want %q", run, updated, want)
+		}
+	}
+}
+
+func TestAutoFixRepositoryBranchRefs(t *testing.T) {
+	// develop is a branch too, as the resolver looks up every name without a v prefix among branches
+	workflow := "steps:\n  - uses: actions/checkout@main\n  - uses: actions/cache@develop\n  - uses: actions/setup-go@v5\n"
+
+	t.Run("branch references are pinned to the head by default", func(t *testing.T) {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		workflowFile := writeWorkflow(t, tmp, workflow)
+
+		output := captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha-head"}, AutoFixOptions{}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		updated, _ := os.ReadFile(workflowFile)
+		want := "steps:\n  - uses: actions/checkout@sha-head # main\n  - uses: actions/cache@sha-head # develop\n  - uses: actions/setup-go@sha-head # v5\n"
+		if string(updated) != want {
+			t.Fatalf("got %q; want %q", updated, want)
+		}
+		if strings.Count(output, "branch pin — will need periodic refresh") != 2 {
+			t.Fatalf("expected two branch pin warnings, got: %s", output)
+		}
+	})
+
+	t.Run("branch references are skipped when asked", func(t *testing.T) {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		workflowFile := writeWorkflow(t, tmp, workflow)
+
+		output := captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha-head"}, AutoFixOptions{SkipBranches: true}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		updated, _ := os.ReadFile(workflowFile)
+		want := "steps:\n  - uses: actions/checkout@main\n  - uses: actions/cache@develop\n  - uses: actions/setup-go@sha-head # v5\n"
+		if string(updated) != want {
+			t.Fatalf("got %q; want %q", updated, want)
+		}
+		if !strings.Contains(output, "Skipped: 'actions/checkout@main' tracks a branch") || strings.Count(output, "tracks a branch") != 2 {
+			t.Fatalf("expected both branch references to be listed as skipped, got: %s", output)
+		}
+	})
+}
+
+func TestAutoFixRepositoryExitNonzeroOnChanges(t *testing.T) {
+	opts := AutoFixOptions{DryRun: true, ExitNonzeroOnChanges: true}
+
+	t.Run("dirty repository", func(t *testing.T) {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		workflowFile := writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@v4\n")
+
+		var applied int
+		captureStdout(t, func() {
+			var err error
+			applied, err = AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, opts)
+			if err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		if applied != 1 {
+			t.Fatalf("applied = %d; want 1", applied)
+		}
+		if code := AutoFixExitCode(applied, opts); code != 1 {
+			t.Fatalf("exit code = %d; want 1", code)
+		}
+		if code := AutoFixExitCode(applied, AutoFixOptions{DryRun: true}); code != 0 {
+			t.Fatalf("exit code without the flag = %d; want 0", code)
+		}
+		content, _ := os.ReadFile(workflowFile)
+		if !strings.Contains(string(content), "actions/checkout@v4") {
+			t.Fatalf("expected dry run to leave the workflow untouched, got: %s", content)
+		}
+	})
+
+	t.Run("clean repository", func(t *testing.T) {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # v4\n")
+
+		var applied int
+		captureStdout(t, func() {
+			var err error
+			applied, err = AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, opts)
+			if err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		if code := AutoFixExitCode(applied, opts); code != 0 {
+			t.Fatalf("exit code = %d; want 0", code)
+		}
+	})
+}
+
+func TestAutoFixFileStandalone(t *testing.T) {
+	// No Git repository around the file
+	file := filepath.Join(t.TempDir(), "ci.yml")
+	content := "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@11bd71901bbe5b1630ceea73d27597364c9af683 # v5\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing workflow: %v", err)
+	}
+
+	var applied int
+	captureStdout(t, func() {
+		var err error
+		applied, err = AutoFixFile(FilePath(file), staticResolver{sha: "sha"}, AutoFixOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("AutoFixFile returned error: %v", err)
+		}
+	})
+	if applied != 1 {
+		t.Fatalf("applied = %d; want 1", applied)
+	}
+	if got, _ := os.ReadFile(file); string(got) != content {
+		t.Fatalf("dry run changed the file: %q", got)
+	}
+
+	captureStdout(t, func() {
+		if _, err := AutoFixFile(FilePath(file), staticResolver{sha: "sha"}, AutoFixOptions{CommentStyle: CommentStyleNone}); err != nil {
+			t.Fatalf("AutoFixFile returned error: %v", err)
+		}
+	})
+	want := "steps:\n  - uses: actions/checkout@sha\n  - uses: actions/setup-go@11bd71901bbe5b1630ceea73d27597364c9af683 # v5\n"
+	if got, _ := os.ReadFile(file); string(got) != want {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+}
+
+func TestAutoFixRepositoryMergesExistingComment(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	workflowFile := writeWorkflow(t, tmp, strings.Join([]string{
+		"steps:",
+		"  - uses: actions/checkout@v4 # some pre-existing comment",
+		"  - uses: actions/setup-go@v5  #   ",
+		"  - uses: actions/cache@v4 # nosemgrep",
+		"  - uses: actions/labeler@v5 # v5",
+		"",
+	}, "\n"))
+
+	captureStdout(t, func() {
+		if _, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, AutoFixOptions{}); err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+
+	content, err := os.ReadFile(workflowFile)
+	if err != nil {
+		t.Fatalf("reading workflow: %v", err)
+	}
+	got := string(content)
+	if !strings.Contains(got, "uses: actions/checkout@sha # v4 - some pre-existing comment\n") {
+		t.Fatalf("expected the existing comment to be merged into the version comment, got:\n%s", got)
+	}
+	if !strings.Contains(got, "uses: actions/setup-go@sha # v5\n") {
+		t.Fatalf("expected an empty comment to be dropped, got:\n%s", got)
+	}
+	// A one-word comment is only dropped when it is the version itself
+	if !strings.Contains(got, "uses: actions/cache@sha # v4 - nosemgrep\n") {
+		t.Fatalf("expected the suppression comment to be kept, got:\n%s", got)
+	}
+	if !strings.Contains(got, "uses: actions/labeler@sha # v5\n") {
+		t.Fatalf("expected a comment naming the version to be dropped, got:\n%s", got)
+	}
+	if n := strings.Count(got, "#"); n != 4 {
+		t.Fatalf("expected one comment per line, got %d:\n%s", n, got)
+	}
+}
+
+func TestAssembleWorkflowReusableWorkflowsAndLocalRefs(t *testing.T) {
+	content := strings.Join([]string{
+		"jobs:",
+		"  call:",
+		"    uses: octo-org/shared/.github/workflows/ci.yml@v1",
+		"  build:",
+		"    steps:",
+		"      - uses: github/codeql-action/init@v3",
+		"      - uses: ./.github/actions/setup@v1",
+		"      - uses: ./local",
+		"  local-call:",
+		"    uses: ./.github/workflows/reusable.yml",
+	}, "\n")
+
+	wf, err := AssembleWorkflow(staticResolver{sha: "sha"}, []byte(content), "ci.yml", "ci.yml")
+	if err != nil {
+		t.Fatalf("AssembleWorkflow returned error: %v", err)
+	}
+
+	if len(wf.Issues) != 2 {
+		t.Fatalf("expected 2 findings and no local references, got %d: %+v", len(wf.Issues), wf.Issues)
+	}
+	if f := wf.Issues[0]; f.Action != "octo-org/shared/.github/workflows/ci.yml" || f.Version != "v1" || f.Line != 3 || f.Column != 11 {
+		t.Errorf("unexpected reusable workflow finding: %+v", f)
+	}
+	if f := wf.Issues[1]; f.Action != "github/codeql-action/init" || f.Version != "v3" {
+		t.Errorf("unexpected sub-action finding: %+v", f)
+	}
+}
+
+func TestAutoFixRepositoryPinsReusableWorkflows(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	workflowFile := writeWorkflow(t, tmp, "jobs:\n  call:\n    uses: old/name/.github/workflows/ci.yml@v1\n")
+
+	captureStdout(t, func() {
+		if _, err := AutoFixRepository(FilePath(tmp), movedFakeResolver{}, AutoFixOptions{RewriteMoved: true}); err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+
+	content, err := os.ReadFile(workflowFile)
+	if err != nil {
+		t.Fatalf("reading workflow: %v", err)
+	}
+	// The workflow path is kept, and a moved repository is detected by owner/repo
+	want := "uses: new/name/.github/workflows/ci.yml@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v1\n"
+	if !strings.Contains(string(content), want) {
+		t.Fatalf("expected %q, got:\n%s", want, content)
+	}
+}
+
+func TestAutoFixRepositoryPreservesMixedCase(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	workflowFile := writeWorkflow(t, tmp, "steps:\n  - uses: Actions/Checkout@v4\n")
+
+	wfs, err := AuditRepository(FilePath(tmp), staticResolver{sha: "sha"})
+	if err != nil {
+		t.Fatalf("AuditRepository returned error: %v", err)
+	}
+	if f := (*wfs)[0].Issues[0]; f.Original != "Actions/Checkout@v4" || f.Action != "Actions/Checkout" {
+		t.Fatalf("expected the reference as written in the report, got %+v", f)
+	}
+
+	captureStdout(t, func() {
+		if _, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, AutoFixOptions{}); err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+	content, err := os.ReadFile(workflowFile)
+	if err != nil {
+		t.Fatalf("reading workflow: %v", err)
+	}
+	if !strings.Contains(string(content), "uses: Actions/Checkout@sha # v4\n") {
+		t.Fatalf("expected the fix to keep the original case, got:\n%s", content)
+	}
+}
+
+func TestAutoFixRepositoryCRLFWorkflow(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	content := "name: ci\r\non: push\r\njobs:\r\n  build:\r\n    runs-on: ubuntu-latest\r\n    steps:\r\n      - uses: actions/checkout@v4\r\n      - uses: actions/setup-go@v5 # keep in sync with go.mod\r\n"
+	path := writeWorkflow(t, tmp, content)
+
+	origParse := yamlParse
+	t.Cleanup(func() { SetYAMLParse(origParse) })
+	for _, parse := range []bool{true, false} {
+		SetYAMLParse(parse)
+		wf, err := AssembleWorkflow(staticResolver{sha: "sha"}, []byte(content), "ci.yml", path)
+		if err != nil {
+			t.Fatalf("AssembleWorkflow returned error: %v", err)
+		}
+		if len(wf.Issues) != 2 || wf.Issues[0].Original != "actions/checkout@v4" || wf.Issues[1].Version != "v5" {
+			t.Fatalf("yaml-parse=%v: unexpected issues %+v", parse, wf.Issues)
+		}
+		if wf.Issues[0].Line != 7 || wf.Issues[0].Column != 15 {
+			t.Fatalf("yaml-parse=%v: unexpected location %d:%d", parse, wf.Issues[0].Line, wf.Issues[0].Column)
+		}
+	}
+	SetYAMLParse(origParse)
+
+	sha := strings.Repeat("a", 40)
+	captureStdout(t, func() {
+		if _, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: sha}, AutoFixOptions{}); err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+
+	pinned := strings.Replace(content, "checkout@v4\r", "checkout@"+sha+" # v4\r", 1)
+	pinned = strings.Replace(pinned, "setup-go@v5 # keep in sync with go.mod\r", "setup-go@"+sha+" # v5 - keep in sync with go.mod\r", 1)
+	if got, _ := os.ReadFile(path); string(got) != pinned {
+		t.Fatalf("content = %q; want %q", got, pinned)
+	}
+
+	// Unpinning restores the original file byte for byte
+	captureStdout(t, func() {
+		if _, err := UnpinRepository(FilePath(tmp), false); err != nil {
+			t.Fatalf("UnpinRepository returned error: %v", err)
+		}
+	})
+	if got, _ := os.ReadFile(path); string(got) != content {
+		t.Fatalf("unpinned content = %q; want %q", got, content)
+	}
+}
+
+func TestBuildRepoPathCleanupRemovesClone(t *testing.T) {
+	base := t.TempDir()
+	orig := cloneRepoToTemp
+	cloneRepoToTemp = func(repoURL string) (string, error) {
+		dir, err := os.MkdirTemp(base, "scharf-repo-*")
+		if err != nil {
+			return "", err
+		}
+		initGitRepo(t, dir)
+		writeWorkflow(t, dir, "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n")
+		return dir, nil
+	}
+	t.Cleanup(func() { cloneRepoToTemp = orig })
+
+	var rp *FilePath
+	var cleanup func()
+	var err error
+	captureStdout(t, func() {
+		rp, cleanup, err = BuildRepoPath("audit", []string{"https://github.com/example/repo.git"}, os.Stdout)
+	})
+	if err != nil {
+		t.Fatalf("BuildRepoPath returned error: %v", err)
+	}
+
+	var report *AuditReport
+	captureStdout(t, func() {
+		report, err = AuditRepositoryReport(*rp, staticResolver{sha: "sha"}, AuditOptions{})
+	})
+	if err != nil || len(report.Workflows) != 1 {
+		t.Fatalf("AuditRepositoryReport = %+v, %v; want one workflow", report, err)
+	}
+
+	cleanup()
+	if _, err := os.Stat(string(*rp)); !os.IsNotExist(err) {
+		t.Fatalf("cloned repository %s still exists after cleanup: %v", *rp, err)
+	}
+}
+
+func TestBuildRepoPathCleanupKeepsLocalPath(t *testing.T) {
+	repo := t.TempDir()
+	rp, cleanup, err := BuildRepoPath("audit", []string{repo}, io.Discard)
+	if err != nil || string(*rp) != repo {
+		t.Fatalf("BuildRepoPath = %v, %v; want %s", rp, err, repo)
+	}
+
+	cleanup()
+	if _, err := os.Stat(repo); err != nil {
+		t.Fatalf("local path was removed by cleanup: %v", err)
+	}
+}
+
+// matrixWorkflow has a static reference in a matrix job, which pins as usual,
+// and references built from matrix values, which can't be pinned
+const matrixWorkflow = `on: push
+jobs:
+  test:
+    runs-on: ${{ matrix.os }}
+    strategy:
+      matrix:
+        os: [ubuntu-latest, windows-latest]
+        suffix: [-lts, -edge]
+        minor: ["1", "2"]
+    steps:
+      - uses: actions/checkout@v4
+      - uses: my-org/setup-tool@v1${{ matrix.suffix }}
+      - uses: "my-org/other-tool@v2.${{ matrix.minor }}"
+`
+
+func TestAssembleWorkflowMatrixReferences(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+	origParse := yamlParse
+	t.Cleanup(func() { SetYAMLParse(origParse) })
+	for _, yamlMode := range []bool{true, false} {
+		SetYAMLParse(yamlMode)
+		wf, err := AssembleWorkflow(staticResolver{sha: sha}, []byte(matrixWorkflow), "ci.yml", "ci.yml")
+		if err != nil {
+			t.Fatalf("AssembleWorkflow returned error: %v", err)
+		}
+		if len(wf.Issues) != 3 {
+			t.Fatalf("yaml=%v: expected 3 issues, got %+v", yamlMode, wf.Issues)
+		}
+
+		if f := wf.Issues[0]; f.Original != "actions/checkout@v4" || f.FixSHA != sha {
+			t.Errorf("yaml=%v: static reference in matrix job = %+v; want it pinned to %s", yamlMode, f, sha)
+		}
+		for i, ref := range []string{"my-org/setup-tool@v1${{ matrix.suffix }}", "my-org/other-tool@v2.${{ matrix.minor }}"} {
+			f := wf.Issues[i+1]
+			if f.FixSHA != SHA256NotAvailable || !strings.Contains(f.FixMsg, "cannot pin") || !strings.Contains(f.FixMsg, ref) {
+				t.Errorf("yaml=%v: dynamic reference = %+v; want it flagged as dynamic %s", yamlMode, f, ref)
+			}
+		}
+	}
+}
+
+func TestAutoFixFileMatrixReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ci.yml")
+	if err := os.WriteFile(path, []byte(matrixWorkflow), 0o644); err != nil {
+		t.Fatalf("writing workflow: %v", err)
+	}
+
+	sha := strings.Repeat("a", 40)
+	wf, err := AssembleWorkflow(staticResolver{sha: sha}, []byte(matrixWorkflow), "ci.yml", path)
+	if err != nil {
+		t.Fatalf("AssembleWorkflow returned error: %v", err)
+	}
+	var n int
+	output := captureStdout(t, func() {
+		n, err = ApplyFixesInFile(*wf, AutoFixOptions{})
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("ApplyFixesInFile = %d, %v; want only the static reference fixed", n, err)
+	}
+	if strings.Count(output, "cannot pin") != 2 {
+		t.Fatalf("expected both dynamic references to be reported:\n%s", output)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := strings.Replace(matrixWorkflow, "actions/checkout@v4", "actions/checkout@"+sha+" # v4", 1)
+	if string(got) != want {
+		t.Fatalf("content = %q; want %q", got, want)
+	}
+}
+
+func TestAuditStagedReport(t *testing.T) {
+	tmp := t.TempDir()
+	repo, err := gitlib.PlainInit(tmp, false)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+
+	dir := filepath.Join(tmp, ".github", "workflows")
+	CheckIfError(os.MkdirAll(dir, 0o755))
+	write := func(name, content string) {
+		t.Helper()
+		CheckIfError(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	stage := func(name string) {
+		t.Helper()
+		_, err := w.Add(".github/workflows/" + name)
+		CheckIfError(err)
+	}
+
+	dirty := "steps:\n  - uses: actions/checkout@v4\n"
+	clean := "steps:\n  - uses: actions/checkout@" + shaA + " # v4\n"
+
+	// Committed findings are not the hook's business
+	write("committed.yml", dirty)
+	stage("committed.yml")
+	_, err = w.Commit("add workflow", &gitlib.CommitOptions{
+		Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+	})
+	CheckIfError(err)
+
+	write("clean.yml", clean)
+	stage("clean.yml")
+	write("dirty.yml", dirty)
+	stage("dirty.yml")
+	// The staged content is what gets committed, not the working tree's
+	write("dirty.yml", clean)
+	write("unstaged.yml", dirty)
+
+	report, err := AuditStagedReport(FilePath(tmp), staticResolver{sha: shaB}, AuditOptions{})
+	if err != nil {
+		t.Fatalf("AuditStagedReport returned error: %v", err)
+	}
+	if report.WorkflowFiles != 2 {
+		t.Fatalf("WorkflowFiles = %d; want the 2 staged workflows", report.WorkflowFiles)
+	}
+	if len(report.Workflows) != 1 || filepath.Base(report.Workflows[0].FilePath) != "dirty.yml" {
+		t.Fatalf("expected findings only in the staged dirty.yml, got %+v", report.Workflows)
+	}
+	if !RaisesError(report.Workflows, RefAny, false) {
+		t.Fatalf("expected the staged finding to fail the hook")
+	}
+
+	// Once the dirty workflow is unstaged, nothing is left to block the commit
+	CheckIfError(w.Reset(&gitlib.ResetOptions{Mode: gitlib.MixedReset}))
+	report, err = AuditStagedReport(FilePath(tmp), staticResolver{sha: shaB}, AuditOptions{})
+	if err != nil || report.WorkflowFiles != 0 || len(report.Workflows) != 0 {
+		t.Fatalf("AuditStagedReport with nothing staged = %+v, %v; want an empty report", report, err)
+	}
+}
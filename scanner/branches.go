@@ -0,0 +1,59 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/cybrota/scharf/git"
+)
+
+// BranchFilter selects the branches of a repository find scans. The zero value
+// scans every branch.
+type BranchFilter struct {
+	Patterns    []string // branch names or globs, Ex: main, release/*
+	DefaultOnly bool     // only the default branch, Ex: main
+}
+
+// NewBranchFilter validates the branch globs of the --branches flag
+func NewBranchFilter(patterns []string, defaultOnly bool) (BranchFilter, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return BranchFilter{}, fmt.Errorf("config error: invalid branch pattern %q: %w", p, err)
+		}
+	}
+
+	return BranchFilter{Patterns: patterns, DefaultOnly: defaultOnly}, nil
+}
+
+// Select returns the branches of the repository at repoPath that pass the
+// filter. With DefaultOnly, only the default branch is kept.
+func (f BranchFilter) Select(repoPath string, branches []string) ([]string, error) {
+	if f.DefaultOnly {
+		name, err := git.DefaultBranch(repoPath)
+		if err != nil {
+			return nil, err
+		}
+		branches = []string{name}
+	}
+	if len(f.Patterns) == 0 {
+		return branches, nil
+	}
+
+	var selected []string
+	for _, b := range branches {
+		for _, p := range f.Patterns {
+			if ok, _ := path.Match(p, b); ok {
+				selected = append(selected, b)
+				break
+			}
+		}
+	}
+
+	return selected, nil
+}
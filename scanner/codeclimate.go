@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Code Climate issue types, limited to what GitLab's Code Quality widget consumes
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Location    codeClimateLocation `json:"location"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+}
+
+// codeClimateSeverity maps a severity to a Code Climate issue severity
+func codeClimateSeverity(s Severity) string {
+	switch s {
+	case SeverityHigh:
+		return "critical"
+	case SeverityLow:
+		return "minor"
+	}
+
+	return "major"
+}
+
+// codeClimateFingerprint identifies a finding by its rule, file, reference and the
+// count of earlier uses of that reference in the file. Line numbers are left
+// out, so editing unrelated lines doesn't turn a known finding into a new one.
+func codeClimateFingerprint(rule string, path string, original string, occurrence int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d", rule, path, original, occurrence)))
+	return hex.EncodeToString(sum[:])
+}
+
+// MarshalCodeClimate renders the findings of a report as a Code Climate issue
+// list, as ingested by GitLab's Code Quality widget
+func MarshalCodeClimate(r *AuditReport) ([]byte, error) {
+	issues := []codeClimateIssue{}
+	for _, wf := range r.Workflows {
+		path := sarifArtifact(r.Root, wf.FilePath).URI
+		seen := map[string]int{}
+		for _, f := range wf.Issues {
+			issues = append(issues, codeClimateIssue{
+				Type:        "issue",
+				CheckName:   f.RuleID(),
+				Description: f.FixMsg,
+				Categories:  []string{"Security"},
+				Location:    codeClimateLocation{Path: path, Lines: codeClimateLines{Begin: f.Line}},
+				Severity:    codeClimateSeverity(f.Severity),
+				Fingerprint: codeClimateFingerprint(f.RuleID(), path, f.Original, seen[f.Original]),
+			})
+			seen[f.Original]++
+		}
+	}
+
+	return json.MarshalIndent(issues, "", "  ")
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestMarshalCodeClimate(t *testing.T) {
+	root := t.TempDir()
+	report := &AuditReport{
+		Root: root,
+		Workflows: []Workflow{{
+			FilePath: filepath.Join(root, ".github", "workflows", "ci.yml"),
+			Issues: []Finding{
+				{Line: 12, Original: "actions/setup-go@main", Severity: SeverityHigh, FixMsg: "Pin `actions/setup-go` to sha-go"},
+				{Line: 20, Original: "actions/cache@v3", Severity: SeverityMedium, FixMsg: "Pin `actions/cache` to sha-cache"},
+			},
+		}},
+	}
+
+	data, err := MarshalCodeClimate(report)
+	if err != nil {
+		t.Fatalf("MarshalCodeClimate returned error: %v", err)
+	}
+	var issues []codeClimateIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(issues))
+	}
+
+	got := issues[0]
+	if got.Type != "issue" || got.CheckName != SarifRuleUnpinnedAction || got.Description != "Pin `actions/setup-go` to sha-go" ||
+		got.Location.Path != ".github/workflows/ci.yml" || got.Location.Lines.Begin != 12 || got.Severity != "critical" {
+		t.Fatalf("unexpected issue: %+v", got)
+	}
+	if issues[1].Severity != "major" {
+		t.Fatalf("severity = %q; want major", issues[1].Severity)
+	}
+}
+
+func TestMarshalCodeClimateStableFingerprints(t *testing.T) {
+	report := func(lineShift int) *AuditReport {
+		return &AuditReport{Workflows: []Workflow{{
+			FilePath: "ci.yml",
+			Issues: []Finding{
+				{Line: 3 + lineShift, Original: "actions/checkout@v4"},
+				{Line: 9 + lineShift, Original: "actions/checkout@v4"},
+				{Line: 12 + lineShift, Original: "actions/cache@v3"},
+			},
+		}}}
+	}
+	fingerprints := func(r *AuditReport) []string {
+		data, err := MarshalCodeClimate(r)
+		if err != nil {
+			t.Fatalf("MarshalCodeClimate returned error: %v", err)
+		}
+		var issues []codeClimateIssue
+		if err := json.Unmarshal(data, &issues); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		var fps []string
+		for _, i := range issues {
+			fps = append(fps, i.Fingerprint)
+		}
+		return fps
+	}
+
+	first := fingerprints(report(0))
+	seen := map[string]bool{}
+	for _, fp := range first {
+		if seen[fp] {
+			t.Fatalf("duplicate fingerprint %s in %v", fp, first)
+		}
+		seen[fp] = true
+	}
+
+	// Re-running, or adding lines above the findings, keeps the fingerprints
+	for _, shift := range []int{0, 5} {
+		again := fingerprints(report(shift))
+		for i := range first {
+			if again[i] != first[i] {
+				t.Fatalf("fingerprint %d changed with a %d line shift: %s != %s", i, shift, again[i], first[i])
+			}
+		}
+	}
+}
+
+func TestMarshalCodeClimateWithoutFindings(t *testing.T) {
+	data, err := MarshalCodeClimate(&AuditReport{})
+	if err != nil || string(data) != "[]" {
+		t.Fatalf("MarshalCodeClimate = %s, %v; want []", data, err)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// CommentStyle decides the version comment autofix writes after a pinned SHA
+type CommentStyle string
+
+const (
+	// CommentStyleTag keeps the ref as written. Ex: # v4
+	CommentStyleTag CommentStyle = "tag"
+	// CommentStyleNone writes no comment
+	CommentStyleNone CommentStyle = "none"
+	// CommentStyleSemver writes the most specific version tag of the SHA. Ex: # v4.2.1
+	CommentStyleSemver CommentStyle = "semver"
+)
+
+var semverTagRegex = regexp.MustCompile(`^v?\d+(\.\d+)*$`)
+
+// tagLister is implemented by resolvers that list the tags of an action
+type tagLister interface {
+	ListTags(action string) ([]network.BranchOrTag, error)
+}
+
+// ParseCommentStyle converts a user given value like "Semver" into a CommentStyle
+func ParseCommentStyle(s string) (CommentStyle, error) {
+	style := CommentStyle(strings.ToLower(strings.TrimSpace(s)))
+	switch style {
+	case "":
+		return CommentStyleTag, nil
+	case CommentStyleTag, CommentStyleNone, CommentStyleSemver:
+		return style, nil
+	}
+
+	return "", fmt.Errorf("invalid comment style: %q. Valid values are tag, none, semver", s)
+}
+
+// mostSpecificSemverTag returns the version tag with the most components among
+// the tags pointing to sha. Ex: v4.2.1 over v4.2 and v4.
+func mostSpecificSemverTag(tags []network.BranchOrTag, sha string) (string, bool) {
+	best := ""
+	for _, tag := range tags {
+		if tag.Commit.Sha != sha || !semverTagRegex.MatchString(tag.Name) {
+			continue
+		}
+		parts, bestParts := strings.Count(tag.Name, "."), strings.Count(best, ".")
+		// Break ties lexically so the API ordering never changes the outcome
+		if best == "" || parts > bestParts || (parts == bestParts && tag.Name < best) {
+			best = tag.Name
+		}
+	}
+
+	return best, best != ""
+}
+
+// resolveSemverComments sets ResolvedVersion of every fixable finding to the most
+// specific version tag of its SHA. Findings keep their version when the resolver
+// can't list tags or no version tag points to the SHA.
+func resolveSemverComments(wfs []Workflow, res network.Resolver) {
+	lister, ok := res.(tagLister)
+	if !ok {
+		return
+	}
+
+	tagsByAction := map[string][]network.BranchOrTag{}
+	for i := range wfs {
+		for j := range wfs[i].Issues {
+			issue := &wfs[i].Issues[j]
+			if issue.FixSHA == SHA256NotAvailable {
+				continue
+			}
+
+			tags, seen := tagsByAction[issue.Action]
+			if !seen {
+				var err error
+				tags, err = lister.ListTags(issue.Action)
+				if err != nil {
+					logger.Warn("could not list tags", "action", issue.Action, "err", err)
+				}
+				tagsByAction[issue.Action] = tags
+			}
+
+			if version, ok := mostSpecificSemverTag(tags, issue.FixSHA); ok {
+				issue.ResolvedVersion = version
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cybrota/scharf/network"
+)
+
+const commentSHA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+// taggedResolver pins every action to commentSHA and lists the given tags
+type taggedResolver struct {
+	tags []network.BranchOrTag
+}
+
+func (taggedResolver) Resolve(action string) (string, error) {
+	return commentSHA, nil
+}
+
+func (r taggedResolver) ListTags(action string) ([]network.BranchOrTag, error) {
+	return r.tags, nil
+}
+
+func tagAt(name string, sha string) network.BranchOrTag {
+	return network.BranchOrTag{Name: name, Commit: network.Commit{Sha: sha}}
+}
+
+func TestParseCommentStyle(t *testing.T) {
+	cases := map[string]CommentStyle{
+		"":        CommentStyleTag,
+		"tag":     CommentStyleTag,
+		"None":    CommentStyleNone,
+		" semver": CommentStyleSemver,
+	}
+	for in, want := range cases {
+		got, err := ParseCommentStyle(in)
+		if err != nil || got != want {
+			t.Errorf("ParseCommentStyle(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseCommentStyle("sha"); err == nil {
+		t.Fatal("expected an error for an unknown style")
+	}
+}
+
+func TestMostSpecificSemverTag(t *testing.T) {
+	tags := []network.BranchOrTag{
+		tagAt("v4", commentSHA),
+		tagAt("v4.2.1", commentSHA),
+		tagAt("v4.2", commentSHA),
+		tagAt("latest", commentSHA),
+		tagAt("v4.3.0", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"),
+	}
+
+	got, ok := mostSpecificSemverTag(tags, commentSHA)
+	if !ok || got != "v4.2.1" {
+		t.Fatalf("mostSpecificSemverTag = %q, %v; want v4.2.1", got, ok)
+	}
+
+	if _, ok := mostSpecificSemverTag(tags, "cccccccccccccccccccccccccccccccccccccccc"); ok {
+		t.Fatal("expected no tag for an unknown SHA")
+	}
+}
+
+func TestAutoFixRepositoryCommentStyle(t *testing.T) {
+	res := taggedResolver{tags: []network.BranchOrTag{tagAt("v4", commentSHA), tagAt("v4.2.1", commentSHA)}}
+	cases := []struct {
+		style CommentStyle
+		want  string
+	}{
+		{CommentStyleTag, "actions/checkout@" + commentSHA + " # v4\n"},
+		{CommentStyleNone, "actions/checkout@" + commentSHA + "\n"},
+		{CommentStyleSemver, "actions/checkout@" + commentSHA + " # v4.2.1\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.style), func(t *testing.T) {
+			tmp := t.TempDir()
+			initGitRepo(t, tmp)
+			workflowFile := writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@v4\n")
+
+			captureStdout(t, func() {
+				if _, err := AutoFixRepository(FilePath(tmp), res, AutoFixOptions{CommentStyle: tc.style}); err != nil {
+					t.Fatalf("AutoFixRepository returned error: %v", err)
+				}
+			})
+
+			updated, _ := os.ReadFile(workflowFile)
+			if !strings.HasSuffix(string(updated), "uses: "+tc.want) {
+				t.Fatalf("unexpected workflow: %s", updated)
+			}
+		})
+	}
+}
+
+func TestAutoFixRepositoryIsIdempotent(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	// An existing version hint is replaced rather than followed by a second comment
+	workflowFile := writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@v4 # v4\n  - uses: actions/setup-go@v5 # keep Go in sync with go.mod\n")
+	want := "steps:\n  - uses: actions/checkout@" + commentSHA + " # v4\n  - uses: actions/setup-go@" + commentSHA + " # v5 - keep Go in sync with go.mod\n"
+
+	for run := 1; run <= 2; run++ {
+		captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), taggedResolver{}, AutoFixOptions{}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		updated, _ := os.ReadFile(workflowFile)
+		if string(updated) != want {
+			t.Fatalf("run %d: got %q; want %q", run, updated, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ContainerImage is a mutable image reference of a job container or service
+// container, Ex: jobs.build.container.image: node:20
+type ContainerImage struct {
+	FilePath string   // workflow file containing the image
+	Line     int      // 1-based line number
+	Column   int      // 1-based column number
+	Image    string   // Ex: postgres:16
+	Severity Severity // high for untagged or latest images, medium for other tags
+}
+
+func (c ContainerImage) String() string {
+	return fmt.Sprintf("container image %s at %s:%d:%d is not pinned to a digest. Pin it as %s@sha256:<digest>", c.Image, c.FilePath, c.Line, c.Column, c.Image)
+}
+
+// imageTag returns the tag of an image reference, Ex: 16 of postgres:16, or ""
+// when it has none. A registry port, Ex: ghcr.io:443/org/app, is no tag.
+func imageTag(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	_, tag, _ := strings.Cut(name, ":")
+	return tag
+}
+
+// classifyImage ranks an image like a reference: latest or no tag follows every
+// push of the image, so it ranks higher than a version tag
+func classifyImage(image string) Severity {
+	if tag := imageTag(image); tag == "" || tag == "latest" {
+		return SeverityHigh
+	}
+
+	return SeverityMedium
+}
+
+// isMutableImage reports whether image can change under the same name. Digest
+// pinned images are immutable; expressions are only known at run time.
+func isMutableImage(image string) bool {
+	return image != "" && !strings.Contains(image, "@sha256:") && !strings.Contains(image, "${{")
+}
+
+// workflowImages returns the image nodes of a workflow: jobs.*.container (an
+// image or a mapping with image) and jobs.*.services.*.image
+func workflowImages(jobs *yaml.Node) []*yaml.Node {
+	var images []*yaml.Node
+	for i := 1; i < len(jobs.Content); i += 2 {
+		job := jobs.Content[i]
+		if c := mappingValue(job, "container"); c != nil {
+			if c.Kind == yaml.ScalarNode {
+				images = append(images, c)
+			} else if img := mappingValue(c, "image"); img != nil && img.Kind == yaml.ScalarNode {
+				images = append(images, img)
+			}
+		}
+
+		services := mappingValue(job, "services")
+		if services == nil || services.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 1; j < len(services.Content); j += 2 {
+			if img := mappingValue(services.Content[j], "image"); img != nil && img.Kind == yaml.ScalarNode {
+				images = append(images, img)
+			}
+		}
+	}
+
+	return images
+}
+
+// FindContainerImages parses content as a workflow and returns its job and
+// service container images that aren't pinned to a digest
+func FindContainerImages(content []byte, filePath string) []ContainerImage {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	jobs := mappingValue(doc.Content[0], "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var images []ContainerImage
+	for _, node := range workflowImages(jobs) {
+		image := strings.TrimPrefix(node.Value, "docker://")
+		if !isMutableImage(image) {
+			continue
+		}
+		images = append(images, ContainerImage{
+			FilePath: filePath,
+			Line:     node.Line,
+			Column:   node.Column,
+			Image:    image,
+			Severity: classifyImage(image),
+		})
+	}
+
+	return images
+}
+
+// CheckContainerImages lists the job and service container images of every
+// workflow in the repository that aren't pinned to a digest
+func CheckContainerImages(path FilePath) ([]ContainerImage, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	files, err := listWorkflowFiles(abs)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	var images []ContainerImage
+	for _, f := range files {
+		content, err := ReadFile(FilePath(f))
+		if err != nil {
+			if errors.Is(err, syscall.EISDIR) {
+				continue
+			}
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+
+		images = append(images, FindContainerImages(content, f)...)
+	}
+
+	return images, nil
+}
+
+// AddContainerFindings adds the mutable images to the report as findings of the
+// SarifRuleUnpinnedImage rule, fixed by the digest that digest looks up, Ex:
+// network.ResolveImageDigest, so every report format lists them next to the
+// unpinned actions. Images whose digest isn't found are reported as warnings too.
+func AddContainerFindings(report *AuditReport, images []ContainerImage, digest func(image string) (string, error)) {
+	type lookup struct {
+		digest string
+		err    error
+	}
+	looked := map[string]lookup{}
+
+	for _, img := range images {
+		l, ok := looked[img.Image]
+		if !ok {
+			l.digest, l.err = digest(img.Image)
+			looked[img.Image] = l
+		}
+
+		tag := imageTag(img.Image)
+		f := Finding{
+			Line:        img.Line,
+			Column:      img.Column,
+			Description: fmt.Sprintf("Mutable container image: `%s`", img.Image),
+			FixMsg:      fmt.Sprintf("Pin `%s` to %s@%s", img.Image, img.Image, l.digest),
+			FixSHA:      l.digest,
+			Action:      strings.TrimSuffix(img.Image, ":"+tag),
+			Version:     tag,
+			Original:    img.Image,
+			Severity:    img.Severity,
+			Rule:        SarifRuleUnpinnedImage,
+		}
+		if l.err != nil {
+			f.FixSHA = SHA256NotAvailable
+			f.FixMsg = fmt.Sprintf("Could not look up the digest of '%s': %s. Pin it as %s@sha256:<digest>", img.Image, l.err.Error(), img.Image)
+			report.Warnings = append(report.Warnings, Warning{
+				Kind:    WarningUnresolvedReference,
+				File:    img.FilePath,
+				Line:    img.Line,
+				Action:  img.Image,
+				Message: f.FixMsg,
+			})
+		}
+
+		i := slices.IndexFunc(report.Workflows, func(wf Workflow) bool { return wf.FilePath == img.FilePath })
+		if i < 0 {
+			report.Workflows = append(report.Workflows, Workflow{Name: img.FilePath, FilePath: img.FilePath})
+			i = len(report.Workflows) - 1
+		}
+		report.Workflows[i].Issues = append(report.Workflows[i].Issues, f)
+	}
+
+	// Images join the findings of their workflow in line order
+	for i := range report.Workflows {
+		issues := report.Workflows[i].Issues
+		sort.SliceStable(issues, func(a, b int) bool {
+			if issues[a].Line != issues[b].Line {
+				return issues[a].Line < issues[b].Line
+			}
+			return issues[a].Column < issues[b].Column
+		})
+	}
+}
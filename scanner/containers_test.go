@@ -0,0 +1,145 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const containerWorkflow = `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    container:
+      image: node:20
+      options: --cpus 2
+    services:
+      db:
+        image: postgres
+      cache:
+        image: redis@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+      registry:
+        image: ghcr.io:443/org/app:latest
+    steps:
+      - uses: actions/checkout@v4
+  lint:
+    runs-on: ubuntu-latest
+    container: docker://golang:1.24
+  matrix:
+    runs-on: ubuntu-latest
+    container:
+      image: ${{ matrix.image }}
+`
+
+func TestFindContainerImages(t *testing.T) {
+	images := FindContainerImages([]byte(containerWorkflow), "ci.yml")
+
+	want := []ContainerImage{
+		{FilePath: "ci.yml", Line: 7, Column: 14, Image: "node:20", Severity: SeverityMedium},
+		{FilePath: "ci.yml", Line: 11, Column: 16, Image: "postgres", Severity: SeverityHigh},
+		{FilePath: "ci.yml", Line: 15, Column: 16, Image: "ghcr.io:443/org/app:latest", Severity: SeverityHigh},
+		{FilePath: "ci.yml", Line: 20, Column: 16, Image: "golang:1.24", Severity: SeverityMedium},
+	}
+	if len(images) != len(want) {
+		t.Fatalf("expected %d images, got %d: %+v", len(want), len(images), images)
+	}
+	for i, img := range want {
+		if images[i] != img {
+			t.Errorf("image %d = %+v; want %+v", i, images[i], img)
+		}
+	}
+}
+
+func TestFindContainerImagesIgnoresOtherFiles(t *testing.T) {
+	action := "runs:\n  using: docker\n  image: docker://alpine:3\n"
+	if images := FindContainerImages([]byte(action), "action.yml"); len(images) != 0 {
+		t.Fatalf("expected no images outside of jobs, got %+v", images)
+	}
+}
+
+func TestCheckContainerImages(t *testing.T) {
+	root := t.TempDir()
+	path := writeWorkflow(t, root, containerWorkflow)
+
+	images, err := CheckContainerImages(FilePath(root))
+	if err != nil {
+		t.Fatalf("CheckContainerImages returned error: %v", err)
+	}
+	if len(images) != 4 || images[0].FilePath != path {
+		t.Fatalf("unexpected images: %+v", images)
+	}
+}
+
+func TestImageTag(t *testing.T) {
+	for image, want := range map[string]string{
+		"node:20":                 "20",
+		"postgres":                "",
+		"ghcr.io:443/org/app":     "",
+		"ghcr.io:443/org/app:1.2": "1.2",
+	} {
+		if got := imageTag(image); got != want {
+			t.Errorf("imageTag(%q) = %q; want %q", image, got, want)
+		}
+	}
+}
+
+func TestAddContainerFindings(t *testing.T) {
+	root := t.TempDir()
+	initGitRepo(t, root)
+	writeWorkflow(t, root, containerWorkflow)
+	report, err := AuditRepositoryReport(FilePath(root), staticResolver{sha: "sha"}, AuditOptions{})
+	if err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+	images, err := CheckContainerImages(FilePath(root))
+	if err != nil {
+		t.Fatalf("CheckContainerImages returned error: %v", err)
+	}
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	AddContainerFindings(report, images, func(image string) (string, error) {
+		if image == "postgres" {
+			return "", errors.New("image postgres is not found")
+		}
+		return digest, nil
+	})
+
+	if len(report.Workflows) != 1 {
+		t.Fatalf("expected the images in the workflow of the action, got %+v", report.Workflows)
+	}
+	var got []string
+	for _, f := range report.Workflows[0].Issues {
+		got = append(got, f.RuleID()+" "+f.Original+" "+f.FixSHA)
+	}
+	want := []string{
+		SarifRuleUnpinnedImage + " node:20 " + digest,
+		SarifRuleUnpinnedImage + " postgres " + SHA256NotAvailable,
+		SarifRuleUnpinnedImage + " ghcr.io:443/org/app:latest " + digest,
+		SarifRuleUnpinnedAction + " actions/checkout@v4 sha",
+		SarifRuleUnpinnedImage + " golang:1.24 " + digest,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("findings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Action != "postgres" {
+		t.Fatalf("expected a warning for postgres, got %+v", report.Warnings)
+	}
+	if refs := UniqueActions(report.Workflows); len(refs) != 1 || refs[0] != "actions/checkout@v4" {
+		t.Fatalf("UniqueActions = %v; want only actions/checkout@v4", refs)
+	}
+
+	data, err := MarshalSARIF(report)
+	if err != nil {
+		t.Fatalf("MarshalSARIF returned error: %v", err)
+	}
+	if !strings.Contains(string(data), `"ruleId": "`+SarifRuleUnpinnedImage+`"`) || !strings.Contains(string(data), `"id": "`+SarifRuleUnpinnedImage+`"`) {
+		t.Fatalf("expected image results and rule in the SARIF, got:\n%s", data)
+	}
+}
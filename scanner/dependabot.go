@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// ReferencesFromDiff returns the distinct mutable references on the added lines
+// of a unified diff, Ex: of a Dependabot pull request bumping action versions
+func ReferencesFromDiff(diff []byte) []string {
+	var refs []string
+	for line := range bytes.Lines(diff) {
+		// Skip removed and context lines, and the "+++ b/file" header
+		if !bytes.HasPrefix(line, []byte("+")) || bytes.HasPrefix(line, []byte("+++")) {
+			continue
+		}
+
+		added := line[1:]
+		for _, loc := range findRegex.FindAllIndex(added, -1) {
+			if isInLocalReference(added, loc[0]) || isPinnedReference(added, loc[0]) {
+				continue
+			}
+			if ref := string(added[loc[0]:loc[1]]); !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	return refs
+}
+
+// PinReferences resolves each owner/repo@ref to its commit SHA and returns the
+// pinned forms, Ex: actions/checkout@<sha> # v4
+func PinReferences(refs []string, res network.Resolver) ([]string, error) {
+	var pins []string
+	for _, ref := range refs {
+		action, version, ok := strings.Cut(ref, "@")
+		if !ok || action == "" || version == "" {
+			return nil, fmt.Errorf("invalid reference %q. Ex: actions/checkout@v4", ref)
+		}
+
+		sha, err := res.Resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", ref, err)
+		}
+
+		if cr, ok := res.(concreteVersionResolver); ok {
+			if v, partial := cr.ResolvedVersion(ref); partial {
+				version = v
+			}
+		}
+		pins = append(pins, formatPin(action, sha, version))
+	}
+
+	return pins, nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const dependabotDiff = `diff --git a/.github/workflows/ci.yml b/.github/workflows/ci.yml
+--- a/.github/workflows/ci.yml
++++ b/.github/workflows/ci.yml
+@@ -10,7 +10,7 @@ jobs:
+     steps:
+-      - uses: actions/checkout@v3
++      - uses: actions/checkout@v4
+-      - uses: actions/setup-go@v4
++      - uses: actions/setup-go@v5
++      - uses: actions/cache@0c45773b623bea8c8e75f6c82b208c3cf94ea4f9 # v4
++      - uses: ./.github/actions/build@main
+       - uses: actions/upload-artifact@v4
+diff --git a/.github/workflows/release.yml b/.github/workflows/release.yml
+--- a/.github/workflows/release.yml
++++ b/.github/workflows/release.yml
+@@ -3,1 +3,1 @@
+-      - uses: actions/checkout@v3
++      - uses: actions/checkout@v4
+`
+
+func TestReferencesFromDiff(t *testing.T) {
+	got := ReferencesFromDiff([]byte(dependabotDiff))
+	want := []string{"actions/checkout@v4", "actions/setup-go@v5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReferencesFromDiff = %v; want %v", got, want)
+	}
+}
+
+func TestPinReferences(t *testing.T) {
+	pins, err := PinReferences([]string{"actions/checkout@v4", "actions/setup-go@v4.2"}, partialSemverResolver{})
+	if err != nil {
+		t.Fatalf("PinReferences returned error: %v", err)
+	}
+
+	want := []string{
+		"actions/checkout@cccccccccccccccccccccccccccccccccccccccc # v4",
+		"actions/setup-go@cccccccccccccccccccccccccccccccccccccccc # v4.2.3",
+	}
+	if !reflect.DeepEqual(pins, want) {
+		t.Fatalf("PinReferences = %v; want %v", pins, want)
+	}
+}
+
+type failingResolver struct{}
+
+func (failingResolver) Resolve(action string) (string, error) {
+	return "", errors.New("not found")
+}
+
+func TestPinReferencesErrors(t *testing.T) {
+	if _, err := PinReferences([]string{"actions/checkout"}, staticResolver{sha: "sha"}); err == nil {
+		t.Fatal("expected an error for a reference without @ref")
+	}
+	if _, err := PinReferences([]string{"actions/checkout@v9"}, failingResolver{}); err == nil {
+		t.Fatal("expected an error for an unresolvable reference")
+	}
+}
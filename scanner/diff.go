@@ -0,0 +1,191 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/cybrota/scharf/git"
+	"github.com/cybrota/scharf/network"
+)
+
+var pinnedSHARegex = regexp.MustCompile(`([\w.-]+/[\w.-]+)@([a-f0-9]{40})\b`)
+
+// PinSet maps an action to the distinct SHAs it is pinned to across workflows
+type PinSet map[string][]string
+
+func (p PinSet) add(action string, sha string) {
+	if slices.Contains(p[action], sha) {
+		return
+	}
+	p[action] = append(p[action], sha)
+	slices.Sort(p[action])
+}
+
+// PinChangeKind tells how an action's pins differ between two sides
+type PinChangeKind string
+
+const (
+	PinAdded   PinChangeKind = "added"
+	PinRemoved PinChangeKind = "removed"
+	PinChanged PinChangeKind = "changed"
+)
+
+// PinChange is a single difference between two PinSets
+type PinChange struct {
+	Kind   PinChangeKind
+	Action string
+	From   []string // pins on the first side, empty when added
+	To     []string // pins on the second side, empty when removed
+}
+
+// CollectPins extracts the action→SHA pins of a repository's workflows. When ref is
+// empty the working tree is read, otherwise the workflows recorded at ref. Mutable
+// references are resolved with res, the same way audit suggests their fix.
+func CollectPins(path FilePath, ref string, res network.Resolver) (PinSet, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	if ref == "" {
+		files, err := listWorkflowFiles(abs)
+		if err != nil {
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+
+		pins := PinSet{}
+		for _, f := range files {
+			content, err := ReadFile(FilePath(f))
+			if err != nil {
+				if errors.Is(err, syscall.EISDIR) {
+					continue
+				}
+				return nil, fmt.Errorf("file error: %w", err)
+			}
+			collectPinsFromContent(pins, content, f, res)
+		}
+		return pins, nil
+	}
+
+	if !git.IsGitRepo(abs) {
+		return nil, fmt.Errorf("The directory: %s is not a Git repository", abs)
+	}
+
+	dirs := WorkflowDirs()
+	files, err := git.ReadFilesAtRef(abs, ref, dirs)
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+	if len(files) == 0 {
+		var searched []string
+		for _, d := range dirs {
+			searched = append(searched, fmt.Sprintf("%s:%s", ref, d))
+		}
+		return nil, fmt.Errorf("file error: %w", &NoWorkflowsError{Searched: searched})
+	}
+
+	pins := PinSet{}
+	for name, content := range files {
+		collectPinsFromContent(pins, content, name, res)
+	}
+	return pins, nil
+}
+
+// RemoteRef returns the ref of branch on remote of the repository at path, Ex:
+// refs/remotes/upstream/main, to read a remote's workflows with CollectPins
+func RemoteRef(path FilePath, remote, branch string) (string, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return "", fmt.Errorf("os: %w", err)
+	}
+	if !git.IsGitRepo(abs) {
+		return "", fmt.Errorf("The directory: %s is not a Git repository", abs)
+	}
+
+	ref, err := git.RemoteBranchRef(abs, remote, branch)
+	if err != nil {
+		return "", fmt.Errorf("git error: %w", err)
+	}
+	return ref, nil
+}
+
+// collectPinsFromContent adds the pinned and resolved mutable references of one workflow to pins
+func collectPinsFromContent(pins PinSet, content []byte, filePath string, res network.Resolver) {
+	matches, err := ScanContentWithPosition(content, pinnedSHARegex)
+	if err == nil {
+		for _, m := range matches {
+			if isInLocalReference(content, m.StartOffset) {
+				continue
+			}
+			parts := strings.SplitN(m.Text, "@", 2)
+			pins.add(parts[0], parts[1])
+		}
+	}
+
+	wf, err := AssembleWorkflow(res, content, path.Base(filepath.ToSlash(filePath)), filePath)
+	if err != nil {
+		return
+	}
+	for _, f := range wf.Issues {
+		if f.FixSHA == SHA256NotAvailable {
+			// Keep unresolved references visible instead of silently dropping them
+			pins.add(f.Action, fmt.Sprintf("%s (unresolved)", f.Version))
+			continue
+		}
+		pins.add(f.Action, f.FixSHA)
+	}
+}
+
+// DiffPins lists the actions whose pins differ between a and b, sorted by action
+func DiffPins(a PinSet, b PinSet) []PinChange {
+	var changes []PinChange
+	for action, from := range a {
+		to, ok := b[action]
+		switch {
+		case !ok:
+			changes = append(changes, PinChange{Kind: PinRemoved, Action: action, From: from})
+		case !slices.Equal(from, to):
+			changes = append(changes, PinChange{Kind: PinChanged, Action: action, From: from, To: to})
+		}
+	}
+	for action, to := range b {
+		if _, ok := a[action]; !ok {
+			changes = append(changes, PinChange{Kind: PinAdded, Action: action, To: to})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Action < changes[j].Action
+	})
+	return changes
+}
+
+// FormatPinDiff renders pin changes into a colored CLI report
+func FormatPinDiff(changes []PinChange) string {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Kind {
+		case PinAdded:
+			fmt.Fprintf(&b, "%s+ %s@%s%s\n", Green, c.Action, strings.Join(c.To, ", "), Reset)
+		case PinRemoved:
+			fmt.Fprintf(&b, "%s- %s@%s%s\n", Red, c.Action, strings.Join(c.From, ", "), Reset)
+		case PinChanged:
+			fmt.Fprintf(&b, "%s~ %s:%s %s → %s\n", Yellow, c.Action, Reset, strings.Join(c.From, ", "), strings.Join(c.To, ", "))
+		}
+	}
+
+	return b.String()
+}
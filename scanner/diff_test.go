@@ -0,0 +1,155 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gitlib "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	shaA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	shaB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	shaC = "cccccccccccccccccccccccccccccccccccccccc"
+)
+
+func TestDiffPins_TwoRepos(t *testing.T) {
+	repoA := t.TempDir()
+	writeWorkflow(t, repoA, strings.Join([]string{
+		"steps:",
+		"  - uses: actions/checkout@" + shaA + " # v4",
+		"  - uses: actions/setup-go@" + shaB,
+		"  - uses: actions/cache@" + shaC + " # v4",
+		"  - uses: ./.github/actions/local",
+	}, "\n"))
+
+	repoB := t.TempDir()
+	writeWorkflow(t, repoB, strings.Join([]string{
+		"steps:",
+		"  - uses: actions/checkout@" + shaB + " # v4.2.0",
+		"  - uses: actions/cache@" + shaC,
+		"  - uses: actions/upload-artifact@v4",
+	}, "\n"))
+
+	res := staticResolver{sha: shaA}
+	a, err := CollectPins(FilePath(repoA), "", res)
+	CheckIfError(err)
+	b, err := CollectPins(FilePath(repoB), "", res)
+	CheckIfError(err)
+
+	got := DiffPins(a, b)
+	expected := []PinChange{
+		{Kind: PinChanged, Action: "actions/checkout", From: []string{shaA}, To: []string{shaB}},
+		{Kind: PinRemoved, Action: "actions/setup-go", From: []string{shaB}},
+		{Kind: PinAdded, Action: "actions/upload-artifact", To: []string{shaA}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected diff:\n got: %+v\nwant: %+v", got, expected)
+	}
+
+	report := FormatPinDiff(got)
+	for _, want := range []string{"~ actions/checkout:", "- actions/setup-go@" + shaB, "+ actions/upload-artifact@" + shaA} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestDiffPins_Identical(t *testing.T) {
+	pins := PinSet{"actions/checkout": {shaA}}
+	if got := DiffPins(pins, PinSet{"actions/checkout": {shaA}}); len(got) != 0 {
+		t.Fatalf("expected no changes, got %+v", got)
+	}
+}
+
+func TestCollectPins_AtRef(t *testing.T) {
+	repoPath := t.TempDir()
+	repo, err := gitlib.PlainInit(repoPath, false)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+
+	commit := func(workflow string) string {
+		writeWorkflow(t, repoPath, workflow)
+		_, err := w.Add(filepath.ToSlash(filepath.Join(".github", "workflows", "ci.yml")))
+		CheckIfError(err)
+		hash, err := w.Commit("update workflow", &gitlib.CommitOptions{
+			Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+		})
+		CheckIfError(err)
+		return hash.String()
+	}
+
+	first := commit("steps:\n  - uses: actions/checkout@" + shaA + " # v4\n")
+	second := commit("steps:\n  - uses: actions/checkout@" + shaB + " # v4\n")
+
+	res := staticResolver{sha: shaC}
+	a, err := CollectPins(FilePath(repoPath), first, res)
+	CheckIfError(err)
+	b, err := CollectPins(FilePath(repoPath), second, res)
+	CheckIfError(err)
+
+	got := DiffPins(a, b)
+	if len(got) != 1 || got[0].Kind != PinChanged || got[0].To[0] != shaB {
+		t.Fatalf("expected actions/checkout to change to %s, got %+v", shaB, got)
+	}
+
+	if _, err := CollectPins(FilePath(repoPath), "does-not-exist", res); err == nil {
+		t.Fatalf("expected an error for an unknown ref")
+	}
+}
+
+func TestCollectPins_FromRemote(t *testing.T) {
+	repoPath := t.TempDir()
+	repo, err := gitlib.PlainInit(repoPath, false)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+
+	// origin and upstream have diverged: main of each pins a different SHA
+	for _, remote := range []struct{ name, sha string }{{"origin", shaA}, {"upstream", shaB}} {
+		writeWorkflow(t, repoPath, "steps:\n  - uses: actions/checkout@"+remote.sha+" # v4\n")
+		_, err := w.Add(filepath.ToSlash(filepath.Join(".github", "workflows", "ci.yml")))
+		CheckIfError(err)
+		hash, err := w.Commit("update workflow", &gitlib.CommitOptions{
+			Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+		})
+		CheckIfError(err)
+
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: remote.name, URLs: []string{"https://example.com/" + remote.name + ".git"}})
+		CheckIfError(err)
+		CheckIfError(repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName(remote.name, "main"), hash)))
+	}
+
+	res := staticResolver{sha: shaC}
+	for _, tc := range []struct{ remote, want string }{{"origin", shaA}, {"upstream", shaB}} {
+		ref, err := RemoteRef(FilePath(repoPath), tc.remote, "main")
+		if err != nil {
+			t.Fatalf("RemoteRef(%s) returned error: %v", tc.remote, err)
+		}
+		pins, err := CollectPins(FilePath(repoPath), ref, res)
+		CheckIfError(err)
+		if got := pins["actions/checkout"]; !reflect.DeepEqual(got, []string{tc.want}) {
+			t.Fatalf("pins of %s/main = %v; want [%s]", tc.remote, got, tc.want)
+		}
+	}
+
+	if _, err := RemoteRef(FilePath(repoPath), "fork", "main"); err == nil {
+		t.Fatalf("expected an error for an unknown remote")
+	}
+	if _, err := RemoteRef(FilePath(repoPath), "upstream", "release"); err == nil || !strings.Contains(err.Error(), "fetch it first") {
+		t.Fatalf("expected a fetch hint for a branch missing on the remote, got %v", err)
+	}
+}
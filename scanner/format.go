@@ -7,12 +7,22 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
+// trailingCommentRegex matches any trailing comment, Ex: " # keep in sync with ci.yml"
+var trailingCommentRegex = regexp.MustCompile(`^\s+#\s*(.*?)\s*$`)
+
 // Color codes
 const (
 	Reset   = "\033[0m"
@@ -28,21 +38,41 @@ const (
 
 // Finding is a single issue in a workflow file.
 type Finding struct {
-	Line        int    // 1-based line number
-	Column      int    // 1-based column number
-	Description string // human-readable problem description
-	FixSHA      string // suggested replacement
-	FixMsg      string // Fix message
-	Action      string
-	Version     string // version
-	Original    string // e.g. "actions/checkout@v2"
+	Line        int      `json:"line"`         // 1-based line number
+	Column      int      `json:"column"`       // 1-based column number
+	StartOffset int      `json:"start_offset"` // byte offset of the reference within the file
+	EndOffset   int      `json:"end_offset"`   // byte offset just past the reference
+	Description string   `json:"description"`  // human-readable problem description
+	FixSHA      string   `json:"fix_sha"`      // suggested replacement
+	FixMsg      string   `json:"fix_msg"`      // Fix message
+	Action      string   `json:"action"`
+	Version     string   `json:"version"`  // version
+	Original    string   `json:"original"` // e.g. "actions/checkout@v2"
+	Severity    Severity `json:"severity"`
+	MovedTo     string   `json:"moved_to,omitempty"` // new owner/repo when the action repository was renamed or moved
+	// ResolvedVersion is the concrete tag a partial version resolved to, Ex: v4.2 -> v4.2.3
+	ResolvedVersion string     `json:"resolved_version,omitempty"`
+	IgnoredBy       IgnoreRule `json:"ignored_by,omitzero"` // ignore pattern that suppressed the finding, if any
+	// Rule is the rule the finding breaks, Ex: SarifRuleUnpinnedImage. Empty means SarifRuleUnpinnedAction.
+	Rule string `json:"rule,omitempty"`
+}
+
+// RuleID returns the rule the finding breaks, Ex: unpinned-action
+func (f Finding) RuleID() string {
+	if f.Rule == "" {
+		return SarifRuleUnpinnedAction
+	}
+	return f.Rule
 }
 
 // Workflow holds all findings for one GitHub Actions YAML
 type Workflow struct {
-	Name     string    // workflow name (from the YAML)
-	FilePath string    // path to the workflow file
-	Issues   []Finding // all unpinned-version findings
+	Name     string    `json:"name"`              // workflow name (from the YAML)
+	FilePath string    `json:"file_path"`         // path to the workflow file
+	Issues   []Finding `json:"issues"`            // all unpinned-version findings
+	Ignored  []Finding `json:"ignored,omitempty"` // findings suppressed by an ignore pattern
+	// IgnoredBy is the pattern that excluded the whole file from the audit, if any
+	IgnoredBy IgnoreRule `json:"ignored_by,omitzero"`
 }
 
 // FormatAuditReport renders a slice of workflows into a colored CLI report.
@@ -60,8 +90,9 @@ func FormatAuditReport(workflows []Workflow) string {
 			// Issue line: location + message
 			loc := fmt.Sprintf("Line %d, Col %d", f.Line, f.Column)
 			fmt.Fprintf(&b,
-				"  - [%s%s%s] %s%s%s\n",
+				"  - [%s%s%s] (%s) %s%s%s\n",
 				Gray, loc, Reset,
+				f.Severity,
 				Red, f.Description, Reset,
 			)
 			// Fix line
@@ -76,69 +107,438 @@ func FormatAuditReport(workflows []Workflow) string {
 	return b.String()
 }
 
+// ReportFormat selects how an audit report is rendered
+type ReportFormat string
+
+const (
+	ReportFormatText        ReportFormat = "text"        // findings listed per workflow file
+	ReportFormatGrouped     ReportFormat = "grouped"     // findings collapsed per action@version
+	ReportFormatSarif       ReportFormat = "sarif"       // SARIF 2.1.0 document for code scanning
+	ReportFormatTeamCity    ReportFormat = "teamcity"    // TeamCity inspection service messages
+	ReportFormatCodeClimate ReportFormat = "codeclimate" // Code Climate issue list for GitLab Code Quality
+	ReportFormatGitHub      ReportFormat = "github"      // GitHub Actions workflow command annotations
+)
+
+// ParseReportFormat converts a user given value like "Grouped" into a ReportFormat
+func ParseReportFormat(s string) (ReportFormat, error) {
+	switch f := ReportFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case ReportFormatText, ReportFormatGrouped, ReportFormatSarif, ReportFormatTeamCity, ReportFormatCodeClimate, ReportFormatGitHub:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("invalid format: %q. Valid values are text, grouped, sarif, teamcity, codeclimate, github", s)
+}
+
+// Occurrence is a location where a reference is used
+type Occurrence struct {
+	FilePath string
+	Line     int
+	Column   int
+}
+
+// FindingGroup collects every occurrence of the same action@version reference
+type FindingGroup struct {
+	Reference   string // Ex: actions/checkout@v4
+	Severity    Severity
+	FixMsg      string
+	Rule        string // Ex: unpinned-action
+	Occurrences []Occurrence
+}
+
+// GroupFindings collapses findings of the same action@version across workflows.
+// Groups are sorted by reference, occurrences keep the order of the workflows.
+func GroupFindings(workflows []Workflow) []FindingGroup {
+	index := map[string]int{}
+	var groups []FindingGroup
+	for _, wf := range workflows {
+		for _, f := range wf.Issues {
+			i, ok := index[f.Original]
+			if !ok {
+				i = len(groups)
+				index[f.Original] = i
+				groups = append(groups, FindingGroup{Reference: f.Original, Severity: f.Severity, FixMsg: f.FixMsg, Rule: f.RuleID()})
+			}
+
+			g := &groups[i]
+			// Path rules and overrides may rank the same reference differently per file
+			if !g.Severity.AtLeast(f.Severity) {
+				g.Severity = f.Severity
+			}
+			g.Occurrences = append(g.Occurrences, Occurrence{FilePath: wf.FilePath, Line: f.Line, Column: f.Column})
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Reference < groups[j].Reference
+	})
+	return groups
+}
+
+// UniqueActions lists the distinct owner/repo@ref references of the findings,
+// sorted. Findings of other rules, Ex: container images, aren't actions.
+func UniqueActions(workflows []Workflow) []string {
+	var refs []string
+	for _, g := range GroupFindings(workflows) {
+		if g.Rule == SarifRuleUnpinnedAction {
+			refs = append(refs, g.Reference)
+		}
+	}
+
+	return refs
+}
+
+// SortByFindings returns the workflows ordered by their number of findings, most
+// first, so remediation can start with the worst offenders. Ties keep their order.
+func SortByFindings(workflows []Workflow) []Workflow {
+	sorted := slices.Clone(workflows)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Issues) > len(sorted[j].Issues)
+	})
+
+	return sorted
+}
+
+// FormatGroupedReport renders findings grouped by action@version, showing the
+// fix once and every file:line:col occurrence beneath it.
+func FormatGroupedReport(workflows []Workflow) string {
+	var b strings.Builder
+
+	for _, g := range GroupFindings(workflows) {
+		fmt.Fprintf(&b,
+			"%s%s%s (%s) - %d occurrence(s)\n",
+			Cyan, g.Reference, Reset,
+			g.Severity,
+			len(g.Occurrences),
+		)
+		fmt.Fprintf(&b,
+			"    🡆 %sFix:%s %s%s%s\n",
+			Green, Reset,
+			Yellow, g.FixMsg, Reset,
+		)
+		for _, o := range g.Occurrences {
+			fmt.Fprintf(&b, "  - %s%s:%d:%d%s\n", Gray, o.FilePath, o.Line, o.Column, Reset)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
 // ApplyFixesInFile opens the given file, applies all Findings in-place, and
 // writes the file back. It applies fixes in top-to-bottom, left-to-right order
-// so byte offsets remain valid.
-func ApplyFixesInFile(wf Workflow, isDryRun bool) error {
-	// 1) Read original content
+// so byte offsets remain valid. It returns the number of fixes applied, or that
+// would be applied in a dry run. With VerifyAfterFix, a file the fixes would
+// leave unparsable is not written.
+func ApplyFixesInFile(wf Workflow, opts AutoFixOptions) (int, error) {
+	if !opts.VerifyAfterFix {
+		return rewriteFindings(wf, opts.DryRun, fixReplacer(opts, os.Stdout))
+	}
+
+	fix, err := FixInMemory(wf, opts)
+	if err != nil {
+		return 0, err
+	}
+	if !opts.DryRun {
+		if err := writeFileAtomic(wf.FilePath, fix.After); err != nil {
+			return 0, fmt.Errorf("writing %s: %w", wf.FilePath, err)
+		}
+	}
+	return fix.Applied, nil
+}
+
+// FileFix is the content of a workflow file before and after its fixes
+type FileFix struct {
+	Path    string // path of the workflow file
+	Before  []byte // content on disk
+	After   []byte // content with the fixes applied
+	Applied int    // number of fixes applied
+}
+
+// FixInMemory computes the fixes ApplyFixesInFile would apply to the workflow
+// file, without writing it, so they can be shown or saved as a patch
+func FixInMemory(wf Workflow, opts AutoFixOptions) (FileFix, error) {
+	return fixInMemory(wf, opts, os.Stdout)
+}
+
+// fixInMemory is FixInMemory writing the applied and skipped fixes to w
+func fixInMemory(wf Workflow, opts AutoFixOptions, w io.Writer) (FileFix, error) {
+	fix, err := rewriteInMemory(wf, fixReplacer(opts, w))
+	if err != nil || !opts.VerifyAfterFix {
+		return fix, err
+	}
+
+	if err := verifyFix(fix); err != nil {
+		return FileFix{}, err
+	}
+	return fix, nil
+}
+
+// verifyFix re-parses the fixed content of a file as YAML, so a botched rewrite
+// is caught before it corrupts the file. Content that wasn't valid YAML before
+// the fixes, Ex: a template, isn't held against them.
+func verifyFix(fix FileFix) error {
+	var doc yaml.Node
+	if yaml.Unmarshal(fix.Before, &doc) != nil {
+		return nil
+	}
+	if err := yaml.Unmarshal(fix.After, &doc); err != nil {
+		return fmt.Errorf("verify: fixing %s would leave invalid YAML, so it is left unchanged: %w", fix.Path, err)
+	}
+
+	return nil
+}
+
+// ApplyFixesToContent applies the fixes of issues to workflow content, like autofix
+// with default options, and returns the fixed content. Nothing is read, written
+// or printed, so editor integrations can fix unsaved buffers or stdin. Findings
+// that can't be applied, Ex: unresolved or no longer matching content, are left
+// as they are and returned in the error, along with the other fixes.
+func ApplyFixesToContent(content []byte, issues []Finding) ([]byte, error) {
+	replace := fixReplacer(AutoFixOptions{}, io.Discard)
+	fixed := map[Finding]bool{}
+	out, _ := rewriteContent(content, issues, "content", io.Discard, func(issue Finding, rest string, loc string) (string, bool) {
+		pin, ok := replace(issue, rest, loc)
+		fixed[issue] = ok
+		return pin, ok
+	})
+
+	var errs []error
+	for _, issue := range issues {
+		if fixed[issue] {
+			continue
+		}
+		if issue.FixSHA == SHA256NotAvailable {
+			errs = append(errs, fmt.Errorf("fix: %s at line %d, col %d is not applied: %s", issue.Original, issue.Line, issue.Column, issue.FixMsg))
+			continue
+		}
+		errs = append(errs, fmt.Errorf("fix: %s at line %d, col %d is not applied", issue.Original, issue.Line, issue.Column))
+	}
+	return out, errors.Join(errs...)
+}
+
+// fixReplacer returns the replace function of rewriteContent pinning each finding
+// to its FixSHA, honoring opts. Fixes and skipped findings are reported to w.
+func fixReplacer(opts AutoFixOptions, w io.Writer) func(issue Finding, rest string, loc string) (string, bool) {
+	return func(issue Finding, rest string, loc string) (string, bool) {
+		if issue.FixSHA == SHA256NotAvailable {
+			// FixMsg tells whether the reference is missing or GitHub rate limited the lookup
+			fmt.Fprintf(w, "  - [%s%s%s] %s Warning: Couldn't fix the reference: %s. %s%s ⚠️\n", Gray, loc, Reset, Yellow, issue.Original, issue.FixMsg, Reset)
+			return "", false
+		}
+		if isBranchRef(issue.Version) && opts.SkipBranches {
+			fmt.Fprintf(w, "  - [%s%s%s] %s Skipped: '%s' tracks a branch. Re-run without '--skip-branches' to pin it to the branch's current head%s ⚠️\n", Gray, loc, Reset, Yellow, issue.Original, Reset)
+			return "", false
+		}
+
+		// Keep the old name unless asked, as the redirect is only followed by GitHub
+		// for as long as nobody re-creates the old repository
+		action := issue.Action
+		if issue.MovedTo != "" {
+			if opts.RewriteMoved {
+				action = issue.MovedTo
+			} else {
+				fmt.Fprintf(w, "  - [%s%s%s] %s Warning: action moved: %s → %s. Re-run with '--rewrite-moved' to update the reference%s ⚠️\n", Gray, loc, Reset, Yellow, issue.Action, issue.MovedTo, Reset)
+			}
+		}
+
+		version := commentVersion(issue, opts.CommentStyle)
+		pin := withTrailingComment(formatPin(action, issue.FixSHA, version), version, rest, issue)
+		fmt.Fprintf(w, "  - [%s%s%s] %s Fixed: Pinned '%s%s' to '%s' %s\n", Gray, loc, Reset, Green, issue.Action, fmt.Sprintf("@%s", issue.Version), issue.FixSHA, Reset)
+		if isBranchRef(issue.Version) {
+			fmt.Fprintf(w, "  - [%s%s%s] %s Warning: branch pin — will need periodic refresh, as '%s' keeps moving%s ⚠️\n", Gray, loc, Reset, Yellow, issue.Version, Reset)
+		}
+		return pin, true
+	}
+}
+
+// rewriteFindings rewrites the findings of the workflow file with rewriteContent,
+// then writes the file back unless dryRun is set. It returns the number of
+// findings replaced.
+func rewriteFindings(wf Workflow, dryRun bool, replace func(issue Finding, rest string, loc string) (string, bool)) (int, error) {
+	fix, err := rewriteInMemory(wf, replace)
+	if err != nil {
+		return 0, err
+	}
+
+	if !dryRun {
+		if err := writeFileAtomic(wf.FilePath, fix.After); err != nil {
+			return 0, fmt.Errorf("writing %s: %w", wf.FilePath, err)
+		}
+	}
+	return fix.Applied, nil
+}
+
+// rewriteInMemory reads the workflow file and rewrites its findings with
+// rewriteContent, leaving the file untouched
+func rewriteInMemory(wf Workflow, replace func(issue Finding, rest string, loc string) (string, bool)) (FileFix, error) {
 	data, err := os.ReadFile(wf.FilePath)
 	if err != nil {
-		return fmt.Errorf("reading %s: %w", wf.FilePath, err)
+		return FileFix{}, fmt.Errorf("reading %s: %w", wf.FilePath, err)
 	}
+
+	output, applied := rewriteContent(data, wf.Issues, wf.FilePath, os.Stdout, replace)
+	return FileFix{Path: wf.FilePath, Before: data, After: output, Applied: applied}, nil
+}
+
+// rewriteContent replaces the Original of each finding, and the rest of its line,
+// with what replace returns. replace is given the text following Original on its
+// line and the finding's location for messages, and returns false to leave the
+// finding untouched. Findings that no longer match data are reported to w, naming
+// it name, and skipped. It returns the rewritten content and the number of findings
+// replaced.
+func rewriteContent(data []byte, issues []Finding, name string, w io.Writer, replace func(issue Finding, rest string, loc string) (string, bool)) ([]byte, int) {
 	lines := strings.Split(string(data), "\n")
 
-	// 2) Sort issues so earlier lines/columns are applied first
-	sort.Slice(wf.Issues, func(i, j int) bool {
-		if wf.Issues[i].Line != wf.Issues[j].Line {
-			return wf.Issues[i].Line < wf.Issues[j].Line
+	// Sort issues so earlier lines/columns are applied first
+	issues = slices.Clone(issues)
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
 		}
-		return wf.Issues[i].Column < wf.Issues[j].Column
+		return issues[i].Column < issues[j].Column
 	})
 
-	// 3) Apply each fix
-	for _, issue := range wf.Issues {
+	// Apply each replacement. A finding that no longer matches the content, Ex:
+	// after a concurrent edit, is skipped so the remaining fixes still apply.
+	applied := 0
+	for _, issue := range issues {
 		loc := fmt.Sprintf("Line %d, Col %d", issue.Line, issue.Column)
-
-		if issue.FixSHA == SHA256NotAvailable {
-			fmt.Printf("  - [%s%s%s] %s Warning: Couldn't fix the reference: %s. Reference '%s' is not found on GitHub%s ⚠️\n", Gray, loc, Reset, Yellow, issue.Action, issue.Version, Reset)
-			continue
-		}
 		idx := issue.Line - 1
 		if idx < 0 || idx >= len(lines) {
-			return fmt.Errorf("invalid line %d in %s", issue.Line, wf.FilePath)
+			skipFinding(w, loc, fmt.Sprintf("invalid line %d in %s", issue.Line, name))
+			continue
 		}
 
-		line := lines[idx]
-		if issue.Column-1 > len(line) {
-			return fmt.Errorf(
-				"column %d out of range on line %d (%q)",
-				issue.Column, issue.Line, line,
-			)
+		// CRLF files keep their line endings; the \r must not end up in comments
+		line, crlf := strings.CutSuffix(lines[idx], "\r")
+		if issue.Column < 1 || issue.Column-1 > len(line) {
+			skipFinding(w, loc, fmt.Sprintf("column %d out of range on line %d (%q)", issue.Column, issue.Line, line))
+			continue
 		}
 
 		// Split at the byte offset; then replace the first occurrence of Original
 		prefix := line[:issue.Column-1]
 		suffix := line[issue.Column-1:]
-		if !strings.Contains(suffix, issue.Original) {
-			return fmt.Errorf(
-				"could not find %q at line %d, col %d in %s",
-				issue.Original, issue.Line, issue.Column, wf.FilePath,
-			)
+		at := strings.Index(suffix, issue.Original)
+		if at < 0 {
+			skipFinding(w, loc, fmt.Sprintf("could not find %q at line %d, col %d in %s", issue.Original, issue.Line, issue.Column, name))
+			continue
 		}
 
 		// Perform exactly one replacement
-		newSuffix := strings.Replace(suffix, issue.Original, fmt.Sprintf("%s@%s # %s", issue.Action, issue.FixSHA, issue.Version), 1)
-		lines[idx] = prefix + newSuffix
-		fmt.Printf("  - [%s%s%s] %s Fixed: Pinned '%s%s' to '%s' %s\n", Gray, loc, Reset, Green, issue.Action, fmt.Sprintf("@%s", issue.Version), issue.FixSHA, Reset)
+		replaced, ok := replace(issue, suffix[at+len(issue.Original):], loc)
+		if !ok {
+			continue
+		}
+		lines[idx] = prefix + suffix[:at] + replaced
+		if crlf {
+			lines[idx] += "\r"
+		}
+		applied++
 	}
 
-	// 4) Write back (you could write to a temp file + rename for safety)
-	output := strings.Join(lines, "\n")
+	return []byte(strings.Join(lines, "\n")), applied
+}
 
-	if !isDryRun {
-		if err := os.WriteFile(wf.FilePath, []byte(output), os.ModeAppend); err != nil {
-			return fmt.Errorf("writing %s: %w", wf.FilePath, err)
-		}
+// writeFileAtomic replaces the file at path with data through a temp file in the
+// same directory, so an interrupted write never leaves a truncated workflow. The
+// file keeps its permission bits, and a symlink keeps pointing at the rewritten file.
+func writeFileAtomic(path string, data []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("os: %w", err)
 	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("os: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("os: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("os: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("os: %w", err)
+	}
+
 	return nil
 }
+
+// skipFinding reports a finding that rewriteContent leaves untouched to w
+func skipFinding(w io.Writer, loc string, reason string) {
+	fmt.Fprintf(w, "  - [%s%s%s] %s Warning: Skipped: %s%s ⚠️\n", Gray, loc, Reset, Yellow, reason, Reset)
+}
+
+// commentVersion picks the version written after a pinned SHA for the given style
+func commentVersion(issue Finding, style CommentStyle) string {
+	if style == CommentStyleNone {
+		return ""
+	}
+
+	// The tag style keeps the ref as written; semver notes the version looked
+	// up for the SHA, or the concrete tag a partial version resolved to
+	if style == CommentStyleSemver && issue.ResolvedVersion != "" {
+		return issue.ResolvedVersion
+	}
+	return issue.Version
+}
+
+// withTrailingComment appends what followed the original reference to its pin.
+// An existing comment is merged into the version comment, Ex: "# v4 - keep in
+// sync", so the line never carries two comments. A comment naming the version
+// of issue, Ex: left behind by an earlier pin, is dropped; other comments, Ex:
+// "# nosemgrep", are kept. The closing quote of a quoted reference stays next
+// to the pin, with the comment after it.
+func withTrailingComment(pin string, version string, rest string, issue Finding) string {
+	if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, "'") {
+		ref, comment, found := strings.Cut(pin, " # ")
+		pin = ref + rest[:1]
+		if found {
+			pin += " # " + comment
+		}
+		rest = rest[1:]
+	}
+
+	m := trailingCommentRegex.FindStringSubmatch(rest)
+	if m == nil {
+		return pin + rest
+	}
+	if m[1] == "" || isVersionHint(m[1], issue) {
+		return pin
+	}
+	if version == "" {
+		return pin + rest
+	}
+
+	return fmt.Sprintf("%s - %s", pin, m[1])
+}
+
+// isVersionHint reports whether a comment merely names the version of issue,
+// as written or as resolved, Ex: v4 of actions/checkout@v4
+func isVersionHint(comment string, issue Finding) bool {
+	return comment == issue.Version || (issue.ResolvedVersion != "" && comment == issue.ResolvedVersion)
+}
+
+// formatPin formats a pinned reference like "owner/repo@<sha> # <version>"
+func formatPin(action string, sha string, version string) string {
+	if version == "" {
+		return fmt.Sprintf("%s@%s", action, sha)
+	}
+	return fmt.Sprintf("%s@%s # %s", action, sha, version)
+}
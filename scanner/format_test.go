@@ -0,0 +1,428 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGroupFindingsCollapsesDuplicates(t *testing.T) {
+	checkout := Finding{Action: "actions/checkout", Version: "v4", Original: "actions/checkout@v4", Severity: SeverityMedium, FixMsg: "Pin `actions/checkout` to sha"}
+	wfs := []Workflow{
+		{FilePath: "a.yml", Issues: []Finding{
+			withLocation(checkout, 3, 15),
+			{Action: "actions/setup-go", Version: "main", Original: "actions/setup-go@main", Severity: SeverityHigh, Line: 5, Column: 15},
+			withLocation(checkout, 9, 15),
+		}},
+		{FilePath: "b.yml", Issues: []Finding{withLocation(checkout, 4, 11)}},
+	}
+
+	groups := GroupFindings(wfs)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %+v", len(groups), groups)
+	}
+
+	g := groups[0]
+	if g.Reference != "actions/checkout@v4" || len(g.Occurrences) != 3 {
+		t.Fatalf("unexpected first group: %+v", g)
+	}
+	want := []Occurrence{{"a.yml", 3, 15}, {"a.yml", 9, 15}, {"b.yml", 4, 11}}
+	for i, o := range want {
+		if g.Occurrences[i] != o {
+			t.Errorf("occurrence %d = %+v; want %+v", i, g.Occurrences[i], o)
+		}
+	}
+	if groups[1].Reference != "actions/setup-go@main" || groups[1].Severity != SeverityHigh {
+		t.Fatalf("unexpected second group: %+v", groups[1])
+	}
+
+	report := FormatGroupedReport(wfs)
+	if n := strings.Count(report, "Pin `actions/checkout` to sha"); n != 1 {
+		t.Fatalf("expected the fix to be shown once, got %d times:\n%s", n, report)
+	}
+	if !strings.Contains(report, "b.yml:4:11") {
+		t.Fatalf("expected every occurrence to be listed:\n%s", report)
+	}
+}
+
+func withLocation(f Finding, line int, col int) Finding {
+	f.Line, f.Column = line, col
+	return f
+}
+
+func TestParseReportFormat(t *testing.T) {
+	if f, err := ParseReportFormat("Grouped"); err != nil || f != ReportFormatGrouped {
+		t.Fatalf("ParseReportFormat(Grouped) = %q, %v", f, err)
+	}
+	if _, err := ParseReportFormat("table"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestUniqueActionsIsSortedAndDeduped(t *testing.T) {
+	wfs := []Workflow{
+		{FilePath: "a.yml", Issues: []Finding{
+			{Original: "actions/setup-go@v5"},
+			{Original: "actions/checkout@v4"},
+			{Original: "actions/setup-go@v5"},
+		}},
+		{FilePath: "b.yml", Issues: []Finding{
+			{Original: "actions/checkout@v4"},
+			{Original: "actions/checkout@main"},
+		}},
+	}
+
+	got := UniqueActions(wfs)
+	want := []string{"actions/checkout@main", "actions/checkout@v4", "actions/setup-go@v5"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("UniqueActions = %v; want %v", got, want)
+	}
+}
+
+func TestSortByFindingsIsDescending(t *testing.T) {
+	one := []Finding{{Original: "actions/checkout@v4"}}
+	three := []Finding{{Original: "actions/checkout@v4"}, {Original: "actions/setup-go@v5"}, {Original: "actions/cache@v3"}}
+	two := three[:2]
+	wfs := []Workflow{
+		{FilePath: "one.yml", Issues: one},
+		{FilePath: "three.yml", Issues: three},
+		{FilePath: "also-one.yml", Issues: one},
+		{FilePath: "two.yml", Issues: two},
+	}
+
+	var got []string
+	for _, wf := range SortByFindings(wfs) {
+		got = append(got, wf.FilePath)
+	}
+	want := []string{"three.yml", "two.yml", "one.yml", "also-one.yml"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("SortByFindings order = %v; want %v", got, want)
+	}
+	if wfs[0].FilePath != "one.yml" {
+		t.Fatal("expected the input to be left untouched")
+	}
+}
+
+func TestApplyFixesInFileKeepsPermissions(t *testing.T) {
+	dir := t.TempDir()
+	content := "steps:\n  - uses: actions/checkout@v4\n"
+	want := "steps:\n  - uses: actions/checkout@" + strings.Repeat("a", 40) + " # v4\n"
+
+	for _, perm := range []os.FileMode{0o644, 0o600, 0o755} {
+		path := filepath.Join(dir, perm.String()+".yml")
+		if err := os.WriteFile(path, []byte(content), perm); err != nil {
+			t.Fatalf("writing workflow: %v", err)
+		}
+		if err := os.Chmod(path, perm); err != nil {
+			t.Fatalf("chmod: %v", err)
+		}
+
+		wf, err := AssembleWorkflow(staticResolver{sha: strings.Repeat("a", 40)}, []byte(content), filepath.Base(path), path)
+		if err != nil {
+			t.Fatalf("AssembleWorkflow returned error: %v", err)
+		}
+		var n int
+		captureStdout(t, func() {
+			n, err = ApplyFixesInFile(*wf, AutoFixOptions{})
+		})
+		if err != nil || n != 1 {
+			t.Fatalf("ApplyFixesInFile = %d, %v; want 1 fix", n, err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		if info.Mode() != perm {
+			t.Errorf("mode after fixing = %v; want %v", info.Mode(), perm)
+		}
+		if got, _ := os.ReadFile(path); string(got) != want {
+			t.Errorf("content after fixing = %q; want %q", got, want)
+		}
+	}
+
+	// Only the fixed files are left; no temp files linger
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("expected 3 files in %s, got %d: %v", dir, len(entries), err)
+	}
+}
+
+func TestApplyFixesToContentMatchesFile(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+	for name, content := range map[string]string{
+		"plain":    "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@v5 # keep in sync\n",
+		"crlf":     "steps:\r\n  - uses: actions/checkout@v4\r\n  - uses: actions/cache@v3\r\n",
+		"no fixes": "steps:\n  - run: echo hello\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ci.yml")
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("writing workflow: %v", err)
+			}
+			wf, err := AssembleWorkflow(staticResolver{sha: sha}, []byte(content), "ci.yml", path)
+			if err != nil {
+				t.Fatalf("AssembleWorkflow returned error: %v", err)
+			}
+
+			var fixed []byte
+			captureStdout(t, func() {
+				fixed, err = ApplyFixesToContent([]byte(content), wf.Issues)
+			})
+			if err != nil {
+				t.Fatalf("ApplyFixesToContent returned error: %v", err)
+			}
+			if len(wf.Issues) > 0 && string(fixed) == content {
+				t.Fatalf("expected %d fixes in %q", len(wf.Issues), fixed)
+			}
+
+			captureStdout(t, func() {
+				_, err = ApplyFixesInFile(*wf, AutoFixOptions{})
+			})
+			if err != nil {
+				t.Fatalf("ApplyFixesInFile returned error: %v", err)
+			}
+			if got, _ := os.ReadFile(path); string(got) != string(fixed) {
+				t.Fatalf("in-memory fix = %q; file fix = %q", fixed, got)
+			}
+		})
+	}
+}
+
+func TestApplyFixesToContentLeavesInputUntouched(t *testing.T) {
+	content := []byte("steps:\n  - uses: actions/cache@v3\n  - uses: actions/checkout@v4\n")
+	wf, err := AssembleWorkflow(staticResolver{sha: strings.Repeat("a", 40)}, content, "ci.yml", "ci.yml")
+	if err != nil || len(wf.Issues) != 2 {
+		t.Fatalf("AssembleWorkflow = %+v, %v; want 2 issues", wf, err)
+	}
+	// Out of order, so sorting would be visible to the caller
+	issues := []Finding{wf.Issues[1], wf.Issues[0]}
+
+	captureStdout(t, func() {
+		_, err = ApplyFixesToContent(content, issues)
+	})
+	if err != nil {
+		t.Fatalf("ApplyFixesToContent returned error: %v", err)
+	}
+	if string(content) != "steps:\n  - uses: actions/cache@v3\n  - uses: actions/checkout@v4\n" {
+		t.Fatalf("content was modified: %q", content)
+	}
+	if issues[0].Line != 3 {
+		t.Fatalf("issues were reordered: %+v", issues)
+	}
+}
+
+func TestApplyFixesToContentReportsUnappliedFindings(t *testing.T) {
+	content := []byte("steps:\n  - uses: actions/checkout@v4\n  - uses: actions/cache@v3\n")
+	wf, err := AssembleWorkflow(staticResolver{sha: strings.Repeat("a", 40)}, content, "ci.yml", "ci.yml")
+	if err != nil || len(wf.Issues) != 2 {
+		t.Fatalf("AssembleWorkflow = %+v, %v; want 2 issues", wf, err)
+	}
+	wf.Issues[1].FixSHA = SHA256NotAvailable
+	wf.Issues[1].FixMsg = "Reference 'v3' is not found on GitHub."
+
+	var fixed []byte
+	out := captureStdout(t, func() {
+		fixed, err = ApplyFixesToContent(content, wf.Issues)
+	})
+	if out != "" {
+		t.Fatalf("expected nothing on stdout, got %q", out)
+	}
+	if err == nil || !strings.Contains(err.Error(), "actions/cache@v3 at line 3, col 11 is not applied: Reference 'v3' is not found") {
+		t.Fatalf("expected an error naming actions/cache@v3, got %v", err)
+	}
+	if want := "steps:\n  - uses: actions/checkout@" + strings.Repeat("a", 40) + " # v4\n  - uses: actions/cache@v3\n"; string(fixed) != want {
+		t.Fatalf("fixed = %q; want %q", fixed, want)
+	}
+}
+
+func TestWriteFileAtomicFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "ci.yml")
+	link := filepath.Join(dir, "link.yml")
+	if err := os.WriteFile(target, []byte("old"), 0o644); err != nil {
+		t.Fatalf("writing target: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if err := writeFileAtomic(link, []byte("new")); err != nil {
+		t.Fatalf("writeFileAtomic returned error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected %s to stay a symlink", link)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Fatalf("target content = %q; want new", got)
+	}
+}
+
+func TestApplyFixesInFileSkipsStaleFindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ci.yml")
+	content := "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@v5\n  - uses: actions/cache@v3\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing workflow: %v", err)
+	}
+
+	sha := strings.Repeat("a", 40)
+	wf, err := AssembleWorkflow(staticResolver{sha: sha}, []byte(content), "ci.yml", path)
+	if err != nil || len(wf.Issues) != 3 {
+		t.Fatalf("AssembleWorkflow = %+v, %v; want 3 issues", wf, err)
+	}
+	// The file moved on since the audit: setup-go was bumped, and a finding points past the end
+	if err := os.WriteFile(path, []byte(strings.Replace(content, "setup-go@v5", "setup-go@v6", 1)), 0o644); err != nil {
+		t.Fatalf("rewriting workflow: %v", err)
+	}
+	wf.Issues = append(wf.Issues, Finding{Line: 42, Column: 1, Action: "actions/gone", Version: "v1", Original: "actions/gone@v1", FixSHA: sha})
+
+	var n int
+	output := captureStdout(t, func() {
+		n, err = ApplyFixesInFile(*wf, AutoFixOptions{})
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("ApplyFixesInFile = %d, %v; want 2 fixes", n, err)
+	}
+	if strings.Count(output, "Skipped") != 2 {
+		t.Fatalf("expected both stale findings to be reported:\n%s", output)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := "steps:\n  - uses: actions/checkout@" + sha + " # v4\n  - uses: actions/setup-go@v6\n  - uses: actions/cache@" + sha + " # v3\n"
+	if string(got) != want {
+		t.Fatalf("content = %q; want %q", got, want)
+	}
+}
+
+func TestApplyFixesInFilePreservesLineEndings(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "LF terminated",
+			content: "steps:\n  - uses: actions/checkout@v4\n  - run: make\n",
+			want:    "steps:\n  - uses: actions/checkout@" + sha + " # v4\n  - run: make\n",
+		},
+		{
+			name:    "not terminated",
+			content: "steps:\n  - run: make\n  - uses: actions/checkout@v4",
+			want:    "steps:\n  - run: make\n  - uses: actions/checkout@" + sha + " # v4",
+		},
+		{
+			name:    "CRLF terminated",
+			content: "steps:\r\n  - uses: actions/checkout@v4\r\n  - uses: actions/setup-go@v5 # keep in sync\r\n",
+			want:    "steps:\r\n  - uses: actions/checkout@" + sha + " # v4\r\n  - uses: actions/setup-go@" + sha + " # v5 - keep in sync\r\n",
+		},
+		{
+			name:    "blank lines at the end",
+			content: "steps:\n  - uses: actions/checkout@v4\n\n\n",
+			want:    "steps:\n  - uses: actions/checkout@" + sha + " # v4\n\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ci.yml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o640); err != nil {
+				t.Fatalf("writing workflow: %v", err)
+			}
+			if err := os.Chmod(path, 0o640); err != nil {
+				t.Fatalf("chmod: %v", err)
+			}
+
+			wf, err := AssembleWorkflow(staticResolver{sha: sha}, []byte(tt.content), "ci.yml", path)
+			if err != nil {
+				t.Fatalf("AssembleWorkflow returned error: %v", err)
+			}
+			captureStdout(t, func() {
+				_, err = ApplyFixesInFile(*wf, AutoFixOptions{})
+			})
+			if err != nil {
+				t.Fatalf("ApplyFixesInFile returned error: %v", err)
+			}
+
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want {
+				t.Fatalf("content = %q; want %q", got, tt.want)
+			}
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+				t.Fatalf("expected mode 0640 to be kept, got %v, %v", info.Mode(), err)
+			}
+		})
+	}
+}
+
+func TestApplyFixesInFileVerifyAfterFix(t *testing.T) {
+	content := "steps:\n  - uses: actions/checkout@v4\n"
+	path := filepath.Join(t.TempDir(), "ci.yml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing workflow: %v", err)
+	}
+
+	// A botched fix whose replacement breaks the YAML of the line
+	wf, err := AssembleWorkflow(staticResolver{sha: "deadbeef: ["}, []byte(content), filepath.Base(path), path)
+	if err != nil {
+		t.Fatalf("AssembleWorkflow returned error: %v", err)
+	}
+
+	var n int
+	captureStdout(t, func() {
+		n, err = ApplyFixesInFile(*wf, AutoFixOptions{VerifyAfterFix: true})
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid YAML") {
+		t.Fatalf("ApplyFixesInFile = %d, %v; want an invalid YAML error", n, err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != content {
+		t.Fatalf("content after a failed verification = %q; want it unchanged", got)
+	}
+
+	// Without verification the broken fix is written, which is what it guards against
+	captureStdout(t, func() {
+		_, err = ApplyFixesInFile(*wf, AutoFixOptions{})
+	})
+	if got, _ := os.ReadFile(path); err != nil || string(got) == content {
+		t.Fatalf("expected the unverified fix to be written, got %q, %v", got, err)
+	}
+}
+
+func TestApplyFixesToContentKeepsQuotesAroundThePin(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+	cases := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"double quoted", `  - uses: "actions/checkout@v4"`, `  - uses: "actions/checkout@` + sha + `" # v4`},
+		{"single quoted", `  - uses: 'actions/checkout@v4'`, `  - uses: 'actions/checkout@` + sha + `' # v4`},
+		{"quoted with a comment", `  - uses: 'foo/bar@v1' # keep`, `  - uses: 'foo/bar@` + sha + `' # v1 - keep`},
+		{"quoted with a version comment", `  - uses: "foo/bar@v1" # v1`, `  - uses: "foo/bar@` + sha + `" # v1`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			content := []byte("steps:\n" + tc.line + "\n")
+			wf, err := AssembleWorkflow(staticResolver{sha: sha}, content, "ci.yml", "ci.yml")
+			if err != nil || len(wf.Issues) != 1 {
+				t.Fatalf("AssembleWorkflow = %+v, %v; want 1 issue", wf, err)
+			}
+
+			fixed, err := ApplyFixesToContent(content, wf.Issues)
+			if err != nil {
+				t.Fatalf("ApplyFixesToContent returned error: %v", err)
+			}
+			if want := "steps:\n" + tc.want + "\n"; string(fixed) != want {
+				t.Fatalf("fixed = %q; want %q", fixed, want)
+			}
+		})
+	}
+}
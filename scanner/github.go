@@ -0,0 +1,47 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GitHubActionsEnv is set to "true" by GitHub Actions in every job
+const GitHubActionsEnv = "GITHUB_ACTIONS"
+
+// gitHubDataEscaper escapes the message of a workflow command
+var gitHubDataEscaper = strings.NewReplacer(
+	"%", "%25",
+	"\r", "%0D",
+	"\n", "%0A",
+)
+
+// gitHubPropertyEscaper escapes the properties of a workflow command, which
+// are separated by , and ended by ::
+var gitHubPropertyEscaper = strings.NewReplacer(
+	"%", "%25",
+	"\r", "%0D",
+	"\n", "%0A",
+	":", "%3A",
+	",", "%2C",
+)
+
+// FormatGitHubAnnotations renders the findings of a report as GitHub Actions
+// workflow commands, Ex: ::error file=.github/workflows/ci.yml,line=12,col=15::...,
+// so a job shows them as inline annotations on the pull request
+func FormatGitHubAnnotations(r *AuditReport) string {
+	var b strings.Builder
+	for _, wf := range r.Workflows {
+		file := gitHubPropertyEscaper.Replace(sarifArtifact(r.Root, wf.FilePath).URI)
+		for _, f := range wf.Issues {
+			fmt.Fprintf(&b, "::error file=%s,line=%d,col=%d::%s\n", file, f.Line, f.Column, gitHubDataEscaper.Replace(f.FixMsg))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatGitHubAnnotations(t *testing.T) {
+	root := t.TempDir()
+	report := &AuditReport{
+		Root: root,
+		Workflows: []Workflow{{
+			FilePath: filepath.Join(root, ".github", "workflows", "ci.yml"),
+			Issues: []Finding{
+				{Line: 12, Column: 15, Severity: SeverityHigh, FixMsg: "Pin `actions/setup-go` to sha-go"},
+				{Line: 20, Column: 9, Severity: SeverityMedium, FixMsg: "Reference 'v9' is not found: 100%\nretry later"},
+			},
+		}},
+	}
+
+	want := "::error file=.github/workflows/ci.yml,line=12,col=15::Pin `actions/setup-go` to sha-go\n" +
+		"::error file=.github/workflows/ci.yml,line=20,col=9::Reference 'v9' is not found: 100%25%0Aretry later\n"
+	if got := FormatGitHubAnnotations(report); got != want {
+		t.Fatalf("FormatGitHubAnnotations =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatGitHubAnnotationsEscapesFileProperty(t *testing.T) {
+	root := t.TempDir()
+	report := &AuditReport{
+		Root: root,
+		Workflows: []Workflow{{
+			FilePath: filepath.Join(root, ".github", "workflows", "a,b:c.yml"),
+			Issues:   []Finding{{Line: 1, Column: 1, FixMsg: "msg"}},
+		}},
+	}
+
+	want := "::error file=.github/workflows/a%2Cb%3Ac.yml,line=1,col=1::msg\n"
+	if got := FormatGitHubAnnotations(report); got != want {
+		t.Fatalf("FormatGitHubAnnotations = %q; want %q", got, want)
+	}
+}
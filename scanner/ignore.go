@@ -0,0 +1,267 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cybrota/scharf/config"
+)
+
+// ScharfIgnoreFile lists glob patterns, one per line, of workflow paths or action
+// names to leave alone. Ex: my-org/* for trusted first-party actions.
+const ScharfIgnoreFile = ".scharfignore"
+
+// IgnoreRule is one configured ignore pattern and where it is configured
+type IgnoreRule struct {
+	Source  string `json:"source"`  // Ex: .scharf.yml path_rules["services/*"]
+	Pattern string `json:"pattern"` // action name or glob
+}
+
+func (r IgnoreRule) String() string {
+	return fmt.Sprintf("%s: %s", r.Source, r.Pattern)
+}
+
+// ConfiguredIgnores lists every ignore pattern configured for a repository
+func ConfiguredIgnores(path FilePath) ([]IgnoreRule, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	cfg, err := config.LoadFromRepo(abs)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	patterns, err := LoadScharfIgnore(abs)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	var rules []IgnoreRule
+	for _, pattern := range patterns {
+		rules = append(rules, scharfIgnoreRule(pattern))
+	}
+	for glob, pr := range cfg.PathRules {
+		for _, pattern := range pr.Ignore {
+			rules = append(rules, IgnoreRule{Source: pathRuleSource(glob), Pattern: pattern})
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Source != rules[j].Source {
+			return rules[i].Source < rules[j].Source
+		}
+		return rules[i].Pattern < rules[j].Pattern
+	})
+	return rules, nil
+}
+
+// UnusedIgnores returns the rules that suppressed no finding of the audited workflows,
+// so stale entries can be pruned
+func UnusedIgnores(rules []IgnoreRule, wfs []Workflow) []IgnoreRule {
+	fired := map[IgnoreRule]bool{}
+	for _, wf := range wfs {
+		if wf.IgnoredBy != (IgnoreRule{}) {
+			fired[wf.IgnoredBy] = true
+		}
+		for _, f := range wf.Ignored {
+			fired[f.IgnoredBy] = true
+		}
+	}
+
+	var unused []IgnoreRule
+	for _, r := range rules {
+		if !fired[r] {
+			unused = append(unused, r)
+		}
+	}
+
+	return unused
+}
+
+// LoadScharfIgnore reads the patterns of the .scharfignore file at the repository
+// root. Blank lines and lines starting with # are skipped. A missing file means
+// no patterns.
+func LoadScharfIgnore(root string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, ScharfIgnoreFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	return parseScharfIgnore(data)
+}
+
+func parseScharfIgnore(data []byte) ([]string, error) {
+	var patterns []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := path.Match(strings.Trim(line, "/"), ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern %q: %w", ScharfIgnoreFile, n, line, err)
+		}
+		patterns = append(patterns, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ScharfIgnoreFile, err)
+	}
+
+	return patterns, nil
+}
+
+func scharfIgnoreRule(pattern string) IgnoreRule {
+	return IgnoreRule{Source: ScharfIgnoreFile, Pattern: pattern}
+}
+
+// matchesIgnorePath matches a slash separated path relative to the repository root,
+// gitignore style: a pattern without a slash matches any path element (Ex: deploy.yml),
+// a leading slash anchors the pattern to the root and a pattern matching a directory
+// matches everything below it.
+func matchesIgnorePath(pattern string, rel string) bool {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	if !anchored && !strings.Contains(pattern, "/") {
+		for _, elem := range strings.Split(rel, "/") {
+			if ok, _ := path.Match(pattern, elem); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredPathBy returns the first pattern matching a workflow path relative to the repository root
+func ignoredPathBy(patterns []string, rel string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchesIgnorePath(pattern, rel) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// ignoredActionBy returns the first pattern matching an action name. Ex: my-org/* matches my-org/deploy
+func ignoredActionBy(patterns []string, action string) (string, bool) {
+	for _, pattern := range patterns {
+		if actionMatches(pattern, action) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// actionMatches reports whether an action glob matches an action, Ex: github/*
+// matches github/codeql-action/init. A sub-action or reusable workflow is matched
+// by its owner/repo too, and names are matched case-insensitively like GitHub does.
+func actionMatches(pattern string, action string) bool {
+	pattern, action = strings.ToLower(pattern), strings.ToLower(action)
+	repo, _ := splitActionPath(action)
+	if ok, _ := path.Match(pattern, repo); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, action)
+	return ok
+}
+
+// IgnoreLineSource is the source of the rules given with the --ignore-line flag
+const IgnoreLineSource = "--ignore-line"
+
+// lineIgnore excludes the findings on a range of lines of one file
+type lineIgnore struct {
+	spec       string // as given, Ex: .github/workflows/ci.yml:12-14
+	file       string // slash separated and relative to the repository root
+	start, end int    // 1-based and inclusive
+}
+
+// ValidateIgnoreLines rejects malformed locations of AuditOptions.IgnoreLines up
+// front. Locations are <file>:<line> or <file>:<start>-<end>, Ex: .github/workflows/ci.yml:12,
+// with files relative to the repository root. They suit one-off suppressions in
+// files that can't be edited, Ex: generated workflows.
+func ValidateIgnoreLines(specs []string) error {
+	_, err := parseIgnoreLines(specs)
+	return err
+}
+
+// parseIgnoreLines parses the locations of AuditOptions.IgnoreLines
+func parseIgnoreLines(specs []string) ([]lineIgnore, error) {
+	var ignores []lineIgnore
+	for _, spec := range specs {
+		li, err := parseIgnoreLine(spec)
+		if err != nil {
+			return nil, err
+		}
+		ignores = append(ignores, li)
+	}
+
+	return ignores, nil
+}
+
+func parseIgnoreLine(spec string) (lineIgnore, error) {
+	invalid := fmt.Errorf("invalid ignore line %q: want <file>:<line> or <file>:<start>-<end>, Ex: .github/workflows/ci.yml:12", spec)
+
+	i := strings.LastIndex(spec, ":")
+	if i <= 0 {
+		return lineIgnore{}, invalid
+	}
+	file, lines := spec[:i], spec[i+1:]
+
+	first, last, isRange := strings.Cut(lines, "-")
+	start, err := strconv.Atoi(first)
+	if err != nil || start < 1 {
+		return lineIgnore{}, invalid
+	}
+	end := start
+	if isRange {
+		end, err = strconv.Atoi(last)
+		if err != nil || end < start {
+			return lineIgnore{}, invalid
+		}
+	}
+
+	return lineIgnore{spec: spec, file: path.Clean(filepath.ToSlash(file)), start: start, end: end}, nil
+}
+
+// ignoredLineBy returns the first ignore line matching a finding of a file, given
+// by its path relative to the repository root
+func ignoredLineBy(ignores []lineIgnore, rel string, line int) (string, bool) {
+	for _, li := range ignores {
+		if li.file == rel && line >= li.start && line <= li.end {
+			return li.spec, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cybrota/scharf/config"
+)
+
+func TestUnusedIgnores(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, "uses: actions/checkout@v4\nuses: actions/setup-go@v5\n")
+	writeFileAt(t, filepath.Join(tmp, config.FileName), `path_rules:
+  ".github/workflows/*":
+    ignore: ["actions/setup-*", "old/removed"]
+  "services/*/workflows/*":
+    ignore: ["actions/checkout"]
+`)
+
+	var wfs *[]Workflow
+	captureStdout(t, func() {
+		var err error
+		wfs, err = AuditRepository(FilePath(tmp), staticResolver{sha: "sha"})
+		if err != nil {
+			t.Fatalf("AuditRepository returned error: %v", err)
+		}
+	})
+
+	rules, err := ConfiguredIgnores(FilePath(tmp))
+	if err != nil {
+		t.Fatalf("ConfiguredIgnores returned error: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 configured ignores, got %v", rules)
+	}
+
+	got := UnusedIgnores(rules, *wfs)
+	want := []IgnoreRule{
+		{Source: `.scharf.yml path_rules[".github/workflows/*"]`, Pattern: "old/removed"},
+		// actions/checkout is flagged, but not in a workflow this rule covers
+		{Source: `.scharf.yml path_rules["services/*/workflows/*"]`, Pattern: "actions/checkout"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("UnusedIgnores = %v; want %v", got, want)
+	}
+
+	// The fired pattern keeps its finding aside instead of reporting it
+	if len(*wfs) != 1 || len((*wfs)[0].Ignored) != 1 || (*wfs)[0].Ignored[0].Action != "actions/setup-go" {
+		t.Fatalf("expected actions/setup-go to be ignored, got %+v", *wfs)
+	}
+}
+
+func TestUnusedIgnores_NoneConfigured(t *testing.T) {
+	rules, err := ConfiguredIgnores(FilePath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("ConfiguredIgnores returned error: %v", err)
+	}
+	if got := UnusedIgnores(rules, nil); len(got) != 0 {
+		t.Fatalf("expected no unused ignores, got %v", got)
+	}
+}
+
+func TestParseScharfIgnore(t *testing.T) {
+	data := []byte("# trusted first-party actions\n\nmy-org/*\n   \n  # indented comment\nlegacy/  \n")
+
+	patterns, err := parseScharfIgnore(data)
+	if err != nil {
+		t.Fatalf("parseScharfIgnore returned error: %v", err)
+	}
+	if want := []string{"my-org/*", "legacy/"}; !reflect.DeepEqual(patterns, want) {
+		t.Fatalf("patterns = %v; want %v", patterns, want)
+	}
+
+	if _, err := parseScharfIgnore([]byte("ok/*\nbad[\n")); err == nil || !strings.Contains(err.Error(), ".scharfignore:2") {
+		t.Fatalf("expected an error naming the invalid line, got: %v", err)
+	}
+}
+
+func TestMatchesIgnorePath(t *testing.T) {
+	cases := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"deploy.yml", ".github/workflows/deploy.yml", true},
+		{"*.yaml", ".github/workflows/ci.yml", false},
+		{"services/legacy/", "services/legacy/.github/workflows/ci.yml", true},
+		{"services/*/.github/workflows/ci.yml", "services/api/.github/workflows/ci.yml", true},
+		{"/.github/workflows/ci.yml", ".github/workflows/ci.yml", true},
+		{"/ci.yml", ".github/workflows/ci.yml", false},
+		{"legacy", "services/legacy/.github/workflows/ci.yml", true},
+	}
+	for _, tc := range cases {
+		if got := matchesIgnorePath(tc.pattern, tc.rel); got != tc.want {
+			t.Errorf("matchesIgnorePath(%q, %q) = %v; want %v", tc.pattern, tc.rel, got, tc.want)
+		}
+	}
+}
+
+func TestIgnoredActionBy(t *testing.T) {
+	cases := []struct {
+		pattern string
+		action  string
+		want    bool
+	}{
+		{"my-org/*", "my-org/deploy", true},
+		{"github/*", "github/codeql-action/init", true},
+		{"github/codeql-action", "github/codeql-action/analyze", true},
+		{"github/codeql-action/init", "github/codeql-action/init", true},
+		{"github/codeql-action/init", "github/codeql-action/analyze", false},
+		{"actions/checkout", "Actions/Checkout", true},
+		{"My-Org/*", "my-org/deploy", true},
+		{"actions/*", "other/checkout", false},
+	}
+	for _, tc := range cases {
+		if _, got := ignoredActionBy([]string{tc.pattern}, tc.action); got != tc.want {
+			t.Errorf("ignoredActionBy(%q, %q) = %v; want %v", tc.pattern, tc.action, got, tc.want)
+		}
+	}
+}
+
+func TestScharfIgnore(t *testing.T) {
+	setup := func(t *testing.T) string {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "ci.yml"), "steps:\n  - uses: my-org/deploy@v1\n  - uses: actions/checkout@v4\n")
+		writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "legacy.yml"), "steps:\n  - uses: actions/setup-go@v5\n")
+		writeFileAt(t, filepath.Join(tmp, ScharfIgnoreFile), "# first-party actions are trusted\nmy-org/*\n\n# frozen workflows\nlegacy.yml\nunused/*\n")
+		return tmp
+	}
+
+	t.Run("audit skips matching paths and actions", func(t *testing.T) {
+		tmp := setup(t)
+
+		var wfs *[]Workflow
+		captureStdout(t, func() {
+			var err error
+			wfs, err = AuditRepository(FilePath(tmp), staticResolver{sha: "sha"})
+			if err != nil {
+				t.Fatalf("AuditRepository returned error: %v", err)
+			}
+		})
+
+		var reported []string
+		for _, wf := range *wfs {
+			for _, f := range wf.Issues {
+				reported = append(reported, filepath.Base(wf.FilePath)+" "+f.Original)
+			}
+		}
+		if want := []string{"ci.yml actions/checkout@v4"}; !reflect.DeepEqual(reported, want) {
+			t.Fatalf("reported = %v; want %v", reported, want)
+		}
+
+		rules, err := ConfiguredIgnores(FilePath(tmp))
+		if err != nil {
+			t.Fatalf("ConfiguredIgnores returned error: %v", err)
+		}
+		unused := UnusedIgnores(rules, *wfs)
+		if want := []IgnoreRule{{Source: ScharfIgnoreFile, Pattern: "unused/*"}}; !reflect.DeepEqual(unused, want) {
+			t.Fatalf("unused = %v; want %v", unused, want)
+		}
+	})
+
+	t.Run("ignored actions are never resolved", func(t *testing.T) {
+		tmp := setup(t)
+
+		// my-org/deploy@v1 is unknown to the resolver, so resolving it would warn
+		report, err := AuditRepositoryReport(FilePath(tmp), refResolver{refs: map[string]string{"actions/checkout@v4": "sha"}}, AuditOptions{})
+		if err != nil {
+			t.Fatalf("AuditRepositoryReport returned error: %v", err)
+		}
+		if len(report.Warnings) != 0 {
+			t.Fatalf("expected no warnings, got %v", report.Warnings)
+		}
+	})
+
+	t.Run("autofix leaves ignored references alone", func(t *testing.T) {
+		tmp := setup(t)
+
+		captureStdout(t, func() {
+			if _, err := AutoFixRepository(FilePath(tmp), staticResolver{sha: "sha"}, AutoFixOptions{}); err != nil {
+				t.Fatalf("AutoFixRepository returned error: %v", err)
+			}
+		})
+
+		ci, _ := os.ReadFile(filepath.Join(tmp, ".github", "workflows", "ci.yml"))
+		if want := "steps:\n  - uses: my-org/deploy@v1\n  - uses: actions/checkout@sha # v4\n"; string(ci) != want {
+			t.Fatalf("ci.yml = %q; want %q", ci, want)
+		}
+		legacy, _ := os.ReadFile(filepath.Join(tmp, ".github", "workflows", "legacy.yml"))
+		if !strings.Contains(string(legacy), "actions/setup-go@v5") {
+			t.Fatalf("expected legacy.yml to stay untouched, got: %s", legacy)
+		}
+	})
+}
+
+func TestParseIgnoreLine(t *testing.T) {
+	valid := map[string]lineIgnore{
+		".github/workflows/ci.yml:12":      {spec: ".github/workflows/ci.yml:12", file: ".github/workflows/ci.yml", start: 12, end: 12},
+		"./.github/workflows/ci.yml:12-14": {spec: "./.github/workflows/ci.yml:12-14", file: ".github/workflows/ci.yml", start: 12, end: 14},
+		"C:dir/ci.yml:3":                   {spec: "C:dir/ci.yml:3", file: "C:dir/ci.yml", start: 3, end: 3},
+	}
+	for spec, want := range valid {
+		got, err := parseIgnoreLine(spec)
+		if err != nil {
+			t.Errorf("parseIgnoreLine(%q) returned error: %v", spec, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseIgnoreLine(%q) = %+v; want %+v", spec, got, want)
+		}
+	}
+
+	for _, spec := range []string{"ci.yml", ":12", "ci.yml:", "ci.yml:0", "ci.yml:x", "ci.yml:14-12", "ci.yml:12-"} {
+		if _, err := parseIgnoreLine(spec); err == nil {
+			t.Errorf("parseIgnoreLine(%q) returned no error", spec)
+		}
+	}
+}
+
+func TestAuditIgnoreLine(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@v5\n  - uses: actions/cache@v4\n")
+
+	opts := AuditOptions{IgnoreLines: []string{".github/workflows/ci.yml:2", "other.yml:3"}}
+	report, err := AuditRepositoryReport(FilePath(tmp), staticResolver{sha: "sha"}, opts)
+	if err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+	if len(report.Workflows) != 1 {
+		t.Fatalf("expected one workflow, got %d", len(report.Workflows))
+	}
+
+	wf := report.Workflows[0]
+	var reported []string
+	for _, f := range wf.Issues {
+		reported = append(reported, f.Original)
+	}
+	if want := []string{"actions/setup-go@v5", "actions/cache@v4"}; !reflect.DeepEqual(reported, want) {
+		t.Fatalf("reported = %v; want %v", reported, want)
+	}
+
+	want := IgnoreRule{Source: IgnoreLineSource, Pattern: ".github/workflows/ci.yml:2"}
+	if len(wf.Ignored) != 1 || wf.Ignored[0].Original != "actions/checkout@v4" || wf.Ignored[0].IgnoredBy != want {
+		t.Fatalf("ignored = %+v; want actions/checkout@v4 ignored by %v", wf.Ignored, want)
+	}
+
+	// Options apply to their audit only
+	report, err = AuditRepositoryReport(FilePath(tmp), staticResolver{sha: "sha"}, AuditOptions{})
+	if err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+	if n := len(report.Workflows[0].Issues); n != 3 {
+		t.Fatalf("expected 3 findings without ignore lines, got %d", n)
+	}
+	if err := ValidateIgnoreLines([]string{"ci.yml:0"}); err == nil {
+		t.Fatal("ValidateIgnoreLines returned no error for ci.yml:0")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+var localUsesRegex = regexp.MustCompile(`uses:\s*["']?(\./[^\s"'#]*)`)
+
+// LocalReference is a same-repository action or reusable workflow reference,
+// Ex: uses: ./.github/actions/foo. These are immutable with the repository
+// itself, so they are never resolved against the GitHub API.
+type LocalReference struct {
+	FilePath string // workflow file containing the reference
+	Line     int    // 1-based line number
+	Path     string // referenced path, Ex: ./.github/actions/foo
+	Exists   bool   // whether the referenced path exists in the repository
+}
+
+// IsLocalReference detects same-repository references (Ex: ./.github/actions/foo)
+func IsLocalReference(ref string) bool {
+	return strings.HasPrefix(ref, "./")
+}
+
+// isInLocalReference reports whether the byte at start belongs to a token that is a
+// local reference. The action regex is unanchored, so a path like
+// ./vendor/actions/checkout@v4 would otherwise be mistaken for a remote action.
+func isInLocalReference(content []byte, start int) bool {
+	tokenStart := start
+	for tokenStart > 0 {
+		c := content[tokenStart-1]
+		if c == ' ' || c == '\t' || c == '\n' || c == '"' || c == '\'' {
+			break
+		}
+		tokenStart--
+	}
+
+	return IsLocalReference(string(content[tokenStart:start]))
+}
+
+// CheckLocalReferences lists the same-repository references of every workflow
+// in the repository and whether the referenced path exists.
+func CheckLocalReferences(path FilePath) ([]LocalReference, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	files, err := listWorkflowFiles(abs)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	var refs []LocalReference
+	for _, f := range files {
+		content, err := ReadFile(FilePath(f))
+		if err != nil {
+			if errors.Is(err, syscall.EISDIR) {
+				continue
+			}
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+
+		for i, line := range strings.Split(string(content), "\n") {
+			m := localUsesRegex.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+
+			// Local paths are relative to the repository root, not the workflow file
+			_, statErr := os.Stat(filepath.Join(abs, filepath.FromSlash(m[1])))
+			refs = append(refs, LocalReference{
+				FilePath: f,
+				Line:     i + 1,
+				Path:     m[1],
+				Exists:   statErr == nil,
+			})
+		}
+	}
+
+	return refs, nil
+}
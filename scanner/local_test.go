@@ -0,0 +1,69 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var localRefsWorkflow = strings.Join([]string{
+	"jobs:",
+	"  build:",
+	"    steps:",
+	"      - uses: ./.github/actions/setup",
+	"      - uses: './.github/actions/missing'",
+	"      - uses: ./vendor/actions/checkout@v4",
+	"      - uses: actions/checkout@v4",
+	"  reuse:",
+	"    uses: ./.github/workflows/reusable.yml",
+}, "\n")
+
+func TestAssembleWorkflowSkipsLocalReferences(t *testing.T) {
+	wf, err := AssembleWorkflow(staticResolver{sha: "sha"}, []byte(localRefsWorkflow), "ci.yml", "ci.yml")
+	CheckIfError(err)
+
+	if len(wf.Issues) != 1 || wf.Issues[0].Original != "actions/checkout@v4" {
+		t.Fatalf("expected only the remote action to be flagged, got: %+v", wf.Issues)
+	}
+}
+
+func TestCheckLocalReferences(t *testing.T) {
+	tmp := t.TempDir()
+	writeWorkflow(t, tmp, localRefsWorkflow)
+	writeFileAt(t, filepath.Join(tmp, ".github", "actions", "setup", "action.yml"), "runs:\n  using: composite\n")
+	writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "reusable.yml"), "on: workflow_call\n")
+
+	refs, err := CheckLocalReferences(FilePath(tmp))
+	CheckIfError(err)
+
+	exists := map[string]bool{}
+	for _, ref := range refs {
+		exists[ref.Path] = ref.Exists
+	}
+
+	expected := map[string]bool{
+		"./.github/actions/setup":          true,
+		"./.github/actions/missing":        false,
+		"./vendor/actions/checkout@v4":     false,
+		"./.github/workflows/reusable.yml": true,
+	}
+	for path, want := range expected {
+		got, ok := exists[path]
+		if !ok {
+			t.Errorf("expected local reference %q to be listed", path)
+			continue
+		}
+		if got != want {
+			t.Errorf("local reference %q exists = %v; want %v", path, got, want)
+		}
+	}
+	if len(refs) != len(expected) {
+		t.Errorf("got %d local references, want %d: %+v", len(refs), len(expected), refs)
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// pinComment splits what follows a pin into a single-word version hint and a
+// note, Ex: " # v4 - keep in sync" into v4 and "keep in sync". ok is false when
+// more than a comment follows, Ex: the closing quote of a quoted uses: value.
+func pinComment(rest string) (word string, note string, ok bool) {
+	if strings.TrimSpace(rest) == "" {
+		return "", "", true
+	}
+	m := trailingCommentRegex.FindStringSubmatch(rest)
+	if m == nil {
+		return "", "", false
+	}
+
+	comment := m[1]
+	if v, n, found := strings.Cut(comment, " - "); found && !strings.ContainsAny(v, " \t") {
+		return v, n, true
+	}
+	if !strings.ContainsAny(comment, " \t") {
+		return comment, "", true
+	}
+	return "", comment, true
+}
+
+// tagFinder is implemented by resolvers that can list the tags pointing at a
+// commit, newest version first, Ex: network.SHAResolver
+type tagFinder interface {
+	TagsAt(action string, sha string) ([]string, error)
+}
+
+// pinNormalizer picks the version comment of already pinned references
+type pinNormalizer struct {
+	res   network.Resolver
+	style CommentStyle
+	tags  map[string][]network.BranchOrTag // tags of each action, listed once
+
+	// annotate only adds the missing comment of bare pins, Ex: for scharf annotate
+	annotate bool
+
+	// w receives the rewrites and warnings of each file, Ex: os.Stdout
+	w io.Writer
+}
+
+func newPinNormalizer(res network.Resolver, style CommentStyle, w io.Writer) *pinNormalizer {
+	return &pinNormalizer{res: res, style: style, tags: map[string][]network.BranchOrTag{}, w: w}
+}
+
+// versionTagAt returns the most specific version tag of action pointing at sha
+func (n *pinNormalizer) versionTagAt(action string, sha string) (string, bool) {
+	finder, ok := n.res.(tagFinder)
+	if !ok {
+		return mostSpecificSemverTag(n.tagsOf(action), sha)
+	}
+
+	// Reverse lookup also matches annotated tags by the commit they point to
+	tags, err := finder.TagsAt(action, sha)
+	if err != nil {
+		logger.Warn("could not list tags", "action", action, "err", err)
+	}
+	for _, tag := range tags {
+		if semverTagRegex.MatchString(tag) {
+			return tag, true
+		}
+	}
+	return "", false
+}
+
+// tagsOf lists the tags of action, or none when the resolver can't list tags
+func (n *pinNormalizer) tagsOf(action string) []network.BranchOrTag {
+	tags, seen := n.tags[action]
+	if seen {
+		return tags
+	}
+
+	if lister, ok := n.res.(tagLister); ok {
+		var err error
+		if tags, err = lister.ListTags(action); err != nil {
+			logger.Warn("could not list tags", "action", action, "err", err)
+		}
+	}
+	n.tags[action] = tags
+	return tags
+}
+
+// version returns the version the comment of a pin of action at sha should name,
+// given the single-word comment it has, if any. A comment naming a ref is
+// confirmed by resolving it again; one naming no ref, Ex: a date, is replaced by
+// the version tag of the SHA. When the pin must be left alone, the reason is
+// returned instead.
+func (n *pinNormalizer) version(action string, sha string, word string) (string, string) {
+	confirmed := false
+	if word != "" {
+		resolved, err := n.res.Resolve(action + "@" + word)
+		switch {
+		case errors.Is(err, network.ErrRateLimited):
+			return "", fmt.Sprintf("could not confirm '%s@%s': %s", action, word, err.Error())
+		case err == nil && resolved != sha:
+			// Re-pinning would hide a moved tag; verify reports it for review
+			return "", fmt.Sprintf("'%s@%s' now points to %s, not to the pinned SHA. Run 'scharf verify' to review it", action, word, resolved)
+		case err == nil:
+			confirmed = true
+			if n.style != CommentStyleSemver {
+				return word, ""
+			}
+		}
+	}
+
+	if v, ok := n.versionTagAt(action, sha); ok {
+		return v, ""
+	}
+	if confirmed {
+		return word, ""
+	}
+	return "", fmt.Sprintf("no version tag points to %s", sha)
+}
+
+// normalizeContent rewrites the pinned references of content to the house style,
+// owner/repo@<sha> # <version>, keeping any note after the version. skip leaves
+// the pins of an action alone. It returns the content and the number of pins
+// rewritten.
+func normalizeContent(content []byte, name string, n *pinNormalizer, skip func(action string) bool) ([]byte, int) {
+	matches, err := workflowMatches(content, pinnedSHARegex)
+	if err != nil {
+		return content, 0
+	}
+
+	var pins []Finding
+	for _, m := range matches {
+		if isInLocalReference(content, m.StartOffset) {
+			continue
+		}
+		action, sha, _ := strings.Cut(m.Text, "@")
+		if skip(action) {
+			continue
+		}
+		pins = append(pins, Finding{
+			Line:        m.Line,
+			Column:      m.Col,
+			StartOffset: m.StartOffset,
+			EndOffset:   m.EndOffset,
+			Action:      action,
+			FixSHA:      sha,
+			Original:    m.Text,
+		})
+	}
+	if len(pins) == 0 {
+		return content, 0
+	}
+
+	header, verb := "🧹 Normalizing", "Normalized"
+	if n.annotate {
+		header, verb = "🏷️ Annotating", "Annotated"
+	}
+	// Files whose pins are already normalized get no header
+	announced := false
+	announce := func() {
+		if !announced {
+			fmt.Fprintf(n.w, "%s %s%s%s: \n", header, Cyan, name, Reset)
+			announced = true
+		}
+	}
+
+	return rewriteContent(content, pins, name, n.w, func(issue Finding, rest string, loc string) (string, bool) {
+		word, note, ok := pinComment(rest)
+		if !ok || (n.annotate && strings.TrimSpace(rest) != "") {
+			return "", false
+		}
+		version, reason := n.version(issue.Action, issue.FixSHA, word)
+		if reason != "" {
+			announce()
+			fmt.Fprintf(n.w, "  - [%s%s%s] %s Warning: Left '%s' as it is: %s%s ⚠️\n", Gray, loc, Reset, Yellow, issue.Original, reason, Reset)
+			return "", false
+		}
+
+		pin := formatPin(issue.Action, issue.FixSHA, version)
+		if note != "" {
+			pin = fmt.Sprintf("%s - %s", pin, note)
+		}
+		if pin == issue.Original+rest {
+			return "", false
+		}
+		announce()
+		fmt.Fprintf(n.w, "  - [%s%s%s] %s %s: '%s%s' to '%s' %s\n", Gray, loc, Reset, Green, verb, issue.Original, rest, pin, Reset)
+		return pin, true
+	})
+}
+
+// normalizeRepository normalizes the pins of every workflow and action file of
+// the repository root abs with n, leaving ignored and trusted actions alone. The
+// content of a file is taken from contents when present, Ex: with fixes not
+// written yet, else read from disk. It returns the normalized content of the
+// files that changed and the number of pins rewritten.
+func normalizeRepository(abs string, n *pinNormalizer, contents map[string][]byte) (map[string][]byte, int, error) {
+	settings, err := loadAuditRules(abs, AuditOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	workflows, actions, err := listAuditFiles(abs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("file error: %w", err)
+	}
+
+	skip := func(action string) bool {
+		_, ignored := ignoredActionBy(settings.ignores, action)
+		return ignored || isTrustedOwner(settings.trusted, action)
+	}
+
+	changed := map[string][]byte{}
+	total := 0
+	for _, f := range slices.Concat(workflows, actions) {
+		if rel, err := filepath.Rel(abs, f); err == nil {
+			if _, ok := ignoredPathBy(settings.ignores, filepath.ToSlash(rel)); ok {
+				continue
+			}
+		}
+
+		content, ok := contents[f]
+		if !ok {
+			content, err = os.ReadFile(f)
+			if err != nil {
+				if errors.Is(err, syscall.EISDIR) {
+					continue
+				}
+				return nil, total, fmt.Errorf("file error: %w", err)
+			}
+		}
+
+		normalized, count := normalizeContent(content, f, n, skip)
+		if count > 0 {
+			changed[f] = normalized
+			total += count
+		}
+	}
+
+	return changed, total, nil
+}
+
+// normalizeInPlace normalizes the pins of the repository at path with n and
+// writes the files, along with pending ones, Ex: fixed by autofix, with
+// writeFixedFiles. It returns the number of pins rewritten.
+func normalizeInPlace(path FilePath, n *pinNormalizer, pending map[string][]byte, verify bool, dryRun bool) (int, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return 0, fmt.Errorf("os: %w", err)
+	}
+
+	changed, count, err := normalizeRepository(abs, n, pending)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 && n.annotate {
+		fmt.Println("No pins to annotate")
+	} else if count == 0 {
+		fmt.Println("No pins to normalize")
+	}
+
+	files := maps.Clone(pending)
+	if files == nil {
+		files = map[string][]byte{}
+	}
+	maps.Copy(files, changed)
+	if err := writeFixedFiles(files, verify, dryRun); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// writeFixedFiles writes the fixed content of each file unless it is a dry run.
+// With verify, every file is re-parsed as YAML first, and none is written if the
+// fixes would break one.
+func writeFixedFiles(files map[string][]byte, verify bool, dryRun bool) error {
+	paths := slices.Sorted(maps.Keys(files))
+	if verify {
+		for _, f := range paths {
+			before, err := os.ReadFile(f)
+			if err != nil {
+				return fmt.Errorf("file error: %w", err)
+			}
+			if err := verifyFix(FileFix{Path: f, Before: before, After: files[f]}); err != nil {
+				return err
+			}
+		}
+	}
+	if dryRun {
+		return nil
+	}
+
+	for _, f := range paths {
+		if err := writeFileAtomic(f, files[f]); err != nil {
+			return fmt.Errorf("file error: writing %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+// AnnotateRepository adds the missing version comment of bare SHA pins in the
+// repository at path, Ex: actions/checkout@<sha> to actions/checkout@<sha> # v4.2.2,
+// naming the most specific version tag pointing at the SHA. Pins with a comment
+// are left alone. It returns the number of pins annotated, or that would be
+// with dryRun. With verify, no file is written if the comments would break one.
+func AnnotateRepository(path FilePath, res network.Resolver, dryRun bool, verify bool) (int, error) {
+	n := newPinNormalizer(res, CommentStyleTag, os.Stdout)
+	n.annotate = true
+
+	annotated, err := normalizeInPlace(path, n, nil, verify, dryRun)
+	if err != nil {
+		return annotated, err
+	}
+	if dryRun && annotated > 0 {
+		fmt.Println("The displayed annotations are not written. Re-run 'scharf annotate' and omit the flag '--dry-run' to write them.")
+	}
+	return annotated, nil
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// refResolver resolves the refs it knows and lists per-action tags
+type refResolver struct {
+	refs map[string]string
+	tags map[string][]network.BranchOrTag
+}
+
+func (r refResolver) Resolve(ref string) (string, error) {
+	if sha, ok := r.refs[ref]; ok {
+		return sha, nil
+	}
+	return "", errors.New("ref not found")
+}
+
+func (r refResolver) ListTags(action string) ([]network.BranchOrTag, error) {
+	return r.tags[action], nil
+}
+
+// mixedWorkflow pins its actions in every style found in the wild
+const mixedWorkflow = `steps:
+  - uses: actions/checkout@` + shaA + ` # v4
+  - uses: actions/cache@` + shaA + `   #v4
+  - uses: actions/setup-go@` + shaA + ` # 2024-01-15
+  - uses: actions/setup-node@` + shaA + `
+  - uses: actions/upload-artifact@` + shaA + ` # v4 - keep in sync
+  - uses: actions/download-artifact@` + shaA + ` # v4
+  - uses: actions/labeler@v5
+`
+
+const normalizedWorkflow = `steps:
+  - uses: actions/checkout@` + shaA + ` # v4
+  - uses: actions/cache@` + shaA + ` # v4
+  - uses: actions/setup-go@` + shaA + ` # v5.0.1
+  - uses: actions/setup-node@` + shaA + ` # v4.0.0
+  - uses: actions/upload-artifact@` + shaA + ` # v4 - keep in sync
+  - uses: actions/download-artifact@` + shaA + ` # v4
+  - uses: actions/labeler@` + shaA + ` # v5
+`
+
+var mixedResolver = refResolver{
+	refs: map[string]string{
+		"actions/checkout@v4":        shaA,
+		"actions/cache@v4":           shaA,
+		"actions/upload-artifact@v4": shaA,
+		// The tag moved after the pin was written
+		"actions/download-artifact@v4": shaB,
+		"actions/labeler@v5":           shaA,
+	},
+	tags: map[string][]network.BranchOrTag{
+		"actions/setup-go":   {tagAt("v5", shaB), tagAt("v5.0.1", shaA)},
+		"actions/setup-node": {tagAt("v4.0.0", shaA)},
+	},
+}
+
+func TestPinComment(t *testing.T) {
+	cases := []struct {
+		rest       string
+		word, note string
+		ok         bool
+	}{
+		{"", "", "", true},
+		{" # v4", "v4", "", true},
+		{"   #v4  ", "v4", "", true},
+		{" # v4 - keep in sync", "v4", "keep in sync", true},
+		{" # keep in sync", "", "keep in sync", true},
+		{"' # v4", "", "", false},
+	}
+
+	for _, tc := range cases {
+		word, note, ok := pinComment(tc.rest)
+		if word != tc.word || note != tc.note || ok != tc.ok {
+			t.Errorf("pinComment(%q) = %q, %q, %v; want %q, %q, %v", tc.rest, word, note, ok, tc.word, tc.note, tc.ok)
+		}
+	}
+}
+
+func TestAutoFixRepositoryNormalize(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	workflowFile := writeWorkflow(t, tmp, mixedWorkflow)
+
+	var applied int
+	out := captureStdout(t, func() {
+		var err error
+		applied, err = AutoFixRepository(FilePath(tmp), mixedResolver, AutoFixOptions{Normalize: true})
+		if err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+
+	updated, _ := os.ReadFile(workflowFile)
+	if string(updated) != normalizedWorkflow {
+		t.Fatalf("got:\n%s\nwant:\n%s", updated, normalizedWorkflow)
+	}
+	// One fix plus the cache, setup-go and setup-node pins
+	if applied != 4 {
+		t.Errorf("applied = %d; want 4", applied)
+	}
+	if !strings.Contains(out, "scharf verify") {
+		t.Errorf("expected a warning about the moved tag, got:\n%s", out)
+	}
+
+	// A normalized repository has nothing left to do
+	out = captureStdout(t, func() {
+		var err error
+		applied, err = AutoFixRepository(FilePath(tmp), mixedResolver, AutoFixOptions{Normalize: true})
+		if err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+	if applied != 0 {
+		t.Errorf("second run applied = %d; want 0", applied)
+	}
+	if !strings.Contains(out, "No actions to fix") {
+		t.Errorf("expected 'No actions to fix' on the second run, got:\n%s", out)
+	}
+}
+
+func TestAutoFixRepositoryNormalizeOnlyPinnedReferences(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	workflowFile := writeWorkflow(t, tmp, "steps:\n  - uses: actions/cache@"+shaA+"   #v4\n")
+
+	var applied int
+	out := captureStdout(t, func() {
+		var err error
+		applied, err = AutoFixRepository(FilePath(tmp), mixedResolver, AutoFixOptions{Normalize: true})
+		if err != nil {
+			t.Fatalf("AutoFixRepository returned error: %v", err)
+		}
+	})
+
+	updated, _ := os.ReadFile(workflowFile)
+	if want := "steps:\n  - uses: actions/cache@" + shaA + " # v4\n"; string(updated) != want {
+		t.Fatalf("got %q; want %q", updated, want)
+	}
+	if applied != 1 {
+		t.Errorf("applied = %d; want 1", applied)
+	}
+	if strings.Contains(out, "No actions to fix") {
+		t.Errorf("did not expect 'No actions to fix' when a pin is rewritten, got:\n%s", out)
+	}
+}
+
+func TestAutoFixRepositoryVerifiesEveryFileBeforeWriting(t *testing.T) {
+	for _, normalize := range []bool{false, true} {
+		tmp := t.TempDir()
+		initGitRepo(t, tmp)
+		good := filepath.Join(tmp, ".github", "workflows", "a.yml")
+		bad := filepath.Join(tmp, ".github", "workflows", "b.yml")
+		writeFileAt(t, good, "steps:\n  - uses: actions/cache@v4\n")
+		writeFileAt(t, bad, "steps:\n  - uses: actions/checkout@v4\n")
+
+		// The fix of b.yml breaks its YAML; a.yml, fixed first, must not be written either
+		res := refResolver{refs: map[string]string{"actions/cache@v4": shaA, "actions/checkout@v4": "deadbeef: ["}}
+		var err error
+		captureStdout(t, func() {
+			_, err = AutoFixRepository(FilePath(tmp), res, AutoFixOptions{VerifyAfterFix: true, Normalize: normalize, CommentStyle: CommentStyleTag})
+		})
+		if err == nil || !strings.Contains(err.Error(), "b.yml would leave invalid YAML") {
+			t.Fatalf("normalize=%v: expected an invalid YAML error for b.yml, got %v", normalize, err)
+		}
+		if got, _ := os.ReadFile(good); string(got) != "steps:\n  - uses: actions/cache@v4\n" {
+			t.Fatalf("normalize=%v: a.yml = %q; want it unchanged", normalize, got)
+		}
+	}
+}
+
+func TestAutoFixDiffNormalize(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	workflowFile := writeWorkflow(t, tmp, mixedWorkflow)
+
+	var diff string
+	captureStdout(t, func() {
+		var err error
+		diff, _, err = AutoFixDiff(FilePath(tmp), mixedResolver, AutoFixOptions{Normalize: true}, os.Stdout, false)
+		if err != nil {
+			t.Fatalf("AutoFixDiff returned error: %v", err)
+		}
+	})
+
+	// Fixes and normalization of the same file share its diff
+	if n := strings.Count(diff, "--- a/.github/workflows/ci.yml"); n != 1 {
+		t.Fatalf("expected one diff of ci.yml, got %d:\n%s", n, diff)
+	}
+	for _, want := range []string{
+		"+  - uses: actions/labeler@" + shaA + " # v5",
+		"+  - uses: actions/setup-go@" + shaA + " # v5.0.1",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff lacks %q:\n%s", want, diff)
+		}
+	}
+
+	if content, _ := os.ReadFile(workflowFile); string(content) != mixedWorkflow {
+		t.Fatal("AutoFixDiff must not write the workflow")
+	}
+}
+
+// finderResolver finds tags by reverse lookup, like network.SHAResolver
+type finderResolver struct {
+	refResolver
+	tagsAt map[string][]string
+}
+
+func (r finderResolver) TagsAt(action string, sha string) ([]string, error) {
+	return r.tagsAt[action+"@"+sha], nil
+}
+
+const bareWorkflow = `steps:
+  - uses: actions/checkout@` + shaA + `
+  - uses: actions/setup-go@` + shaB + ` # v5
+  - uses: actions/cache@` + shaC + `
+  - uses: actions/upload-artifact@` + shaA + ` # keep in sync
+`
+
+func TestAnnotateRepository(t *testing.T) {
+	res := finderResolver{tagsAt: map[string][]string{
+		// Newest first, as network.SHAResolver.TagsAt lists them
+		"actions/checkout@" + shaA:        {"v4.2.2", "v4", "latest"},
+		"actions/upload-artifact@" + shaA: {"v4.1.0"},
+	}}
+	want := `steps:
+  - uses: actions/checkout@` + shaA + ` # v4.2.2
+  - uses: actions/setup-go@` + shaB + ` # v5
+  - uses: actions/cache@` + shaC + `
+  - uses: actions/upload-artifact@` + shaA + ` # keep in sync
+`
+
+	tmp := t.TempDir()
+	workflowFile := writeWorkflow(t, tmp, bareWorkflow)
+
+	var annotated int
+	out := captureStdout(t, func() {
+		var err error
+		annotated, err = AnnotateRepository(FilePath(tmp), res, true, false)
+		if err != nil {
+			t.Fatalf("AnnotateRepository returned error: %v", err)
+		}
+	})
+	if content, _ := os.ReadFile(workflowFile); string(content) != bareWorkflow {
+		t.Fatal("a dry run must not write the workflow")
+	}
+	if annotated != 1 || !strings.Contains(out, "Annotated:") {
+		t.Fatalf("dry run annotated %d pins; want 1:\n%s", annotated, out)
+	}
+	// No tag points to the cache SHA, so it stays bare
+	if !strings.Contains(out, "no version tag points to "+shaC) {
+		t.Errorf("expected a warning about the untagged SHA:\n%s", out)
+	}
+
+	captureStdout(t, func() {
+		if _, err := AnnotateRepository(FilePath(tmp), res, false, false); err != nil {
+			t.Fatalf("AnnotateRepository returned error: %v", err)
+		}
+	})
+	if content, _ := os.ReadFile(workflowFile); string(content) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", content, want)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// orderedOutput is set from the --concurrency-safe-output flag
+var orderedOutput bool
+
+// SetOrderedOutput makes concurrent scans buffer the output of each repository
+// and print it in repository order, instead of as it is produced
+func SetOrderedOutput(ordered bool) {
+	orderedOutput = ordered
+}
+
+// outputCoordinator flushes the output of concurrent tasks one task at a time,
+// in task order, so lines of different tasks never interleave. The output of a
+// task waits until every earlier task is flushed.
+type outputCoordinator struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int]func()
+}
+
+func newOutputCoordinator() *outputCoordinator {
+	return &outputCoordinator{pending: map[int]func(){}}
+}
+
+// done hands over the flush of task index, the 0-based position of the task.
+// It runs along with those of later tasks that were waiting on it.
+func (o *outputCoordinator) done(index int, flush func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.pending[index] = flush
+	for {
+		f, ok := o.pending[o.next]
+		if !ok {
+			return
+		}
+		delete(o.pending, o.next)
+		f()
+		o.next++
+	}
+}
+
+// bufferedRecord is a log record with the handler it is replayed to
+type bufferedRecord struct {
+	h slog.Handler
+	r slog.Record
+}
+
+// recordBuffer is a slog.Handler keeping the records of one task, so they are
+// replayed in the configured format (and redacted) when the task is flushed
+type recordBuffer struct {
+	h       slog.Handler
+	records *[]bufferedRecord
+}
+
+func newRecordBuffer(h slog.Handler) *recordBuffer {
+	return &recordBuffer{h: h, records: &[]bufferedRecord{}}
+}
+
+func (b *recordBuffer) Enabled(ctx context.Context, l slog.Level) bool {
+	return b.h.Enabled(ctx, l)
+}
+
+func (b *recordBuffer) Handle(_ context.Context, r slog.Record) error {
+	*b.records = append(*b.records, bufferedRecord{h: b.h, r: r.Clone()})
+	return nil
+}
+
+func (b *recordBuffer) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &recordBuffer{h: b.h.WithAttrs(attrs), records: b.records}
+}
+
+func (b *recordBuffer) WithGroup(name string) slog.Handler {
+	return &recordBuffer{h: b.h.WithGroup(name), records: b.records}
+}
+
+// replay hands the kept records to their handlers, in the order they were logged
+func (b *recordBuffer) replay() {
+	for _, br := range *b.records {
+		_ = br.h.Handle(context.Background(), br.r)
+	}
+	*b.records = nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// assertGroupedInOrder fails unless the lines of out naming a task, captured
+// by taskRegex, form one block per task, in task order
+func assertGroupedInOrder(t *testing.T, out string, taskRegex *regexp.Regexp) {
+	t.Helper()
+
+	var order []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		m := taskRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if len(order) == 0 || order[len(order)-1] != m[1] {
+			order = append(order, m[1])
+		}
+	}
+
+	for i := 1; i < len(order); i++ {
+		if order[i] <= order[i-1] {
+			t.Fatalf("output of %s is interleaved or out of order: %v\n%s", order[i], order, out)
+		}
+	}
+}
+
+func TestOutputCoordinator(t *testing.T) {
+	var out bytes.Buffer
+	h := slog.NewTextHandler(&out, nil)
+	coordinator := newOutputCoordinator()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := newRecordBuffer(h)
+			log := slog.New(buf).With("task", fmt.Sprintf("%02d", i))
+			for line := range 5 {
+				log.Info("working", "line", line)
+				// Later tasks finish first, the worst case for ordering
+				time.Sleep(time.Duration(8-i) * time.Millisecond)
+			}
+			coordinator.done(i, buf.replay)
+		}()
+	}
+	wg.Wait()
+
+	if n := strings.Count(out.String(), "\n"); n != 8*5 {
+		t.Fatalf("expected 40 lines, got %d:\n%s", n, out.String())
+	}
+	assertGroupedInOrder(t, out.String(), regexp.MustCompile(`task=(\d+)`))
+}
+
+func TestOutputCoordinatorWaitsForEarlierTasks(t *testing.T) {
+	var flushed []int
+	coordinator := newOutputCoordinator()
+	flush := func(i int) func() { return func() { flushed = append(flushed, i) } }
+
+	coordinator.done(2, flush(2))
+	coordinator.done(1, flush(1))
+	if len(flushed) != 0 {
+		t.Fatalf("flushed %v before task 0 was done", flushed)
+	}
+	coordinator.done(0, flush(0))
+	if fmt.Sprint(flushed) != "[0 1 2]" {
+		t.Fatalf("flushed %v; want [0 1 2]", flushed)
+	}
+}
+
+func TestScanReposOrderedOutput(t *testing.T) {
+	repos := workspaceWithWorkflows(t, 6)
+
+	var out bytes.Buffer
+	origLogger := logger
+	logger = slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	SetOrderedOutput(true)
+	t.Cleanup(func() {
+		logger = origLogger
+		SetOrderedOutput(false)
+	})
+
+	if _, err := ScanRepos(repos, findRegex, false, BranchFilter{}, 4); err != nil {
+		t.Fatalf("ScanRepos returned error: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "repo=repo-05") {
+		t.Fatalf("expected the logs of every repository:\n%s", out.String())
+	}
+	// Every line names its repository in its path
+	assertGroupedInOrder(t, out.String(), regexp.MustCompile(`/(repo-\d+)/`))
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// diffContext is the number of unchanged lines around a change, as in diff -u
+const diffContext = 3
+
+// diffLines splits content into lines and reports whether the last line lacks a
+// trailing newline
+func diffLines(content []byte) ([]string, bool) {
+	if len(content) == 0 {
+		return nil, false
+	}
+	lines := strings.Split(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1], false
+	}
+	return lines, true
+}
+
+// hunkRange formats one side of a hunk header. An empty side starts at the
+// line before it, as in diff -u.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// UnifiedDiff returns the unified diff (diff -u) of a file, labelled a/<name>
+// and b/<name> like git so the output applies with git apply. It is empty when
+// before and after are equal. Pinning rewrites lines in place, so lines are
+// compared pairwise; a change of the line count shows as a rewrite of the
+// whole file. color adds ANSI colors for terminals.
+func UnifiedDiff(name string, before, after []byte, color bool) string {
+	if string(before) == string(after) {
+		return ""
+	}
+
+	paint := func(c, s string) string {
+		if !color {
+			return s
+		}
+		return c + s + Reset
+	}
+
+	oldLines, oldNoEOL := diffLines(before)
+	newLines, newNoEOL := diffLines(after)
+
+	var b strings.Builder
+	b.WriteString(paint(White, fmt.Sprintf("--- a/%s", name)) + "\n")
+	b.WriteString(paint(White, fmt.Sprintf("+++ b/%s", name)) + "\n")
+
+	line := func(c, prefix, s string, last, noEOL bool) {
+		b.WriteString(paint(c, prefix+s) + "\n")
+		if last && noEOL {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+
+	if len(oldLines) != len(newLines) || oldNoEOL != newNoEOL {
+		b.WriteString(paint(Cyan, fmt.Sprintf("@@ -%s +%s @@", hunkRange(0, len(oldLines)), hunkRange(0, len(newLines)))) + "\n")
+		for i, l := range oldLines {
+			line(Red, "-", l, i == len(oldLines)-1, oldNoEOL)
+		}
+		for i, l := range newLines {
+			line(Green, "+", l, i == len(newLines)-1, newNoEOL)
+		}
+		return b.String()
+	}
+
+	var changed []int
+	for i := range oldLines {
+		if oldLines[i] != newLines[i] {
+			changed = append(changed, i)
+		}
+	}
+
+	// Changes whose context overlaps or touches share a hunk
+	for i := 0; i < len(changed); {
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*diffContext {
+			j++
+		}
+		start := max(changed[i]-diffContext, 0)
+		end := min(changed[j]+diffContext+1, len(oldLines))
+
+		b.WriteString(paint(Cyan, fmt.Sprintf("@@ -%s +%s @@", hunkRange(start, end-start), hunkRange(start, end-start))) + "\n")
+		for k := start; k < end; {
+			if oldLines[k] == newLines[k] {
+				line("", " ", oldLines[k], k == len(oldLines)-1, oldNoEOL)
+				k++
+				continue
+			}
+			// A run of changed lines lists its removals before its additions
+			run := k
+			for run < end && oldLines[run] != newLines[run] {
+				run++
+			}
+			for r := k; r < run; r++ {
+				line(Red, "-", oldLines[r], r == len(oldLines)-1, oldNoEOL)
+			}
+			for r := k; r < run; r++ {
+				line(Green, "+", newLines[r], r == len(newLines)-1, newNoEOL)
+			}
+			k = run
+		}
+		i = j + 1
+	}
+
+	return b.String()
+}
+
+// AutoFixDiff computes the fixes of AutoFixRepository without writing them and
+// returns them as a unified diff of every changed workflow, with paths relative
+// to path so it can be reviewed or piped into git apply. It also returns the
+// number of fixes in the diff. The audit summary and the fixes of each file
+// are written to w, so the diff can go to stdout alone.
+func AutoFixDiff(path FilePath, res network.Resolver, opts AutoFixOptions, w io.Writer, color bool) (string, int, error) {
+	report, err := AuditRepositoryReport(path, res, AuditOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	fmt.Fprint(w, report.Summary())
+	wfs := &report.Workflows
+	if opts.CommentStyle == CommentStyleSemver {
+		resolveSemverComments(*wfs, res)
+	}
+
+	root, err := filepath.Abs(string(path))
+	if err != nil {
+		return "", 0, fmt.Errorf("os: %w", err)
+	}
+
+	befores := map[string][]byte{}
+	afters := map[string][]byte{}
+	var files []string
+	applied := 0
+	for _, wf := range *wfs {
+		if len(wf.Issues) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "🪄 Fixing %s%s%s: \n", Cyan, wf.FilePath, Reset)
+		fix, err := fixInMemory(wf, opts, w)
+		if err != nil {
+			return "", applied, fmt.Errorf("file error: %w", err)
+		}
+		applied += fix.Applied
+		befores[wf.FilePath], afters[wf.FilePath] = fix.Before, fix.After
+		files = append(files, wf.FilePath)
+	}
+
+	if opts.Normalize {
+		// Normalized on top of the fixes, so a file changed by both gets one diff
+		changed, n, err := normalizeRepository(root, newPinNormalizer(res, opts.CommentStyle, w), afters)
+		if err != nil {
+			return "", applied, err
+		}
+		for f, content := range changed {
+			if _, fixed := befores[f]; !fixed {
+				before, err := os.ReadFile(f)
+				if err != nil {
+					return "", applied, fmt.Errorf("file error: %w", err)
+				}
+				befores[f] = before
+				files = append(files, f)
+			}
+			afters[f] = content
+		}
+		applied += n
+	}
+	slices.Sort(files)
+
+	var b strings.Builder
+	for _, f := range files {
+		name := f
+		if rel, err := filepath.Rel(root, f); err == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+		b.WriteString(UnifiedDiff(name, befores[f], afters[f], color))
+	}
+
+	return b.String(), applied, nil
+}
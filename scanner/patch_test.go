@@ -0,0 +1,146 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// diffWorkflow has two nearby references sharing a hunk and one far enough
+// away to get a hunk of its own
+const diffWorkflow = `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+      - run: go build ./...
+      - run: go vet ./...
+      - run: go test ./...
+      - run: echo one
+      - run: echo two
+      - run: echo three
+      - run: echo four
+      - uses: actions/upload-artifact@v4 # keep in sync with release.yml
+        with:
+          name: dist
+`
+
+func TestAutoFixDiffMatchesGolden(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	file := writeWorkflow(t, tmp, diffWorkflow)
+
+	var patch string
+	var n int
+	var err error
+	var progress bytes.Buffer
+	stdout := captureStdout(t, func() {
+		patch, n, err = AutoFixDiff(FilePath(tmp), staticResolver{sha: strings.Repeat("a", 40)}, AutoFixOptions{}, &progress, false)
+	})
+	if err != nil {
+		t.Fatalf("AutoFixDiff returned error: %v", err)
+	}
+	if stdout != "" || !strings.Contains(progress.String(), "Fixing") {
+		t.Fatalf("progress went to stdout %q instead of its writer %q", stdout, progress.String())
+	}
+	if n != 3 {
+		t.Fatalf("AutoFixDiff fixes = %d; want 3", n)
+	}
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "autofix.diff"))
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if patch != string(golden) {
+		t.Fatalf("diff mismatch\n got:\n%s\nwant:\n%s", patch, golden)
+	}
+
+	got, _ := os.ReadFile(file)
+	if string(got) != diffWorkflow {
+		t.Fatalf("AutoFixDiff modified the workflow:\n%s", got)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := UnifiedDiff("ci.yml", []byte("a\n"), []byte("a\n"), false); got != "" {
+		t.Fatalf("UnifiedDiff of equal content = %q; want empty", got)
+	}
+
+	got := UnifiedDiff("ci.yml", []byte("a\nb"), []byte("a\nc"), false)
+	want := "--- a/ci.yml\n+++ b/ci.yml\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"
+	if got != want {
+		t.Fatalf("UnifiedDiff without trailing newline = %q; want %q", got, want)
+	}
+
+	colored := UnifiedDiff("ci.yml", []byte("b\n"), []byte("c\n"), true)
+	if !strings.Contains(colored, Red+"-b"+Reset) || !strings.Contains(colored, Green+"+c"+Reset) {
+		t.Fatalf("expected colored removed and added lines, got %q", colored)
+	}
+}
+
+func TestAutoFixDiffPatchAppliesLikeAutofix(t *testing.T) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+
+	release := "on: push\njobs:\n  release:\n    steps:\n      - uses: actions/setup-node@v4\n"
+	setup := func() string {
+		repo := t.TempDir()
+		initGitRepo(t, repo)
+		writeWorkflow(t, repo, diffWorkflow)
+		if err := os.WriteFile(filepath.Join(repo, ".github", "workflows", "release.yml"), []byte(release), 0o644); err != nil {
+			t.Fatalf("writing workflow: %v", err)
+		}
+		return repo
+	}
+	patched, inPlace := setup(), setup()
+	res := staticResolver{sha: strings.Repeat("a", 40)}
+
+	var patch string
+	captureStdout(t, func() {
+		patch, _, err = AutoFixDiff(FilePath(patched), res, AutoFixOptions{}, os.Stdout, false)
+	})
+	if err != nil {
+		t.Fatalf("AutoFixDiff returned error: %v", err)
+	}
+	patchFile := filepath.Join(t.TempDir(), "pins.patch")
+	if err := os.WriteFile(patchFile, []byte(patch), 0o644); err != nil {
+		t.Fatalf("writing patch: %v", err)
+	}
+	apply := exec.Command(gitBin, "apply", patchFile)
+	apply.Dir = patched
+	if out, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("git apply failed: %v\n%s\npatch:\n%s", err, out, patch)
+	}
+
+	captureStdout(t, func() {
+		_, err = AutoFixRepository(FilePath(inPlace), res, AutoFixOptions{})
+	})
+	if err != nil {
+		t.Fatalf("AutoFixRepository returned error: %v", err)
+	}
+
+	for _, name := range []string{"ci.yml", "release.yml"} {
+		got, _ := os.ReadFile(filepath.Join(patched, ".github", "workflows", name))
+		want, _ := os.ReadFile(filepath.Join(inPlace, ".github", "workflows", name))
+		if string(got) != string(want) {
+			t.Fatalf("%s after git apply:\n%s\nwant, as autofix wrote it:\n%s", name, got, want)
+		}
+		if string(got) == diffWorkflow || string(got) == release {
+			t.Fatalf("%s was not fixed", name)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/cybrota/scharf/config"
+)
+
+// PathRuleSet is a validated rule set applied to the findings of one workflow file
+type PathRuleSet struct {
+	Ignore      []string // action names or globs whose findings are dropped
+	MinSeverity Severity // findings below this are dropped. Empty keeps all
+}
+
+// ParsePathRules validates the path_rules config section
+func ParsePathRules(raw map[string]config.PathRules) (map[string]PathRuleSet, error) {
+	rules := make(map[string]PathRuleSet, len(raw))
+	for pattern, r := range raw {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid path rule pattern %q: %w", pattern, err)
+		}
+
+		for _, ignore := range r.Ignore {
+			if _, err := path.Match(ignore, ""); err != nil {
+				return nil, fmt.Errorf("invalid ignore pattern %q for path %q: %w", ignore, pattern, err)
+			}
+		}
+
+		rs := PathRuleSet{Ignore: r.Ignore}
+		if r.MinSeverity != "" {
+			sev, err := ParseSeverity(r.MinSeverity)
+			if err != nil {
+				return nil, fmt.Errorf("path rule for %q: %w", pattern, err)
+			}
+			rs.MinSeverity = sev
+		}
+		rules[pattern] = rs
+	}
+
+	return rules, nil
+}
+
+// RulesForPath returns the rule set of a workflow file given by its slash separated
+// path relative to the repository root. Only the most specific matching glob applies,
+// using the same precedence as severity overrides.
+func RulesForPath(relPath string, rules map[string]PathRuleSet) (PathRuleSet, bool) {
+	pattern, ok := mostSpecificMatch(rules, relPath, matchesPath)
+	if !ok {
+		return PathRuleSet{}, false
+	}
+
+	return rules[pattern], true
+}
+
+// IgnoredBy returns the ignore pattern of the rule set matching the finding's action
+func (r PathRuleSet) IgnoredBy(f Finding) (string, bool) {
+	return ignoredActionBy(r.Ignore, f.Action)
+}
+
+// Allows reports whether a finding survives the rule set
+func (r PathRuleSet) Allows(f Finding) bool {
+	if _, ignored := r.IgnoredBy(f); ignored {
+		return false
+	}
+
+	return r.MinSeverity == "" || f.Severity.AtLeast(r.MinSeverity)
+}
+
+// matchesPath reports whether a path glob matches a slash separated path
+func matchesPath(pattern string, p string) bool {
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
+
+// pathRuleSource names where the rules of a path glob are configured
+func pathRuleSource(glob string) string {
+	return fmt.Sprintf("%s path_rules[%q]", config.FileName, glob)
+}
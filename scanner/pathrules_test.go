@@ -0,0 +1,131 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cybrota/scharf/config"
+)
+
+func TestRulesForPath_OverlappingGlobs(t *testing.T) {
+	rules, err := ParsePathRules(map[string]config.PathRules{
+		"*/workflows/*":                     {MinSeverity: "low"},
+		"services/*/workflows/*":            {MinSeverity: "medium"},
+		"services/api/workflows/*":          {MinSeverity: "high"},
+		"services/api/workflows/deploy.yml": {Ignore: []string{"actions/*"}},
+	})
+	if err != nil {
+		t.Fatalf("ParsePathRules returned error: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want PathRuleSet
+		ok   bool
+	}{
+		{"ci/workflows/a.yml", PathRuleSet{MinSeverity: SeverityLow}, true},
+		{"services/web/workflows/a.yml", PathRuleSet{MinSeverity: SeverityMedium}, true},
+		{"services/api/workflows/a.yml", PathRuleSet{MinSeverity: SeverityHigh}, true},
+		// An exact path wins over every glob and does not inherit their rules
+		{"services/api/workflows/deploy.yml", PathRuleSet{Ignore: []string{"actions/*"}}, true},
+		{"workflows/a.yml", PathRuleSet{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := RulesForPath(tt.path, rules)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RulesForPath(%q) = %+v, %v; want %+v, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRulesForPath_EqualLengthTieIsStable(t *testing.T) {
+	rules, err := ParsePathRules(map[string]config.PathRules{
+		"ci/*/a.yml": {MinSeverity: "high"},
+		"ci/x/*.yml": {MinSeverity: "low"},
+	})
+	if err != nil {
+		t.Fatalf("ParsePathRules returned error: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		got, _ := RulesForPath("ci/x/a.yml", rules)
+		if got.MinSeverity != SeverityHigh {
+			t.Fatalf("expected the lexically smaller glob to win, got %+v", got)
+		}
+	}
+}
+
+func TestParsePathRules_Invalid(t *testing.T) {
+	if _, err := ParsePathRules(map[string]config.PathRules{"[": {}}); err == nil {
+		t.Error("expected error for invalid path glob")
+	}
+	if _, err := ParsePathRules(map[string]config.PathRules{"*": {MinSeverity: "critical"}}); err == nil {
+		t.Error("expected error for invalid min_severity")
+	}
+	if _, err := ParsePathRules(map[string]config.PathRules{"*": {Ignore: []string{"["}}}); err == nil {
+		t.Error("expected error for invalid ignore glob")
+	}
+}
+
+func TestAuditRepositoryAppliesPathRules(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "ci.yml"), "uses: actions/checkout@v4\nuses: actions/setup-go@main\n")
+	writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "deploy.yml"), "uses: actions/checkout@v4\nuses: actions/setup-go@main\n")
+	writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "lint.yml"), "uses: actions/checkout@v4\nuses: actions/setup-go@main\n")
+	writeFileAt(t, filepath.Join(tmp, config.FileName), `path_rules:
+  ".github/workflows/*":
+    min_severity: high
+  ".github/workflows/deploy.yml":
+    ignore: ["actions/setup-*"]
+`)
+
+	var wfs *[]Workflow
+	captureStdout(t, func() {
+		var err error
+		wfs, err = AuditRepository(FilePath(tmp), staticResolver{sha: "sha"})
+		if err != nil {
+			t.Fatalf("AuditRepository returned error: %v", err)
+		}
+	})
+
+	got := map[string][]string{}
+	for _, wf := range *wfs {
+		for _, f := range wf.Issues {
+			got[filepath.Base(wf.FilePath)] = append(got[filepath.Base(wf.FilePath)], f.Original)
+		}
+	}
+	want := map[string][]string{
+		"ci.yml":     {"actions/setup-go@main"},
+		"deploy.yml": {"actions/checkout@v4"},
+		"lint.yml":   {"actions/setup-go@main"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected findings: %v; want %v", got, want)
+	}
+}
+
+func TestPathRuleSetIgnoredBy(t *testing.T) {
+	rules := PathRuleSet{Ignore: []string{"actions/*", "my-org/deploy"}}
+	cases := []struct {
+		action string
+		want   string
+	}{
+		{"actions/cache/save", "actions/*"},
+		{"Actions/Checkout", "actions/*"},
+		{"My-Org/Deploy", "my-org/deploy"},
+		{"other/action", ""},
+	}
+	for _, tc := range cases {
+		if got, _ := rules.IgnoredBy(Finding{Action: tc.action}); got != tc.want {
+			t.Errorf("IgnoredBy(%q) = %q; want %q", tc.action, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"sync"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// resolveWorkers is the number of references an audit resolves in parallel.
+// --resolve-concurrency-per-host caps how many of them reach the same host.
+const resolveWorkers = 8
+
+// resolution is the answer of a resolver to one reference
+type resolution struct {
+	sha string
+	err error
+}
+
+// prefetchedResolver answers the references resolved up front by
+// prefetchResolutions, and asks its resolver for any other
+type prefetchedResolver struct {
+	res      network.Resolver
+	resolved map[string]resolution
+}
+
+func (p *prefetchedResolver) Resolve(action string) (string, error) {
+	if r, ok := p.resolved[action]; ok {
+		return r.sha, r.err
+	}
+
+	progress.resolving(action)
+	return p.res.Resolve(action)
+}
+
+func (p *prefetchedResolver) MovedTo(action string) (string, bool) {
+	if mr, ok := p.res.(movedResolver); ok {
+		return mr.MovedTo(action)
+	}
+	return "", false
+}
+
+func (p *prefetchedResolver) ResolvedVersion(action string) (string, bool) {
+	if cr, ok := p.res.(concreteVersionResolver); ok {
+		return cr.ResolvedVersion(action)
+	}
+	return "", false
+}
+
+// prefetchResolutions resolves the distinct refs with resolveWorkers lookups in
+// flight, so an audit waits on its slowest lookup rather than on all of them in
+// turn. Repeated references are resolved once.
+func prefetchResolutions(res network.Resolver, refs []string) *prefetchedResolver {
+	var unique []string
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if !seen[ref] {
+			seen[ref] = true
+			unique = append(unique, ref)
+		}
+	}
+	progress.expect(len(unique))
+
+	resolved := make(map[string]resolution, len(unique))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Each worker holds a slot of the semaphore while it resolves a reference
+	sem := make(chan struct{}, resolveWorkers)
+	for _, ref := range unique {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			progress.resolving(ref)
+			sha, err := res.Resolve(ref)
+			mu.Lock()
+			resolved[ref] = resolution{sha: sha, err: err}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return &prefetchedResolver{res: res, resolved: resolved}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// barrierResolver holds every lookup until want of them are in flight, so it
+// only answers when references are resolved in parallel
+type barrierResolver struct {
+	want    int
+	mu      sync.Mutex
+	calls   map[string]int
+	arrived chan struct{}
+	once    sync.Once
+}
+
+func (b *barrierResolver) Resolve(action string) (string, error) {
+	b.mu.Lock()
+	b.calls[action]++
+	inFlight := 0
+	for _, n := range b.calls {
+		inFlight += n
+	}
+	b.mu.Unlock()
+	if inFlight == b.want {
+		b.once.Do(func() { close(b.arrived) })
+	}
+
+	select {
+	case <-b.arrived:
+		return "sha", nil
+	case <-time.After(5 * time.Second):
+		return "", nil
+	}
+}
+
+func TestAuditResolvesReferencesInParallel(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, `jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/cache@v4
+      - uses: actions/setup-go@v5
+      - uses: actions/checkout@v4
+`)
+
+	res := &barrierResolver{want: 3, calls: map[string]int{}, arrived: make(chan struct{})}
+	report, err := AuditRepositoryReport(FilePath(tmp), res, AuditOptions{})
+	if err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+
+	if len(report.Workflows) != 1 || len(report.Workflows[0].Issues) != 4 {
+		t.Fatalf("expected 4 findings in one workflow, got %+v", report.Workflows)
+	}
+	for _, issue := range report.Workflows[0].Issues {
+		if issue.FixSHA != "sha" {
+			t.Fatalf("%s was not resolved in parallel with the others: FixSHA = %q", issue.Original, issue.FixSHA)
+		}
+	}
+	if res.calls["actions/checkout@v4"] != 1 {
+		t.Fatalf("actions/checkout@v4 resolved %d times; want once", res.calls["actions/checkout@v4"])
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// spinnerFrames animate the progress line on a terminal
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Progress reports each action reference as it is resolved, so audits of repos
+// with many actions don't look hung while they wait on the network
+type Progress struct {
+	w       io.Writer
+	spinner bool // redraw a single line instead of printing one per reference
+
+	mu    sync.Mutex
+	total int
+	done  int
+}
+
+// NewProgress returns a Progress writing to w, Ex: stderr, so JSON and SARIF on
+// stdout stay clean. With spinner, a single animated line is redrawn in place,
+// which only reads well on a terminal.
+func NewProgress(w io.Writer, spinner bool) *Progress {
+	return &Progress{w: w, spinner: spinner}
+}
+
+// progress is set from the --progress flag; nil reports nothing
+var progress *Progress
+
+// SetProgress makes audits report their progress to p. nil turns it off.
+func SetProgress(p *Progress) {
+	progress = p
+}
+
+// expect adds n references to the total to resolve
+func (p *Progress) expect(n int) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total += n
+}
+
+// resolving reports that ref is about to be resolved
+func (p *Progress) resolving(ref string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	// References beyond the expected ones, Ex: of an action file, grow the total
+	p.total = max(p.total, p.done)
+	if p.spinner {
+		frame := spinnerFrames[(p.done-1)%len(spinnerFrames)]
+		fmt.Fprintf(p.w, "\r%s [%d/%d] resolving %s\033[K", frame, p.done, p.total, ref)
+		return
+	}
+	fmt.Fprintf(p.w, "[%d/%d] resolving %s\n", p.done, p.total, ref)
+}
+
+// finish clears the spinner line, so the report starts on a clean line, and
+// resets the counts for the next audit
+func (p *Progress) finish() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.spinner && p.done > 0 {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+	p.total, p.done = 0, 0
+}
+
+// resolvableRefs returns the references of a workflow that assembleWorkflow
+// resolves, Ex: actions/checkout@v4, skipping those it never looks up
+func resolvableRefs(content []byte, trusted []string, ignores []string) []string {
+	matches, err := workflowMatches(content, findRegex)
+	if err != nil {
+		return nil
+	}
+
+	var refs []string
+	for _, m := range matches {
+		if isInLocalReference(content, m.StartOffset) || isPinnedReference(content, m.StartOffset) {
+			continue
+		}
+		action, _, _ := strings.Cut(m.Text, "@")
+		if isTrustedOwner(trusted, action) {
+			continue
+		}
+		if _, ok := ignoredActionBy(ignores, action); ok {
+			continue
+		}
+		if _, dynamic := dynamicReference(content, m.StartOffset, m.EndOffset); dynamic {
+			continue
+		}
+		refs = append(refs, m.Text)
+	}
+
+	return refs
+}
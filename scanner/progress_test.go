@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// progressWorkflow has two references to resolve, and a pinned and a local one
+// that are never resolved
+const progressWorkflow = `jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v5
+      - uses: ./.github/actions/local
+      - uses: actions/cache@v4
+`
+
+func auditWithProgress(t *testing.T, p *Progress) {
+	t.Helper()
+	SetProgress(p)
+	t.Cleanup(func() { SetProgress(nil) })
+
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, progressWorkflow)
+	if _, err := AuditRepositoryReport(FilePath(tmp), staticResolver{sha: "sha"}, AuditOptions{}); err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+}
+
+func TestProgressLines(t *testing.T) {
+	var buf bytes.Buffer
+	auditWithProgress(t, NewProgress(&buf, false))
+
+	// References are resolved in parallel, so in any order
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "[1/2] resolving ") || !strings.HasPrefix(lines[1], "[2/2] resolving ") {
+		t.Fatalf("progress = %q; want one [n/2] line per reference", buf.String())
+	}
+	for _, ref := range []string{"actions/checkout@v4", "actions/cache@v4"} {
+		if !strings.Contains(buf.String(), "resolving "+ref+"\n") {
+			t.Fatalf("progress = %q; want a line resolving %s", buf.String(), ref)
+		}
+	}
+}
+
+func TestProgressSpinnerClearsLine(t *testing.T) {
+	var buf bytes.Buffer
+	auditWithProgress(t, NewProgress(&buf, true))
+
+	out := buf.String()
+	if !strings.Contains(out, "\r⠙ [2/2] resolving actions/") {
+		t.Fatalf("expected the spinner line to be redrawn in place, got %q", out)
+	}
+	if !strings.HasSuffix(out, "\r\033[K") || strings.Contains(out, "\n") {
+		t.Fatalf("expected the spinner line to be cleared, got %q", out)
+	}
+}
+
+func TestResolvableRefsSkipsTrustedAndDynamic(t *testing.T) {
+	content := []byte(`jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: my-org/deploy@v1
+      - uses: my-org/setup@v1${{ matrix.suffix }}
+`)
+	if got := resolvableRefs(content, nil, nil); !slices.Equal(got, []string{"actions/checkout@v4", "my-org/deploy@v1"}) {
+		t.Fatalf("resolvableRefs = %v; want actions/checkout@v4 and my-org/deploy@v1", got)
+	}
+	if got := resolvableRefs(content, []string{"my-org"}, nil); !slices.Equal(got, []string{"actions/checkout@v4"}) {
+		t.Fatalf("resolvableRefs with trusted my-org = %v; want actions/checkout@v4", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// pinnedCommitRegex matches owner/repo[/path]@<sha>, capturing owner/repo and the SHA
+var pinnedCommitRegex = regexp.MustCompile(`([\w.-]+/[\w.-]+)(?:/[\w.-]+)*@([a-f0-9]{40})\b`)
+
+// CommitChecker looks up whether a commit is reachable in an action repository
+type CommitChecker interface {
+	CommitExists(action string, sha string) (bool, error)
+}
+
+// PinnedCommit is a reference pinned to a commit SHA, Ex: actions/checkout@<sha>
+type PinnedCommit struct {
+	FilePath string // workflow file containing the pin
+	Line     int    // 1-based line number
+	Action   string // owner/repo
+	SHA      string // pinned commit SHA
+	Exists   bool   // whether the commit is on the default branch or at a tag of the action repository
+}
+
+// CheckPinnedCommits lists the commit-pinned references of every workflow in the
+// repository and whether each commit is still reachable upstream. An unreachable
+// commit is a dangling pin, Ex: to a force-pushed away or fork commit.
+func CheckPinnedCommits(path FilePath, cc CommitChecker) ([]PinnedCommit, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	files, err := listWorkflowFiles(abs)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	// The same pin tends to repeat across workflows; look each up once
+	exists := map[string]bool{}
+	var pins []PinnedCommit
+	for _, f := range files {
+		content, err := ReadFile(FilePath(f))
+		if err != nil {
+			if errors.Is(err, syscall.EISDIR) {
+				continue
+			}
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+
+		for i, line := range strings.Split(string(content), "\n") {
+			for _, m := range pinnedCommitRegex.FindAllStringSubmatchIndex(line, -1) {
+				if isInLocalReference([]byte(line), m[0]) {
+					continue
+				}
+
+				action, sha := line[m[2]:m[3]], line[m[4]:m[5]]
+				key := action + "@" + sha
+				ok, seen := exists[key]
+				if !seen {
+					ok, err = cc.CommitExists(action, sha)
+					if err != nil {
+						return nil, fmt.Errorf("checking %s: %w", key, err)
+					}
+					exists[key] = ok
+				}
+
+				pins = append(pins, PinnedCommit{
+					FilePath: f,
+					Line:     i + 1,
+					Action:   action,
+					SHA:      sha,
+					Exists:   ok,
+				})
+			}
+		}
+	}
+
+	return pins, nil
+}
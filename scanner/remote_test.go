@@ -0,0 +1,68 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	reachableSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+	danglingSHA  = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
+)
+
+// fakeCommitChecker knows the commits in known and counts lookups
+type fakeCommitChecker struct {
+	known map[string]bool
+	calls int
+	err   error
+}
+
+func (f *fakeCommitChecker) CommitExists(action string, sha string) (bool, error) {
+	f.calls++
+	return f.known[action+"@"+sha], f.err
+}
+
+func TestCheckPinnedCommits(t *testing.T) {
+	tmp := t.TempDir()
+	writeWorkflow(t, tmp, "steps:\n"+
+		"  - uses: actions/checkout@"+reachableSHA+" # v4\n"+
+		"  - uses: github/codeql-action/init@"+danglingSHA+"\n"+
+		"  - uses: actions/checkout@"+reachableSHA+"\n"+
+		"  - uses: actions/setup-go@v5\n")
+
+	cc := &fakeCommitChecker{known: map[string]bool{"actions/checkout@" + reachableSHA: true}}
+	pins, err := CheckPinnedCommits(FilePath(tmp), cc)
+	if err != nil {
+		t.Fatalf("CheckPinnedCommits returned error: %v", err)
+	}
+
+	if len(pins) != 3 {
+		t.Fatalf("expected 3 pins, got %d: %+v", len(pins), pins)
+	}
+	if !pins[0].Exists || pins[0].Line != 2 || pins[0].Action != "actions/checkout" {
+		t.Errorf("expected a reachable checkout pin on line 2, got %+v", pins[0])
+	}
+	// Sub-path actions are looked up in their repository
+	if pins[1].Exists || pins[1].Action != "github/codeql-action" || pins[1].SHA != danglingSHA {
+		t.Errorf("expected a dangling codeql-action pin, got %+v", pins[1])
+	}
+	if cc.calls != 2 {
+		t.Errorf("expected each distinct pin to be looked up once, got %d lookups", cc.calls)
+	}
+}
+
+func TestCheckPinnedCommitsPropagatesErrors(t *testing.T) {
+	tmp := t.TempDir()
+	writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@"+reachableSHA+"\n")
+
+	cc := &fakeCommitChecker{err: errors.New("rate limited")}
+	if _, err := CheckPinnedCommits(FilePath(tmp), cc); err == nil {
+		t.Fatal("expected the lookup error to be returned")
+	}
+}
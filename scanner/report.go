@@ -0,0 +1,111 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Kinds of non-fatal audit warnings
+const (
+	WarningUnreadableFile      = "unreadable_file"
+	WarningUnresolvedReference = "unresolved_reference"
+	WarningUpdateCheck         = "update_check"
+)
+
+// Warning is a non-fatal problem that leaves audit results incomplete
+type Warning struct {
+	Kind    string `json:"kind"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Action  string `json:"action,omitempty"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	loc := w.File
+	if w.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", w.File, w.Line)
+	}
+	if loc == "" {
+		return w.Message
+	}
+
+	return fmt.Sprintf("%s: %s", loc, w.Message)
+}
+
+// AuditReport is the result of an audit, including the warnings that tell
+// consumers the scan was partial
+type AuditReport struct {
+	Workflows []Workflow `json:"workflows"`
+	Warnings  []Warning  `json:"warnings"`
+	// Root is the directory the workflow paths are under
+	Root string `json:"-"`
+	// WorkflowFiles is the number of workflow files scanned
+	WorkflowFiles int `json:"-"`
+	// ActionFiles is the number of action metadata files (action.yml) scanned
+	ActionFiles int `json:"-"`
+}
+
+// Summary renders the number of scanned workflows and the warnings for the console
+func (r *AuditReport) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "No of workflows: %s%d%s\n", Blue, r.WorkflowFiles, Reset)
+	if r.ActionFiles > 0 {
+		fmt.Fprintf(&b, "No of action files: %s%d%s\n", Blue, r.ActionFiles, Reset)
+	}
+	b.WriteString("\n")
+
+	return b.String() + FormatWarnings(r.Warnings)
+}
+
+// MarshalAuditReport renders a report as indented JSON. Empty lists are kept as []
+// so consumers can always rely on both keys.
+func MarshalAuditReport(r *AuditReport) ([]byte, error) {
+	out := *r
+	if out.Workflows == nil {
+		out.Workflows = []Workflow{}
+	}
+	if out.Warnings == nil {
+		out.Warnings = []Warning{}
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// FormatWarnings renders warnings into colored CLI lines
+func FormatWarnings(warnings []Warning) string {
+	var b strings.Builder
+	for _, w := range warnings {
+		fmt.Fprintf(&b, "%sWarning:%s %s\n", Yellow, Reset, w)
+	}
+
+	return b.String()
+}
+
+// RaisesError reports whether findings fail a --raise-error gate. Only
+// references of the failOn kind (see ClassifyRef, or RefAny) count. With
+// ignoreUnresolvable, findings whose SHA couldn't be resolved, Ex: of private or
+// deleted actions or after a network hiccup, only warn, so the gate fails only
+// on references that can be pinned.
+func RaisesError(wfs []Workflow, failOn string, ignoreUnresolvable bool) bool {
+	for _, wf := range wfs {
+		for _, f := range wf.Issues {
+			// Container images have no branches; they fail whatever failOn is
+			if failOn != RefAny && f.Rule == "" && ClassifyRef(f.Version) != failOn {
+				continue
+			}
+			if !ignoreUnresolvable || f.FixSHA != SHA256NotAvailable {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditRepositoryReport_UnreadableFileWarning(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, "uses: actions/checkout@v4\n")
+	// A dangling symlink is listed as a workflow but cannot be read
+	broken := filepath.Join(tmp, ".github", "workflows", "broken.yml")
+	if err := os.Symlink(filepath.Join(tmp, "missing.yml"), broken); err != nil {
+		t.Fatalf("creating symlink: %v", err)
+	}
+
+	var report *AuditReport
+	captureStdout(t, func() {
+		var err error
+		report, err = AuditRepositoryReport(FilePath(tmp), staticResolver{sha: "sha"}, AuditOptions{})
+		if err != nil {
+			t.Fatalf("AuditRepositoryReport returned error: %v", err)
+		}
+	})
+
+	if len(report.Workflows) != 1 {
+		t.Fatalf("expected the readable workflow to still be audited, got %+v", report.Workflows)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Kind != WarningUnreadableFile || report.Warnings[0].File != broken {
+		t.Fatalf("expected an unreadable file warning for %s, got %+v", broken, report.Warnings)
+	}
+
+	data, err := MarshalAuditReport(report)
+	if err != nil {
+		t.Fatalf("MarshalAuditReport returned error: %v", err)
+	}
+	var decoded struct {
+		Warnings []map[string]any `json:"warnings"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if len(decoded.Warnings) != 1 || decoded.Warnings[0]["kind"] != WarningUnreadableFile {
+		t.Fatalf("expected the warning in the JSON output, got:\n%s", data)
+	}
+}
+
+func TestAuditRepositoryReport_UnresolvedReferenceWarning(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, "uses: actions/checkout@v4\n")
+
+	var report *AuditReport
+	captureStdout(t, func() {
+		var err error
+		report, err = AuditRepositoryReport(FilePath(tmp), rateLimitedResolver{}, AuditOptions{})
+		if err != nil {
+			t.Fatalf("AuditRepositoryReport returned error: %v", err)
+		}
+	})
+
+	if len(report.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %+v", report.Warnings)
+	}
+	w := report.Warnings[0]
+	if w.Kind != WarningUnresolvedReference || w.Action != "actions/checkout@v4" || w.Line != 1 {
+		t.Fatalf("unexpected warning: %+v", w)
+	}
+}
+
+func TestMarshalAuditReport_EmptyListsStayArrays(t *testing.T) {
+	data, err := MarshalAuditReport(&AuditReport{})
+	if err != nil {
+		t.Fatalf("MarshalAuditReport returned error: %v", err)
+	}
+	if !strings.Contains(string(data), `"warnings": []`) || !strings.Contains(string(data), `"workflows": []`) {
+		t.Fatalf("expected empty arrays, got:\n%s", data)
+	}
+}
+
+func TestMarshalAuditReport_FindingsAcrossFiles(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "build.yml"), "steps:\n  - uses: actions/checkout@v4\n")
+	writeFileAt(t, filepath.Join(tmp, ".github", "workflows", "release.yml"), "steps:\n  - uses: actions/setup-go@main\n")
+
+	var report *AuditReport
+	output := captureStdout(t, func() {
+		var err error
+		report, err = AuditRepositoryReport(FilePath(tmp), staticResolver{sha: "sha-resolved"}, AuditOptions{})
+		if err != nil {
+			t.Fatalf("AuditRepositoryReport returned error: %v", err)
+		}
+	})
+	if output != "" {
+		t.Fatalf("expected AuditRepositoryReport to print nothing, got %q", output)
+	}
+
+	data, err := MarshalAuditReport(report)
+	if err != nil {
+		t.Fatalf("MarshalAuditReport returned error: %v", err)
+	}
+	if strings.Contains(string(data), "\033[") {
+		t.Fatalf("ANSI color codes leaked into the JSON:\n%s", data)
+	}
+
+	var decoded struct {
+		Workflows []struct {
+			FilePath string `json:"file_path"`
+			Issues   []struct {
+				Action   string `json:"action"`
+				Version  string `json:"version"`
+				FixSHA   string `json:"fix_sha"`
+				Line     int    `json:"line"`
+				Column   int    `json:"column"`
+				Severity string `json:"severity"`
+			} `json:"issues"`
+		} `json:"workflows"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+
+	if len(decoded.Workflows) != 2 {
+		t.Fatalf("expected 2 workflows, got:\n%s", data)
+	}
+	want := map[string]string{"build.yml": "actions/checkout@v4", "release.yml": "actions/setup-go@main"}
+	for _, wf := range decoded.Workflows {
+		if len(wf.Issues) != 1 {
+			t.Fatalf("expected 1 issue in %s, got %+v", wf.FilePath, wf.Issues)
+		}
+		f := wf.Issues[0]
+		if got := f.Action + "@" + f.Version; got != want[filepath.Base(wf.FilePath)] {
+			t.Errorf("%s: reference = %q; want %q", wf.FilePath, got, want[filepath.Base(wf.FilePath)])
+		}
+		if f.FixSHA != "sha-resolved" || f.Line != 2 || f.Column != 11 || f.Severity == "" {
+			t.Errorf("%s: unexpected finding %+v", wf.FilePath, f)
+		}
+	}
+}
+
+func TestRaisesError(t *testing.T) {
+	resolvable := Finding{Original: "actions/checkout@v4", FixSHA: "sha"}
+	unresolvable := Finding{Original: "private/action@v1", FixSHA: SHA256NotAvailable}
+
+	tests := []struct {
+		name               string
+		issues             []Finding
+		ignoreUnresolvable bool
+		want               bool
+	}{
+		{name: "no findings", want: false},
+		{name: "resolvable finding", issues: []Finding{resolvable}, want: true},
+		{name: "unresolvable finding", issues: []Finding{unresolvable}, want: true},
+		{name: "unresolvable finding ignored", issues: []Finding{unresolvable}, ignoreUnresolvable: true, want: false},
+		{name: "resolvable finding with unresolvable ones ignored", issues: []Finding{unresolvable, resolvable}, ignoreUnresolvable: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wfs := []Workflow{{FilePath: "ci.yml", Issues: tt.issues}}
+			if got := RaisesError(wfs, RefAny, tt.ignoreUnresolvable); got != tt.want {
+				t.Errorf("RaisesError = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRaisesErrorFailOnPolicy(t *testing.T) {
+	root := t.TempDir()
+	initGitRepo(t, root)
+	writeWorkflow(t, root, "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@main\n")
+
+	report, err := AuditRepositoryReport(FilePath(root), staticResolver{sha: "sha"}, AuditOptions{})
+	CheckIfError(err)
+
+	tagsOnly := []Workflow{{FilePath: "ci.yml", Issues: []Finding{{Version: "v4", FixSHA: "sha"}, {Version: "v1.2", FixSHA: "sha"}}}}
+	for _, policy := range []string{RefBranch, RefTag, RefAny} {
+		if !RaisesError(report.Workflows, policy, false) {
+			t.Errorf("--fail-on %s: expected the mixed repository to fail", policy)
+		}
+	}
+	if RaisesError(tagsOnly, RefBranch, false) {
+		t.Error("--fail-on branch: expected tag references to pass")
+	}
+	if !RaisesError(tagsOnly, RefTag, false) || !RaisesError(tagsOnly, RefAny, false) {
+		t.Error("expected tag references to fail --fail-on tag and any")
+	}
+
+	branchesOnly := []Workflow{{FilePath: "ci.yml", Issues: []Finding{{Version: "main", FixSHA: "sha"}}}}
+	if RaisesError(branchesOnly, RefTag, false) {
+		t.Error("--fail-on tag: expected branch references to pass")
+	}
+}
+
+func TestClassifyRef(t *testing.T) {
+	for version, want := range map[string]string{
+		"main": RefBranch, "master": RefBranch, "dev": RefBranch, "develop": RefBranch, "release": RefBranch, "stable": RefBranch,
+		// Without a v prefix the resolver looks a version up among branches
+		"1.2": RefBranch,
+		"v4":  RefTag, "v4.2.1": RefTag, "V2": RefTag,
+		// An abbreviated SHA is no branch
+		"abc1234": RefTag,
+	} {
+		if got := ClassifyRef(version); got != want {
+			t.Errorf("ClassifyRef(%q) = %q; want %q", version, got, want)
+		}
+	}
+}
+
+func TestParseFailOn(t *testing.T) {
+	if p, err := ParseFailOn(" Branch "); err != nil || p != RefBranch {
+		t.Fatalf("ParseFailOn(Branch) = %q, %v", p, err)
+	}
+	if _, err := ParseFailOn("sha"); err == nil {
+		t.Fatal("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// SarifRuleUnpinnedAction is the rule of every unpinned action finding
+	SarifRuleUnpinnedAction = "unpinned-action"
+	// SarifRuleUnpinnedImage is the rule of job and service container images
+	// that aren't pinned to a digest, Ex: from --check-containers
+	SarifRuleUnpinnedImage = "unpinned-container-image"
+	// sarifSrcRoot lets code scanning resolve URIs against the checkout
+	sarifSrcRoot = "%SRCROOT%"
+)
+
+// SARIF 2.1.0 types, limited to what GitHub code scanning consumes
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+// sarifInvocation tells whether the run was complete. A partial run, Ex: rate
+// limited, lists why in its notifications, so it isn't mistaken for a clean one.
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level      string             `json:"level"`
+	Message    sarifMessage       `json:"message"`
+	Descriptor sarifDescriptorRef `json:"descriptor"`
+	Locations  []sarifLocation    `json:"locations,omitempty"`
+}
+
+type sarifDescriptorRef struct {
+	ID string `json:"id"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+	HelpURI          string       `json:"helpUri"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int  `json:"startLine"`
+	StartColumn int  `json:"startColumn,omitempty"`
+	ByteOffset  *int `json:"byteOffset,omitempty"`
+	ByteLength  int  `json:"byteLength,omitempty"`
+}
+
+// findingRegion locates a finding by line and column, and by the bytes of its
+// reference within the file when they are known
+func findingRegion(f Finding) *sarifRegion {
+	region := &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+	if f.EndOffset > f.StartOffset {
+		offset := f.StartOffset
+		region.ByteOffset, region.ByteLength = &offset, f.EndOffset-f.StartOffset
+	}
+	return region
+}
+
+// sarifRules describes the rules of the findings, Ex: for the tool driver
+var sarifRules = map[string]sarifRule{
+	SarifRuleUnpinnedAction: {
+		ID:               SarifRuleUnpinnedAction,
+		Name:             "UnpinnedAction",
+		ShortDescription: sarifMessage{Text: "Third-party GitHub Action is not pinned to a commit SHA"},
+		FullDescription:  sarifMessage{Text: "Mutable references such as tags and branches can be moved to malicious code. Pin third-party actions to a full commit SHA."},
+		HelpURI:          "https://github.com/cybrota/scharf#the-risk-of-mutable-tags",
+	},
+	SarifRuleUnpinnedImage: {
+		ID:               SarifRuleUnpinnedImage,
+		Name:             "UnpinnedContainerImage",
+		ShortDescription: sarifMessage{Text: "Container image is not pinned to a digest"},
+		FullDescription:  sarifMessage{Text: "Image tags can be pushed again with different content. Pin job and service container images to a sha256 digest."},
+		HelpURI:          "https://github.com/cybrota/scharf#the-risk-of-mutable-tags",
+	},
+}
+
+// usedRules returns the IDs of the rules of the report, unpinned-action first
+// and always, so a clean report still describes it
+func usedRules(r *AuditReport) []string {
+	ids := []string{SarifRuleUnpinnedAction}
+	for _, wf := range r.Workflows {
+		for _, f := range wf.Issues {
+			if !slices.Contains(ids, f.RuleID()) {
+				ids = append(ids, f.RuleID())
+			}
+		}
+	}
+
+	return ids
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityHigh:
+		return "error"
+	case SeverityLow:
+		return "note"
+	}
+
+	return "warning"
+}
+
+// sarifArtifact returns the URI of a workflow file, relative to the report root
+// when possible so code scanning can link it to the repository
+func sarifArtifact(root string, file string) sarifArtifactLocation {
+	if root != "" {
+		if rel, err := filepath.Rel(root, file); err == nil {
+			return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: sarifSrcRoot}
+		}
+	}
+
+	return sarifArtifactLocation{URI: filepath.ToSlash(file)}
+}
+
+// sarifInvocationOf reports a run with warnings as unsuccessful, with a
+// notification per warning naming its kind and, when known, its location
+func sarifInvocationOf(r *AuditReport) sarifInvocation {
+	inv := sarifInvocation{ExecutionSuccessful: len(r.Warnings) == 0}
+	for _, w := range r.Warnings {
+		n := sarifNotification{
+			Level:      "warning",
+			Message:    sarifMessage{Text: w.Message},
+			Descriptor: sarifDescriptorRef{ID: w.Kind},
+		}
+		if w.File != "" {
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifact(r.Root, w.File)}
+			if w.Line > 0 {
+				loc.Region = &sarifRegion{StartLine: w.Line}
+			}
+			n.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		inv.ToolExecutionNotifications = append(inv.ToolExecutionNotifications, n)
+	}
+
+	return inv
+}
+
+// MarshalSARIF renders the findings of a report as a SARIF 2.1.0 document. Its
+// invocation is unsuccessful when the report has warnings, as the run was partial.
+func MarshalSARIF(r *AuditReport) ([]byte, error) {
+	results := []sarifResult{}
+	for _, wf := range r.Workflows {
+		for _, f := range wf.Issues {
+			results = append(results, sarifResult{
+				RuleID:  f.RuleID(),
+				Level:   sarifLevel(f.Severity),
+				Message: sarifMessage{Text: f.FixMsg},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifact(r.Root, wf.FilePath),
+						Region:           findingRegion(f),
+					},
+				}},
+			})
+		}
+	}
+
+	var rules []sarifRule
+	for _, id := range usedRules(r) {
+		rules = append(rules, sarifRules[id])
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "scharf",
+				InformationURI: "https://github.com/cybrota/scharf",
+				Rules:          rules,
+			}},
+			Invocations: []sarifInvocation{sarifInvocationOf(r)},
+			Results:     results,
+		}},
+	}
+
+	return json.MarshalIndent(log, "", "  ")
+}
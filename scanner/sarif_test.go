@@ -0,0 +1,169 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestMarshalSARIFRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	report := &AuditReport{
+		Root: root,
+		Workflows: []Workflow{{
+			FilePath: filepath.Join(root, ".github", "workflows", "ci.yml"),
+			Issues: []Finding{
+				{Line: 12, Column: 15, StartOffset: 200, EndOffset: 221, Severity: SeverityHigh, Original: "actions/setup-go@main", FixMsg: "Pin `actions/setup-go` to sha-go"},
+				{Line: 20, Column: 9, Severity: SeverityMedium, Original: "actions/checkout@v4", FixMsg: "Pin `actions/checkout` to sha-co"},
+			},
+		}},
+	}
+
+	data, err := MarshalSARIF(report)
+	if err != nil {
+		t.Fatalf("MarshalSARIF returned error: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("SARIF is not valid JSON: %v", err)
+	}
+	if doc["version"] != "2.1.0" || doc["$schema"] == nil {
+		t.Fatalf("missing version or $schema: %v", doc)
+	}
+
+	runs, _ := doc["runs"].([]any)
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 run, got %v", doc["runs"])
+	}
+	run := runs[0].(map[string]any)
+	driver := run["tool"].(map[string]any)["driver"].(map[string]any)
+	if driver["name"] != "scharf" {
+		t.Fatalf("tool.driver.name = %v; want scharf", driver["name"])
+	}
+	rules := driver["rules"].([]any)
+	if len(rules) != 1 || rules[0].(map[string]any)["id"] != SarifRuleUnpinnedAction {
+		t.Fatalf("unexpected rules: %v", rules)
+	}
+
+	results := run["results"].([]any)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	first := results[0].(map[string]any)
+	if first["ruleId"] != SarifRuleUnpinnedAction || first["level"] != "error" {
+		t.Fatalf("unexpected ruleId or level: %v", first)
+	}
+	if first["message"].(map[string]any)["text"] != "Pin `actions/setup-go` to sha-go" {
+		t.Fatalf("unexpected message: %v", first["message"])
+	}
+
+	loc := first["locations"].([]any)[0].(map[string]any)["physicalLocation"].(map[string]any)
+	artifact := loc["artifactLocation"].(map[string]any)
+	if artifact["uri"] != ".github/workflows/ci.yml" || artifact["uriBaseId"] != "%SRCROOT%" {
+		t.Fatalf("unexpected artifactLocation: %v", artifact)
+	}
+	region := loc["region"].(map[string]any)
+	if region["startLine"] != float64(12) || region["startColumn"] != float64(15) {
+		t.Fatalf("unexpected region: %v", region)
+	}
+	if region["byteOffset"] != float64(200) || region["byteLength"] != float64(21) {
+		t.Fatalf("expected the byte offset and length of the reference, got region: %v", region)
+	}
+
+	if results[1].(map[string]any)["level"] != "warning" {
+		t.Fatalf("expected medium findings to be warnings: %v", results[1])
+	}
+	second := results[1].(map[string]any)["locations"].([]any)[0].(map[string]any)["physicalLocation"].(map[string]any)["region"].(map[string]any)
+	if _, ok := second["byteOffset"]; ok {
+		t.Fatalf("expected no byte offset for a finding without offsets, got region: %v", second)
+	}
+}
+
+func TestMarshalSARIFWithoutFindings(t *testing.T) {
+	data, err := MarshalSARIF(&AuditReport{})
+	if err != nil {
+		t.Fatalf("MarshalSARIF returned error: %v", err)
+	}
+
+	var doc struct {
+		Runs []struct {
+			Results []any `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("SARIF is not valid JSON: %v", err)
+	}
+	// Code scanning requires results to be present, even when empty
+	if len(doc.Runs) != 1 || doc.Runs[0].Results == nil {
+		t.Fatalf("expected an empty results list, got %s", data)
+	}
+}
+
+// decodeSARIFInvocation decodes the single invocation of a SARIF document
+func decodeSARIFInvocation(t *testing.T, data []byte) sarifInvocation {
+	t.Helper()
+	var doc sarifLog
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("SARIF is not valid JSON: %v", err)
+	}
+	if len(doc.Runs) != 1 || len(doc.Runs[0].Invocations) != 1 {
+		t.Fatalf("expected 1 run with 1 invocation, got %s", data)
+	}
+	return doc.Runs[0].Invocations[0]
+}
+
+func TestMarshalSARIFInvocationSuccessful(t *testing.T) {
+	data, err := MarshalSARIF(&AuditReport{Root: t.TempDir()})
+	if err != nil {
+		t.Fatalf("MarshalSARIF returned error: %v", err)
+	}
+
+	inv := decodeSARIFInvocation(t, data)
+	if !inv.ExecutionSuccessful || len(inv.ToolExecutionNotifications) != 0 {
+		t.Fatalf("expected a successful invocation without notifications, got %+v", inv)
+	}
+}
+
+func TestMarshalSARIFInvocationPartialRun(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, ".github", "workflows", "ci.yml")
+	report := &AuditReport{
+		Root: root,
+		Warnings: []Warning{
+			{Kind: WarningUnresolvedReference, File: file, Line: 7, Action: "actions/checkout@v4", Message: "GitHub API rate limit exceeded"},
+			{Kind: WarningUnreadableFile, File: file, Message: "could not read workflow file: permission denied"},
+		},
+	}
+
+	data, err := MarshalSARIF(report)
+	if err != nil {
+		t.Fatalf("MarshalSARIF returned error: %v", err)
+	}
+
+	inv := decodeSARIFInvocation(t, data)
+	if inv.ExecutionSuccessful {
+		t.Fatalf("expected executionSuccessful=false for a run with warnings")
+	}
+	if len(inv.ToolExecutionNotifications) != 2 {
+		t.Fatalf("expected a notification per warning, got %+v", inv.ToolExecutionNotifications)
+	}
+
+	first := inv.ToolExecutionNotifications[0]
+	if first.Level != "warning" || first.Message.Text != "GitHub API rate limit exceeded" || first.Descriptor.ID != WarningUnresolvedReference {
+		t.Fatalf("unexpected notification: %+v", first)
+	}
+	loc := first.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != ".github/workflows/ci.yml" || loc.Region == nil || loc.Region.StartLine != 7 {
+		t.Fatalf("unexpected notification location: %+v", loc)
+	}
+	if second := inv.ToolExecutionNotifications[1]; second.Locations[0].PhysicalLocation.Region != nil {
+		t.Fatalf("expected no region for a warning without a line, got %+v", second.Locations[0].PhysicalLocation.Region)
+	}
+}
@@ -10,20 +10,30 @@ package scanner
 
 import (
 	"bytes"
+	"cmp"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/cybrota/scharf/git"
+	"gopkg.in/yaml.v3"
 )
 
 // Relative or Absolute path of a file
 type FilePath string
 
 var findRegex = regexp.MustCompile(
-	`([\w-]+)\/([\w-]+)@` +
+	`([\w-]+)\/([\w.-]+)` +
+		`(?:\/[\w.-]+)*` + // path of a sub-action or reusable workflow, Ex: /.github/workflows/ci.yml
+		`@` +
 		`(?:` +
 		`v\d+(?:\.\d+)*` + // e.g. v1, v1.2, v10.0.1
 		`|` +
@@ -43,16 +53,20 @@ func (g GitRepository) Name() string {
 	return g.name
 }
 
+// listGitBranches is swapped in tests to count branch listings
+var listGitBranches = git.ListGitBranches
+
 func (g GitRepository) ListBranches(fp FilePath) ([]string, error) {
-	return git.ListGitBranches(string(fp))
+	return listGitBranches(string(fp))
 }
 
 // InventoryRecord holds details for a regex match in a file.
 type InventoryRecord struct {
-	Repository string   `json:"repository_name"` // Repository name or path
-	Branch     string   `json:"branch_name"`     // Branch name
-	FilePath   string   `json:"actions_file"`    // File path where the match was found
-	Matches    []string `json:"matches"`         // Regex match results from the file content
+	Repository string   `json:"repository_name"`        // Repository name or path
+	Branch     string   `json:"branch_name,omitempty"`  // Branch name
+	Branches   []string `json:"branch_names,omitempty"` // Branch names sharing the record when deduplicated
+	FilePath   string   `json:"actions_file"`           // File path where the match was found
+	Matches    []string `json:"matches"`                // Regex match results from the file content
 }
 
 // Inventory aggregates multiple inventory records.
@@ -60,13 +74,76 @@ type Inventory struct {
 	Records []*InventoryRecord `json:"findings"`
 }
 
+// EncodeInventory writes inv as JSON, indented by two spaces unless compact is
+// set. Compact output is a single line, smaller and faster to parse.
+func EncodeInventory(w io.Writer, inv *Inventory, compact bool) error {
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(inv); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+
+	return nil
+}
+
+// DedupeInventory collapses identical (file, action@version) findings seen on
+// multiple branches into one record per file listing all those branches.
+func DedupeInventory(inv *Inventory) *Inventory {
+	type matchKey struct{ repo, file, match string }
+	type fileKey struct{ repo, file, branches string }
+
+	// Record on which branches each match was seen, keeping first-seen order
+	branchesByMatch := map[matchKey][]string{}
+	var matchOrder []matchKey
+	for _, ir := range inv.Records {
+		for _, m := range ir.Matches {
+			k := matchKey{ir.Repository, ir.FilePath, m}
+			seen, ok := branchesByMatch[k]
+			if !ok {
+				matchOrder = append(matchOrder, k)
+			}
+			if !slices.Contains(seen, ir.Branch) {
+				branchesByMatch[k] = append(seen, ir.Branch)
+			}
+		}
+	}
+
+	// Group matches of a file that share the same set of branches
+	var deduped Inventory
+	records := map[fileKey]*InventoryRecord{}
+	for _, k := range matchOrder {
+		branches := branchesByMatch[k]
+		fk := fileKey{k.repo, k.file, strings.Join(branches, "\x00")}
+		ir, ok := records[fk]
+		if !ok {
+			ir = &InventoryRecord{
+				Repository: k.repo,
+				Branches:   branches,
+				FilePath:   k.file,
+			}
+			records[fk] = ir
+			deduped.Records = append(deduped.Records, ir)
+		}
+		ir.Matches = append(ir.Matches, k.match)
+	}
+
+	return &deduped
+}
+
 // ScanBranch scans a given branch for mutable references
 func ScanBranch(branch string, repo GitRepository, regex *regexp.Regexp, dirPath string) *Inventory {
+	return scanBranch(logger, branch, repo, regex, dirPath)
+}
+
+// scanBranch is ScanBranch logging to scanLog
+func scanBranch(scanLog *slog.Logger, branch string, repo GitRepository, regex *regexp.Regexp, dirPath string) *Inventory {
 	var inventory Inventory
-	fileNames, err := ListFiles(FilePath(dirPath))
+	fileNames, err := listFiles(scanLog, FilePath(dirPath))
 	if err != nil {
 		// The directory might not exist on this branch; skip to next branch.
-		logger.Debug("directory might not exist on branch. skipping to next repo")
+		scanLog.Debug("directory might not exist on branch. skipping to next repo")
 		return nil
 	}
 
@@ -76,7 +153,7 @@ func ScanBranch(branch string, repo GitRepository, regex *regexp.Regexp, dirPath
 		content, err := ReadFile(FilePath(loc))
 		if err != nil {
 			// Log error and skip this file.
-			logger.Debug("workflow directory might not exist. skipping to next repo")
+			scanLog.Debug("workflow directory might not exist. skipping to next repo")
 			continue
 		}
 
@@ -100,37 +177,98 @@ func ScanBranch(branch string, repo GitRepository, regex *regexp.Regexp, dirPath
 	return &inventory
 }
 
-// ScanRepos traverses all repositories found under the root directory,
-// checks each branch, enumerates over files in the given workflow directory path,
-// and scans each file's content for regex matches.
-// ho - HEAD only
-func ScanRepos(repos []*GitRepository, regex *regexp.Regexp, ho bool) (*Inventory, error) {
-	var inventory Inventory
+// DefaultConcurrency is the number of repositories find scans in parallel
+const DefaultConcurrency = 4
 
-	// Process each repository.
-	for _, repo := range repos {
-		branches, err := repo.ListBranches(repo.absPath)
-		if err != nil {
-			// Log error and continue with next repository.
-			logger.Debug("couldn't detect branches. skipping to next repo")
-			continue
-		}
+// scanRepo scans the branches of a single repository selected by filter
+func scanRepo(scanLog *slog.Logger, repo *GitRepository, regex *regexp.Regexp, ho bool, filter BranchFilter) []*InventoryRecord {
+	// Most repositories of a large workspace have no workflows. Skip them
+	// before the comparatively expensive branch listing.
+	if !hasWorkflowDir(string(repo.absPath)) {
+		scanLog.Debug("no workflow directory. skipping to next repo", "repo", repo.Name())
+		return nil
+	}
 
-		if ho {
-			branches = []string{"HEAD"}
-		}
+	branches, err := repo.ListBranches(repo.absPath)
+	if err != nil {
+		// Log error and continue with next repository.
+		scanLog.Debug("couldn't detect branches. skipping to next repo")
+		return nil
+	}
 
-		// For each branch, enumerate files in the specified directory.
-		for _, branch := range branches {
-			searchPath := filepath.Join(string(repo.absPath), ".github", "workflows")
-			logger.Debug("Processing the repo:", "repo", repo.Name(), "branch", branch, "filepath", searchPath)
-			inv := ScanBranch(branch, *repo, regex, searchPath)
+	if ho {
+		branches = []string{"HEAD"}
+	} else if branches, err = filter.Select(string(repo.absPath), branches); err != nil {
+		scanLog.Debug("couldn't select branches. skipping to next repo", "repo", repo.Name(), "error", err)
+		return nil
+	}
+
+	// For each branch, enumerate files in the workflow directories.
+	var records []*InventoryRecord
+	for _, branch := range branches {
+		for _, dir := range WorkflowDirs() {
+			searchPath := filepath.Join(string(repo.absPath), filepath.FromSlash(dir))
+			scanLog.Debug("Processing the repo:", "repo", repo.Name(), "branch", branch, "filepath", searchPath)
+			inv := scanBranch(scanLog, branch, *repo, regex, searchPath)
 			if inv != nil {
-				inventory.Records = append(inventory.Records, inv.Records...)
+				records = append(records, inv.Records...)
 			}
 		}
 	}
 
+	return records
+}
+
+// SortInventory orders records by repository, branch and file so output
+// doesn't depend on the order in which repositories were scanned
+func SortInventory(inv *Inventory) {
+	slices.SortStableFunc(inv.Records, func(a, b *InventoryRecord) int {
+		return cmp.Or(
+			cmp.Compare(a.Repository, b.Repository),
+			cmp.Compare(a.Branch, b.Branch),
+			cmp.Compare(a.FilePath, b.FilePath),
+		)
+	})
+}
+
+// ScanRepos traverses all repositories found under the root directory,
+// checks each branch, enumerates over files in the given workflow directory path,
+// and scans each file's content for regex matches.
+// ho - HEAD only, wins over filter
+// filter - branches to scan, Ex: main and release/*
+// concurrency - number of repositories scanned in parallel, at least one
+func ScanRepos(repos []*GitRepository, regex *regexp.Regexp, ho bool, filter BranchFilter, concurrency int) (*Inventory, error) {
+	var inventory Inventory
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	coordinator := newOutputCoordinator()
+
+	// Each worker holds a slot of the semaphore while it scans a repository
+	sem := make(chan struct{}, max(concurrency, 1))
+	for i, repo := range repos {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			scanLog := logger
+			if orderedOutput {
+				buf := newRecordBuffer(logger.Handler())
+				scanLog = slog.New(buf)
+				defer coordinator.done(i, buf.replay)
+			}
+
+			records := scanRepo(scanLog, repo, regex, ho, filter)
+			mu.Lock()
+			inventory.Records = append(inventory.Records, records...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	SortInventory(&inventory)
 	return &inventory, nil
 }
 
@@ -173,6 +311,11 @@ func ListRepositoriesAtRoot(root FilePath) ([]*GitRepository, error) {
 }
 
 func ListFiles(loc FilePath) ([]*FilePath, error) {
+	return listFiles(logger, loc)
+}
+
+// listFiles is ListFiles logging to scanLog
+func listFiles(scanLog *slog.Logger, loc FilePath) ([]*FilePath, error) {
 	entries, err := os.ReadDir(string(loc))
 	if err != nil {
 		return nil, fmt.Errorf("os: %w", err)
@@ -180,7 +323,7 @@ func ListFiles(loc FilePath) ([]*FilePath, error) {
 
 	var files []*FilePath
 	for _, entry := range entries {
-		logger.Debug("found file at location", "repo", entry.Name(), "loc", loc)
+		scanLog.Debug("found file at location", "repo", entry.Name(), "loc", loc)
 		fp := FilePath(entry.Name())
 		files = append(files, &fp)
 	}
@@ -213,6 +356,9 @@ func ScanContent(content []byte, regex *regexp.Regexp) ([]string, error) {
 type Match struct {
 	Text      string
 	Line, Col int
+	// StartOffset and EndOffset are byte offsets within the whole content,
+	// so that content[StartOffset:EndOffset] == Text.
+	StartOffset, EndOffset int
 }
 
 // ScanContentWithPosition scans the content and returns each match
@@ -222,6 +368,7 @@ func ScanContentWithPosition(content []byte, regex *regexp.Regexp) ([]Match, err
 
 	// Split on \n so we can track line numbers easily.
 	lines := bytes.Split(content, []byte("\n"))
+	lineOffset := 0
 	for i, line := range lines {
 		// FindAllIndex returns a slice of [2]int{startByte, endByte} pairs.
 		locs := regex.FindAllIndex(line, -1)
@@ -233,23 +380,148 @@ func ScanContentWithPosition(content []byte, regex *regexp.Regexp) ([]Match, err
 			// Column is byte-offset +1. (If you care about rune/character columns,
 			// you can convert line[:start] to runes and take len(runes).)
 			results = append(results, Match{
-				Text: matchedText,
-				Line: i + 1,
-				Col:  start + 1,
+				Text:        matchedText,
+				Line:        i + 1,
+				Col:         start + 1,
+				StartOffset: lineOffset + start,
+				EndOffset:   lineOffset + end,
 			})
 		}
+		// +1 accounts for the \n removed by Split
+		lineOffset += len(line) + 1
 	}
 
 	return results, nil
 }
 
-func Find(root string, headOnly bool) (*Inventory, error) {
+// yamlParse turns on YAML-aware scanning of workflows
+var yamlParse = true
+
+// SetYAMLParse switches between YAML-aware scanning of workflows and scanning
+// their raw lines with a regex
+func SetYAMLParse(on bool) {
+	yamlParse = on
+}
+
+// mappingValue returns the value of key in a YAML mapping, following aliases
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			v := node.Content[i+1]
+			if v.Kind == yaml.AliasNode {
+				v = v.Alias
+			}
+			return v
+		}
+	}
+	return nil
+}
+
+// workflowUses returns the uses nodes of a workflow document: jobs.*.uses of
+// reusable workflow calls and jobs.*.steps[].uses of steps
+func workflowUses(jobs *yaml.Node) []*yaml.Node {
+	var uses []*yaml.Node
+	for i := 1; i < len(jobs.Content); i += 2 {
+		job := jobs.Content[i]
+		if u := mappingValue(job, "uses"); u != nil && u.Kind == yaml.ScalarNode {
+			uses = append(uses, u)
+		}
+
+		if steps := mappingValue(job, "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+			uses = append(uses, stepUses(steps)...)
+		}
+	}
+
+	return uses
+}
+
+// stepUses returns the uses nodes of a sequence of steps
+func stepUses(steps *yaml.Node) []*yaml.Node {
+	var uses []*yaml.Node
+	for _, step := range steps.Content {
+		if u := mappingValue(step, "uses"); u != nil && u.Kind == yaml.ScalarNode {
+			uses = append(uses, u)
+		}
+	}
+
+	return uses
+}
+
+// ScanWorkflowUses parses content as a workflow, or as the metadata of a composite
+// action (runs.steps[].uses), and returns the action references of its uses keys
+// matched by regex, with their positions. Unlike scanning lines, comments, run
+// scripts and other strings are never mistaken for references, and quoted or
+// wrapped values are found. ok is false when content is neither (it doesn't parse
+// or has no jobs or steps), so callers can fall back to ScanContentWithPosition.
+func ScanWorkflowUses(content []byte, regex *regexp.Regexp) ([]Match, bool) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return nil, false
+	}
+	var uses []*yaml.Node
+	if jobs := mappingValue(doc.Content[0], "jobs"); jobs != nil && jobs.Kind == yaml.MappingNode {
+		uses = workflowUses(jobs)
+	} else if steps := mappingValue(mappingValue(doc.Content[0], "runs"), "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+		uses = stepUses(steps)
+	} else {
+		return nil, false
+	}
+
+	// Byte offset of the start of each line, to place values found by the parser
+	lineStarts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	var results []Match
+	for _, u := range uses {
+		loc := regex.FindStringIndex(u.Value)
+		if loc == nil || loc[0] != 0 || u.Line < 1 || u.Line > len(lineStarts) {
+			continue
+		}
+		text := u.Value[:loc[1]]
+
+		// The value starts on the line of its node, or on a later one when it is
+		// wrapped in a block scalar. It can't be placed if it was written escaped.
+		at := bytes.Index(content[lineStarts[u.Line-1]:], []byte(text))
+		if at < 0 {
+			logger.Debug("couldn't locate uses value in workflow", "uses", u.Value, "line", u.Line)
+			continue
+		}
+		start := lineStarts[u.Line-1] + at
+
+		line, _ := slices.BinarySearch(lineStarts, start+1)
+		results = append(results, Match{
+			Text:        text,
+			Line:        line,
+			Col:         start - lineStarts[line-1] + 1,
+			StartOffset: start,
+			EndOffset:   start + len(text),
+		})
+	}
+
+	return results, true
+}
+
+func Find(root string, headOnly bool, filter BranchFilter, concurrency int) (*Inventory, error) {
 	repos, err := ListRepositoriesAtRoot(FilePath(root))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
-	inv, err := ScanRepos(repos, findRegex, headOnly)
+	inv, err := ScanRepos(repos, findRegex, headOnly, filter, concurrency)
 	if err != nil {
 		return nil, err
 	}
@@ -7,10 +7,20 @@
 package scanner
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"testing"
+	"time"
+
+	gitlib "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // --- Dummy implementations for Testing ---
@@ -25,6 +35,15 @@ func CheckIfError(err error) {
 	os.Exit(1)
 }
 
+// staticResolver resolves every action to the same SHA.
+type staticResolver struct {
+	sha string
+}
+
+func (s staticResolver) Resolve(action string) (string, error) {
+	return s.sha, nil
+}
+
 // --- Tests ---
 
 // TestShouldIncludeDir verifies that directories/files meant to be ignored return false.
@@ -60,6 +79,300 @@ func TestGitHubWorkFlowScanner_ScanContent(t *testing.T) {
 	}
 }
 
+// TestScanContentWithPosition_Offsets checks that byte offsets delimit the matched text.
+func TestScanContentWithPosition_Offsets(t *testing.T) {
+	content := []byte("jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: owner/répo-x@main # après\n      - uses: actions/cache@v3 && actions/setup-go@v5\n")
+	matches, err := ScanContentWithPosition(content, findRegex)
+	CheckIfError(err)
+
+	expected := []string{"actions/checkout@v4", "actions/cache@v3", "actions/setup-go@v5"}
+	if len(matches) != len(expected) {
+		t.Fatalf("expected %d matches, got %d: %+v", len(expected), len(matches), matches)
+	}
+	for i, m := range matches {
+		if m.Text != expected[i] {
+			t.Errorf("match %d text = %q; want %q", i, m.Text, expected[i])
+		}
+		if got := string(content[m.StartOffset:m.EndOffset]); got != m.Text {
+			t.Errorf("content[%d:%d] = %q; want %q", m.StartOffset, m.EndOffset, got, m.Text)
+		}
+	}
+}
+
+// TestAssembleWorkflow_Offsets checks that findings carry offsets of the offending reference.
+func TestAssembleWorkflow_Offsets(t *testing.T) {
+	content := []byte("steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@v5\n")
+	wf, err := AssembleWorkflow(staticResolver{sha: "sha"}, content, "ci.yml", "ci.yml")
+	CheckIfError(err)
+
+	if len(wf.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(wf.Issues))
+	}
+	for _, f := range wf.Issues {
+		if got := string(content[f.StartOffset:f.EndOffset]); got != f.Original {
+			t.Errorf("content[%d:%d] = %q; want %q", f.StartOffset, f.EndOffset, got, f.Original)
+		}
+	}
+}
+
+// commitWorkflowOnBranches commits a workflow into a new repository under root
+// and points every given branch at that commit, so the workflow is shared.
+func commitWorkflowOnBranches(t testing.TB, root string, name string, workflow string, branches []string) {
+	t.Helper()
+	repoPath := filepath.Join(root, name)
+	repo, err := gitlib.PlainInit(repoPath, false)
+	CheckIfError(err)
+	writeWorkflow(t, repoPath, workflow)
+
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	hash, err := w.Commit("add workflow", &gitlib.CommitOptions{
+		Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+	})
+	CheckIfError(err)
+
+	for _, b := range branches {
+		ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(b), hash)
+		CheckIfError(repo.Storer.SetReference(ref))
+	}
+}
+
+// TestDedupeInventory_MultiBranch checks that a workflow shared by several branches
+// yields a single record listing those branches.
+func TestDedupeInventory_MultiBranch(t *testing.T) {
+	root := t.TempDir()
+	workflow := "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@v5\n"
+	commitWorkflowOnBranches(t, root, "repo", workflow, []string{"dev", "feature"})
+
+	repos, err := ListRepositoriesAtRoot(FilePath(root))
+	CheckIfError(err)
+	inv, err := ScanRepos(repos, findRegex, false, BranchFilter{}, 1)
+	CheckIfError(err)
+	if len(inv.Records) < 3 {
+		t.Fatalf("expected a record per branch, got %d", len(inv.Records))
+	}
+
+	deduped := DedupeInventory(inv)
+	if len(deduped.Records) != 1 {
+		t.Fatalf("expected 1 deduplicated record, got %d: %+v", len(deduped.Records), deduped.Records)
+	}
+
+	ir := deduped.Records[0]
+	for _, b := range []string{"master", "dev", "feature"} {
+		if !slices.Contains(ir.Branches, b) {
+			t.Errorf("expected branch %q in %v", b, ir.Branches)
+		}
+	}
+	if !reflect.DeepEqual(ir.Matches, []string{"actions/checkout@v4", "actions/setup-go@v5"}) {
+		t.Errorf("unexpected matches: %v", ir.Matches)
+	}
+}
+
+// TestDedupeInventory_KeepsDivergentFindingsApart checks that findings seen only
+// on some branches are grouped separately from shared ones.
+func TestDedupeInventory_KeepsDivergentFindingsApart(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{Repository: "repo", Branch: "main", FilePath: "ci.yml", Matches: []string{"actions/checkout@v4"}},
+		{Repository: "repo", Branch: "dev", FilePath: "ci.yml", Matches: []string{"actions/checkout@v4", "actions/cache@v3"}},
+		{Repository: "repo", Branch: "dev", FilePath: "release.yml", Matches: []string{"actions/checkout@v4"}},
+	}}
+
+	deduped := DedupeInventory(inv)
+	if len(deduped.Records) != 3 {
+		t.Fatalf("expected 3 records, got %d: %+v", len(deduped.Records), deduped.Records)
+	}
+
+	shared := deduped.Records[0]
+	if shared.FilePath != "ci.yml" || !reflect.DeepEqual(shared.Branches, []string{"main", "dev"}) {
+		t.Errorf("unexpected shared record: %+v", shared)
+	}
+	devOnly := deduped.Records[1]
+	if !reflect.DeepEqual(devOnly.Branches, []string{"dev"}) || !reflect.DeepEqual(devOnly.Matches, []string{"actions/cache@v3"}) {
+		t.Errorf("unexpected dev-only record: %+v", devOnly)
+	}
+}
+
+// scannedBranches lists the distinct branches of the records, sorted
+func scannedBranches(inv *Inventory) []string {
+	var branches []string
+	for _, ir := range inv.Records {
+		if !slices.Contains(branches, ir.Branch) {
+			branches = append(branches, ir.Branch)
+		}
+	}
+	slices.Sort(branches)
+	return branches
+}
+
+// TestScanRepos_BranchFilter checks that only branches matching the filter are scanned
+func TestScanRepos_BranchFilter(t *testing.T) {
+	root := t.TempDir()
+	commitWorkflowOnBranches(t, root, "repo", "steps:\n  - uses: actions/checkout@v4\n", []string{"main", "dev", "release/1.0", "release/2.0"})
+	repos, err := ListRepositoriesAtRoot(FilePath(root))
+	CheckIfError(err)
+
+	for _, tc := range []struct {
+		name     string
+		filter   BranchFilter
+		headOnly bool
+		want     []string
+	}{
+		{"patterns", BranchFilter{Patterns: []string{"main", "release/*"}}, false, []string{"main", "release/1.0", "release/2.0"}},
+		{"default branch only", BranchFilter{DefaultOnly: true}, false, []string{"master"}},
+		{"default branch filtered out", BranchFilter{Patterns: []string{"release/*"}, DefaultOnly: true}, false, nil},
+		{"head only wins", BranchFilter{Patterns: []string{"dev"}}, true, []string{"HEAD"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inv, err := ScanRepos(repos, findRegex, tc.headOnly, tc.filter, 1)
+			CheckIfError(err)
+			if got := scannedBranches(inv); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("scanned branches = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewBranchFilter(t *testing.T) {
+	if _, err := NewBranchFilter([]string{"main", "release/*"}, false); err != nil {
+		t.Fatalf("NewBranchFilter returned error: %v", err)
+	}
+	if _, err := NewBranchFilter([]string{"release/["}, false); err == nil {
+		t.Fatal("expected an error for a malformed pattern")
+	}
+}
+
+// countBranchListings counts calls to list the branches of a repository
+func countBranchListings(t testing.TB) *int {
+	t.Helper()
+	calls := 0
+	orig := listGitBranches
+	listGitBranches = func(path string) ([]string, error) {
+		calls++
+		return orig(path)
+	}
+	t.Cleanup(func() { listGitBranches = orig })
+	return &calls
+}
+
+// TestScanRepos_SkipsReposWithoutWorkflows checks that branches are only listed
+// for repositories that have a workflow directory.
+func TestScanRepos_SkipsReposWithoutWorkflows(t *testing.T) {
+	root := t.TempDir()
+	commitWorkflowOnBranches(t, root, "with-workflows", "steps:\n  - uses: actions/checkout@v4\n", nil)
+	_, err := gitlib.PlainInit(filepath.Join(root, "without-workflows"), false)
+	CheckIfError(err)
+
+	calls := countBranchListings(t)
+	repos, err := ListRepositoriesAtRoot(FilePath(root))
+	CheckIfError(err)
+	inv, err := ScanRepos(repos, findRegex, false, BranchFilter{}, 1)
+	CheckIfError(err)
+
+	if *calls != 1 {
+		t.Fatalf("expected branches to be listed for 1 repository, got %d", *calls)
+	}
+	if len(inv.Records) == 0 {
+		t.Fatal("expected records for the repository with workflows")
+	}
+	for _, ir := range inv.Records {
+		if ir.Repository != "with-workflows" {
+			t.Fatalf("unexpected record of %s: %+v", ir.Repository, ir)
+		}
+	}
+}
+
+func BenchmarkScanReposWithoutWorkflows(b *testing.B) {
+	root := b.TempDir()
+	for i := range 200 {
+		CheckIfError(os.MkdirAll(filepath.Join(root, fmt.Sprintf("repo-%d", i), "src"), 0o755))
+	}
+	repos, err := ListRepositoriesAtRoot(FilePath(root))
+	CheckIfError(err)
+	calls := countBranchListings(b)
+
+	for b.Loop() {
+		if _, err := ScanRepos(repos, findRegex, false, BranchFilter{}, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if *calls != 0 {
+		b.Fatalf("expected no branch listings, got %d", *calls)
+	}
+}
+
+// workspaceWithWorkflows creates n repositories under a new root, each with a
+// workflow committed on two extra branches
+func workspaceWithWorkflows(t testing.TB, n int) []*GitRepository {
+	t.Helper()
+	root := t.TempDir()
+	workflow := "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/setup-go@v5\n"
+	for i := range n {
+		commitWorkflowOnBranches(t, root, fmt.Sprintf("repo-%02d", i), workflow, []string{"dev", "feature"})
+	}
+	repos, err := ListRepositoriesAtRoot(FilePath(root))
+	CheckIfError(err)
+	return repos
+}
+
+// TestScanRepos_ParallelMatchesSerial checks that scanning repositories in
+// parallel yields the same, ordered inventory as a serial scan.
+func TestScanRepos_ParallelMatchesSerial(t *testing.T) {
+	repos := workspaceWithWorkflows(t, 8)
+
+	serial, err := ScanRepos(repos, findRegex, false, BranchFilter{}, 1)
+	CheckIfError(err)
+	parallel, err := ScanRepos(repos, findRegex, false, BranchFilter{}, 4)
+	CheckIfError(err)
+
+	if len(serial.Records) < 8*3 {
+		t.Fatalf("expected a record per repository and branch, got %d", len(serial.Records))
+	}
+	if !reflect.DeepEqual(serial, parallel) {
+		t.Fatalf("parallel inventory differs from serial one:\nserial:   %+v\nparallel: %+v", serial.Records, parallel.Records)
+	}
+
+	var serialJSON, parallelJSON bytes.Buffer
+	CheckIfError(EncodeInventory(&serialJSON, serial, true))
+	CheckIfError(EncodeInventory(&parallelJSON, parallel, true))
+	if serialJSON.String() != parallelJSON.String() {
+		t.Fatal("expected identical JSON output")
+	}
+}
+
+func TestSortInventory(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{Repository: "b", Branch: "main", FilePath: "ci.yml"},
+		{Repository: "a", Branch: "main", FilePath: "release.yml"},
+		{Repository: "a", Branch: "dev", FilePath: "ci.yml"},
+		{Repository: "a", Branch: "main", FilePath: "ci.yml"},
+	}}
+
+	SortInventory(inv)
+	var got []string
+	for _, ir := range inv.Records {
+		got = append(got, ir.Repository+"/"+ir.Branch+"/"+ir.FilePath)
+	}
+	want := []string{"a/dev/ci.yml", "a/main/ci.yml", "a/main/release.yml", "b/main/ci.yml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortInventory order = %v; want %v", got, want)
+	}
+}
+
+func BenchmarkScanRepos(b *testing.B) {
+	repos := workspaceWithWorkflows(b, 32)
+	for _, concurrency := range []int{1, DefaultConcurrency} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := ScanRepos(repos, findRegex, false, BranchFilter{}, concurrency); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 // TestScanner_ScanRepos tests the ScanRepos method by wiring in fake VCS and repository implementations.
 func TestScanner_ScanRepos(t *testing.T) {
 	// TODO
@@ -69,3 +382,124 @@ func TestScanner_ScanRepos(t *testing.T) {
 func TestScanner_ScanReposDefaultBranch(t *testing.T) {
 	// TODO
 }
+
+const yamlWorkflow = `name: ci
+on: push
+jobs:
+  call:
+    uses: "octo-org/shared/.github/workflows/ci.yml@v1"
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      # - uses: actions/cache@v3
+      - uses: 'actions/checkout@v4'
+      - name: Wrapped
+        uses: >-
+          actions/setup-go@v5
+      - run: |
+          echo "uses: docker/login-action@v3"
+      - uses: ./.github/actions/local@v1
+      - uses: actions/upload-artifact@v4 # keep artifacts
+`
+
+func TestScanWorkflowUses(t *testing.T) {
+	matches, ok := ScanWorkflowUses([]byte(yamlWorkflow), findRegex)
+	if !ok {
+		t.Fatal("expected the workflow to parse")
+	}
+
+	want := []struct {
+		text      string
+		line, col int
+	}{
+		{"octo-org/shared/.github/workflows/ci.yml@v1", 5, 12},
+		{"actions/checkout@v4", 10, 16},
+		{"actions/setup-go@v5", 13, 11},
+		{"actions/upload-artifact@v4", 17, 15},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("expected %d matches, got %d: %+v", len(want), len(matches), matches)
+	}
+	for i, w := range want {
+		m := matches[i]
+		if m.Text != w.text || m.Line != w.line || m.Col != w.col {
+			t.Errorf("match %d = %q at %d:%d; want %q at %d:%d", i, m.Text, m.Line, m.Col, w.text, w.line, w.col)
+		}
+		if got := yamlWorkflow[m.StartOffset:m.EndOffset]; got != m.Text {
+			t.Errorf("match %d offsets point to %q; want %q", i, got, m.Text)
+		}
+	}
+}
+
+func TestScanWorkflowUsesFallsBackForNonWorkflows(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid YAML": "jobs: [unclosed\n",
+		"no jobs":      "steps:\n  - uses: actions/checkout@v4\n",
+	} {
+		if _, ok := ScanWorkflowUses([]byte(content), findRegex); ok {
+			t.Errorf("%s: expected no workflow", name)
+		}
+	}
+}
+
+func TestAssembleWorkflowIgnoresCommentedUses(t *testing.T) {
+	wf, err := AssembleWorkflow(staticResolver{sha: "sha"}, []byte(yamlWorkflow), "ci.yml", "ci.yml")
+	CheckIfError(err)
+	for _, f := range wf.Issues {
+		if f.Action == "actions/cache" || f.Action == "docker/login-action" {
+			t.Errorf("flagged %s outside of a uses key", f.Original)
+		}
+	}
+	if len(wf.Issues) != 4 {
+		t.Fatalf("expected 4 findings, got %d: %+v", len(wf.Issues), wf.Issues)
+	}
+
+	// The regex scan is kept as an option, and flags every action-like string
+	SetYAMLParse(false)
+	t.Cleanup(func() { SetYAMLParse(true) })
+	wf, err = AssembleWorkflow(staticResolver{sha: "sha"}, []byte(yamlWorkflow), "ci.yml", "ci.yml")
+	CheckIfError(err)
+	if len(wf.Issues) != 6 {
+		t.Fatalf("expected 6 findings with regex scanning, got %d: %+v", len(wf.Issues), wf.Issues)
+	}
+}
+
+func TestEncodeInventory(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{Repository: "repo", Branch: "main", FilePath: "ci.yml", Matches: []string{"actions/checkout@v4"}},
+	}}
+
+	var pretty, compact bytes.Buffer
+	CheckIfError(EncodeInventory(&pretty, inv, false))
+	CheckIfError(EncodeInventory(&compact, inv, true))
+
+	wantCompact := `{"findings":[{"repository_name":"repo","branch_name":"main","actions_file":"ci.yml","matches":["actions/checkout@v4"]}]}` + "\n"
+	if compact.String() != wantCompact {
+		t.Fatalf("compact = %q; want %q", compact.String(), wantCompact)
+	}
+
+	wantPretty := `{
+  "findings": [
+    {
+      "repository_name": "repo",
+      "branch_name": "main",
+      "actions_file": "ci.yml",
+      "matches": [
+        "actions/checkout@v4"
+      ]
+    }
+  ]
+}
+`
+	if pretty.String() != wantPretty {
+		t.Fatalf("pretty = %q; want %q", pretty.String(), wantPretty)
+	}
+
+	// Both forms hold the same document
+	var a, b Inventory
+	CheckIfError(json.Unmarshal(pretty.Bytes(), &a))
+	CheckIfError(json.Unmarshal(compact.Bytes(), &b))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("pretty and compact decode differently: %+v vs %+v", a, b)
+	}
+}
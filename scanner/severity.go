@@ -0,0 +1,161 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// Severity ranks how risky a mutable reference is
+type Severity string
+
+const (
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+var severityRank = map[Severity]int{
+	SeverityLow:    1,
+	SeverityMedium: 2,
+	SeverityHigh:   3,
+}
+
+// ParseSeverity converts a user given value like "High" into a Severity
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := severityRank[sev]; !ok {
+		return "", fmt.Errorf("invalid severity: %q. Valid values are low, medium, high", s)
+	}
+
+	return sev, nil
+}
+
+// AtLeast reports whether s is as severe as min or more
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+// Kinds of mutable references, as returned by ClassifyRef
+const (
+	RefBranch = "branch"
+	RefTag    = "tag"
+	// RefAny is the --fail-on policy matching every kind of reference
+	RefAny = "any"
+)
+
+// isBranchRef reports whether version names a branch rather than a tag. It
+// follows the resolver, so what is pinned to a branch head is gated as a branch.
+func isBranchRef(version string) bool {
+	return network.IsBranchVersion(version)
+}
+
+// ClassifyRef returns the kind of reference version is: RefBranch (Ex: main or
+// 1.2, which the resolver looks up among branches) or RefTag (Ex: v4). An
+// abbreviated SHA, Ex: abc1234, is no branch and counts as RefTag.
+func ClassifyRef(version string) string {
+	if isBranchRef(version) {
+		return RefBranch
+	}
+
+	return RefTag
+}
+
+// ParseFailOn validates a --fail-on policy: branch, tag or any
+func ParseFailOn(s string) (string, error) {
+	policy := strings.ToLower(strings.TrimSpace(s))
+	switch policy {
+	case RefBranch, RefTag, RefAny:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("invalid --fail-on value: %q. Valid values are branch, tag, any", s)
+}
+
+// ClassifySeverity is the default classifier. Branch references move on every
+// push, so they rank higher than tags which usually move only on releases.
+func ClassifySeverity(version string) Severity {
+	if isBranchRef(version) {
+		return SeverityHigh
+	}
+
+	return SeverityMedium
+}
+
+// ParseSeverityOverrides validates the severity_overrides config section
+func ParseSeverityOverrides(raw map[string]string) (map[string]Severity, error) {
+	overrides := make(map[string]Severity, len(raw))
+	for pattern, value := range raw {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid severity override pattern %q: %w", pattern, err)
+		}
+
+		sev, err := ParseSeverity(value)
+		if err != nil {
+			return nil, fmt.Errorf("severity override for %q: %w", pattern, err)
+		}
+		overrides[pattern] = sev
+	}
+
+	return overrides, nil
+}
+
+// SeverityFor returns the severity of an action reference. An exact action
+// override wins, then the longest matching glob, then the default classifier.
+func SeverityFor(action string, version string, overrides map[string]Severity) Severity {
+	if pattern, ok := mostSpecificMatch(overrides, action, actionMatches); ok {
+		return overrides[pattern]
+	}
+
+	return ClassifySeverity(version)
+}
+
+// mostSpecificMatch picks the pattern of m that best matches name with match:
+// an exact key wins, then the longest matching glob.
+func mostSpecificMatch[V any](m map[string]V, name string, match func(pattern string, name string) bool) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+
+	best := ""
+	for pattern := range m {
+		if !match(pattern, name) {
+			continue
+		}
+		// Longer patterns are more specific; break ties lexically so map
+		// iteration order never changes the outcome.
+		if len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+
+	return best, best != ""
+}
+
+// FilterBySeverity drops findings below min and workflows left without findings
+func FilterBySeverity(wfs []Workflow, min Severity) []Workflow {
+	var filtered []Workflow
+	for _, wf := range wfs {
+		var issues []Finding
+		for _, f := range wf.Issues {
+			if f.Severity.AtLeast(min) {
+				issues = append(issues, f)
+			}
+		}
+
+		if len(issues) > 0 {
+			wf.Issues = issues
+			filtered = append(filtered, wf)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import "testing"
+
+func TestClassifySeverity(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected Severity
+	}{
+		{"main", SeverityHigh},
+		{"master", SeverityHigh},
+		{"dev", SeverityHigh},
+		{"develop", SeverityHigh},
+		{"v4", SeverityMedium},
+		// Without a v prefix the resolver pins a branch head
+		{"1.2.3", SeverityHigh},
+	}
+	for _, tc := range tests {
+		if got := ClassifySeverity(tc.version); got != tc.expected {
+			t.Errorf("ClassifySeverity(%q) = %q; want %q", tc.version, got, tc.expected)
+		}
+	}
+}
+
+func TestSeverityForOverridePrecedence(t *testing.T) {
+	overrides, err := ParseSeverityOverrides(map[string]string{
+		"owner/*":          "high",
+		"owner/trusted":    "low",
+		"actions/*":        "low",
+		"actions/checkout": "High",
+	})
+	if err != nil {
+		t.Fatalf("ParseSeverityOverrides returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		action   string
+		version  string
+		expected Severity
+	}{
+		{"glob override beats tag default", "owner/thing", "v1", SeverityHigh},
+		{"exact override beats glob", "owner/trusted", "main", SeverityLow},
+		{"glob override beats branch default", "actions/setup-go", "main", SeverityLow},
+		{"exact override is case-insensitive in value", "actions/checkout", "v4", SeverityHigh},
+		{"no override falls back to default", "other/action", "main", SeverityHigh},
+		{"glob matches a sub-action by its repository", "actions/cache/save", "v4", SeverityLow},
+		{"exact override matches mixed case", "Actions/Checkout", "v4", SeverityHigh},
+		{"glob matches mixed case", "Owner/Thing", "v1", SeverityHigh},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SeverityFor(tc.action, tc.version, overrides); got != tc.expected {
+				t.Errorf("SeverityFor(%q, %q) = %q; want %q", tc.action, tc.version, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSeverityForLongestGlobWins(t *testing.T) {
+	overrides := map[string]Severity{
+		"*/*":         SeverityLow,
+		"my-org/*":    SeverityHigh,
+		"my-org/ci-*": SeverityMedium,
+	}
+	if got := SeverityFor("my-org/ci-tools", "v1", overrides); got != SeverityMedium {
+		t.Errorf("SeverityFor() = %q; want %q", got, SeverityMedium)
+	}
+	if got := SeverityFor("my-org/deploy", "v1", overrides); got != SeverityHigh {
+		t.Errorf("SeverityFor() = %q; want %q", got, SeverityHigh)
+	}
+	if got := SeverityFor("someone/else", "main", overrides); got != SeverityLow {
+		t.Errorf("SeverityFor() = %q; want %q", got, SeverityLow)
+	}
+}
+
+func TestParseSeverityOverridesRejectsInvalidValues(t *testing.T) {
+	if _, err := ParseSeverityOverrides(map[string]string{"owner/*": "critical"}); err == nil {
+		t.Fatal("expected error for unknown severity, got nil")
+	}
+	if _, err := ParseSeverityOverrides(map[string]string{"owner/[": "high"}); err == nil {
+		t.Fatal("expected error for malformed pattern, got nil")
+	}
+}
+
+func TestFilterBySeverity(t *testing.T) {
+	wfs := []Workflow{
+		{FilePath: "a.yml", Issues: []Finding{
+			{Action: "actions/checkout", Severity: SeverityMedium},
+			{Action: "owner/repo", Severity: SeverityHigh},
+		}},
+		{FilePath: "b.yml", Issues: []Finding{
+			{Action: "actions/cache", Severity: SeverityLow},
+		}},
+	}
+
+	got := FilterBySeverity(wfs, SeverityHigh)
+	if len(got) != 1 || got[0].FilePath != "a.yml" {
+		t.Fatalf("FilterBySeverity() workflows = %+v; want only a.yml", got)
+	}
+	if len(got[0].Issues) != 1 || got[0].Issues[0].Action != "owner/repo" {
+		t.Fatalf("FilterBySeverity() issues = %+v; want only owner/repo", got[0].Issues)
+	}
+
+	if got := FilterBySeverity(wfs, SeverityLow); len(got) != 2 {
+		t.Fatalf("FilterBySeverity(low) kept %d workflows; want 2", len(got))
+	}
+}
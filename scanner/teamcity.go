@@ -0,0 +1,80 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// teamCityEscaper escapes values of TeamCity service message attributes
+var teamCityEscaper = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"\n", "|n",
+	"\r", "|r",
+	"[", "|[",
+	"]", "|]",
+	"\u0085", "|x",
+	"\u2028", "|l",
+	"\u2029", "|p",
+)
+
+// teamCitySeverity maps a severity to a TeamCity inspection severity
+func teamCitySeverity(s Severity) string {
+	switch s {
+	case SeverityHigh:
+		return "ERROR"
+	case SeverityLow:
+		return "WEAK WARNING"
+	}
+
+	return "WARNING"
+}
+
+// teamCityMessage renders a service message, Ex: ##teamcity[inspection typeId='x']
+func teamCityMessage(name string, attrs ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "##teamcity[%s", name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(&b, " %s='%s'", attrs[i], teamCityEscaper.Replace(attrs[i+1]))
+	}
+	b.WriteString("]\n")
+
+	return b.String()
+}
+
+// FormatTeamCity renders the findings of a report as TeamCity service messages:
+// the inspection type once, then an inspection per finding, so TeamCity lists
+// them on the build's Inspections tab
+func FormatTeamCity(r *AuditReport) string {
+	var b strings.Builder
+	for _, id := range usedRules(r) {
+		rule := sarifRules[id]
+		b.WriteString(teamCityMessage("inspectionType",
+			"id", rule.ID,
+			"name", rule.Name,
+			"description", rule.ShortDescription.Text,
+			"category", "Security",
+		))
+	}
+
+	for _, wf := range r.Workflows {
+		file := sarifArtifact(r.Root, wf.FilePath).URI
+		for _, f := range wf.Issues {
+			b.WriteString(teamCityMessage("inspection",
+				"typeId", f.RuleID(),
+				"message", f.FixMsg,
+				"file", file,
+				"line", fmt.Sprint(f.Line),
+				"SEVERITY", teamCitySeverity(f.Severity),
+			))
+		}
+	}
+
+	return b.String()
+}
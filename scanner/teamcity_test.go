@@ -0,0 +1,53 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatTeamCity(t *testing.T) {
+	root := t.TempDir()
+	report := &AuditReport{
+		Root: root,
+		Workflows: []Workflow{{
+			FilePath: filepath.Join(root, ".github", "workflows", "ci.yml"),
+			Issues: []Finding{
+				{Line: 12, Column: 15, Severity: SeverityHigh, FixMsg: "Pin `actions/setup-go` to sha-go"},
+				{Line: 20, Column: 9, Severity: SeverityMedium, FixMsg: "Reference 'v9' is not found [for now]"},
+			},
+		}},
+	}
+
+	lines := strings.Split(strings.TrimSpace(FormatTeamCity(report)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected an inspection type and 2 inspections, got:\n%s", strings.Join(lines, "\n"))
+	}
+	if !strings.HasPrefix(lines[0], "##teamcity[inspectionType id='unpinned-action' ") {
+		t.Fatalf("unexpected inspection type: %s", lines[0])
+	}
+
+	want := []string{
+		"##teamcity[inspection typeId='unpinned-action' message='Pin `actions/setup-go` to sha-go' file='.github/workflows/ci.yml' line='12' SEVERITY='ERROR']",
+		"##teamcity[inspection typeId='unpinned-action' message='Reference |'v9|' is not found |[for now|]' file='.github/workflows/ci.yml' line='20' SEVERITY='WARNING']",
+	}
+	for i, w := range want {
+		if lines[i+1] != w {
+			t.Errorf("inspection %d =\n%s\nwant\n%s", i, lines[i+1], w)
+		}
+	}
+}
+
+func TestTeamCityEscaping(t *testing.T) {
+	got := teamCityEscaper.Replace("a|b'c\nd\re[f]g\u0085h\u2028i\u2029j")
+	want := "a||b|'c|nd|re|[f|]g|xh|li|pj"
+	if got != want {
+		t.Fatalf("escaped = %q; want %q", got, want)
+	}
+}
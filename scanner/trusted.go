@@ -0,0 +1,39 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ValidateTrustedOwners rejects malformed owner globs (Ex: actions, my-org-*) up
+// front, as path.Match only reports them when matching
+func ValidateTrustedOwners(globs []string) error {
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil || strings.Contains(glob, "/") {
+			return fmt.Errorf("invalid trusted owner %q: want an owner name or glob, Ex: actions or my-org-*", glob)
+		}
+	}
+
+	return nil
+}
+
+// isTrustedOwner reports whether the owner of action (Ex: actions of actions/checkout)
+// matches one of the owner globs. Owners are matched case-insensitively like GitHub does.
+func isTrustedOwner(globs []string, action string) bool {
+	owner, _, _ := strings.Cut(action, "/")
+	owner = strings.ToLower(owner)
+	for _, glob := range globs {
+		if ok, _ := path.Match(strings.ToLower(glob), owner); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybrota/scharf/config"
+)
+
+const trustedWorkflow = "steps:\n  - uses: actions/checkout@v4\n  - uses: third-party/x@v1\n"
+
+func TestAuditRepositoryTrustsOwnersOfOptions(t *testing.T) {
+	root := t.TempDir()
+	initGitRepo(t, root)
+	writeWorkflow(t, root, trustedWorkflow)
+
+	report, err := AuditRepositoryReport(FilePath(root), staticResolver{sha: "sha"}, AuditOptions{TrustedOwners: []string{"actions"}})
+	if err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+	if len(report.Workflows) != 1 || len(report.Workflows[0].Issues) != 1 || report.Workflows[0].Issues[0].Original != "third-party/x@v1" {
+		t.Fatalf("expected only third-party/x@v1 to be flagged, got %+v", report.Workflows)
+	}
+
+	// Options apply to their audit only
+	report, err = AuditRepositoryReport(FilePath(root), staticResolver{sha: "sha"}, AuditOptions{})
+	if err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+	if len(report.Workflows) != 1 || len(report.Workflows[0].Issues) != 2 {
+		t.Fatalf("expected both actions to be flagged without trusted owners, got %+v", report.Workflows)
+	}
+}
+
+func TestAuditRepositoryTrustsConfiguredOwners(t *testing.T) {
+	root := t.TempDir()
+	initGitRepo(t, root)
+	writeWorkflow(t, root, trustedWorkflow)
+	if err := os.WriteFile(filepath.Join(root, config.FileName), []byte("trusted_owners: [ACTIONS]\n"), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	report, err := AuditRepositoryReport(FilePath(root), staticResolver{sha: "sha"}, AuditOptions{})
+	if err != nil {
+		t.Fatalf("AuditRepositoryReport returned error: %v", err)
+	}
+	if len(report.Workflows) != 1 || len(report.Workflows[0].Issues) != 1 || report.Workflows[0].Issues[0].Action != "third-party/x" {
+		t.Fatalf("expected only third-party/x to be flagged, got %+v", report.Workflows)
+	}
+}
+
+func TestValidateTrustedOwnersRejectsInvalidGlobs(t *testing.T) {
+	for _, glob := range []string{"my-org-[", "actions/checkout"} {
+		if err := ValidateTrustedOwners([]string{glob}); err == nil {
+			t.Errorf("expected an error for %q", glob)
+		}
+	}
+}
+
+func TestIsTrustedOwner(t *testing.T) {
+	globs := []string{"actions", "my-org-*"}
+	tests := map[string]bool{
+		"actions/checkout":          true,
+		"Actions/Setup-Go":          true,
+		"my-org-infra/deploy":       true,
+		"my-org/deploy":             false,
+		"actions-rs/toolchain":      false,
+		"third-party/x/sub/path":    false,
+		"my-org-infra/deploy/build": true,
+	}
+	for action, want := range tests {
+		if got := isTrustedOwner(globs, action); got != want {
+			t.Errorf("isTrustedOwner(%q) = %v; want %v", action, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// unpinFindings returns the Scharf-formatted pins of content, whose comment names
+// the ref to revert to, and the lines of pins without such a comment
+func unpinFindings(content []byte) ([]Finding, []int) {
+	pins := CollectPinnedRefs(content)
+	commented := map[int]bool{}
+	for _, p := range pins {
+		commented[p.StartOffset] = true
+	}
+
+	var bare []int
+	matches, _ := ScanContentWithPosition(content, pinnedSHARegex)
+	for _, m := range matches {
+		if !commented[m.StartOffset] && !isInLocalReference(content, m.StartOffset) {
+			bare = append(bare, m.Line)
+		}
+	}
+
+	return pins, bare
+}
+
+// unpinLine rewrites a pin to its commented ref. A comment merged into the version
+// comment by autofix, Ex: "# v4 - keep in sync", is kept as a comment of its own.
+func unpinLine(issue Finding, rest string) string {
+	ref := fmt.Sprintf("%s@%s", issue.Action, issue.Version)
+	if note, ok := strings.CutPrefix(rest, " - "); ok {
+		return fmt.Sprintf("%s # %s", ref, note)
+	}
+
+	return ref + rest
+}
+
+// UnpinFile reverts the pins of a workflow file, Ex: actions/checkout@<sha> # v4,
+// to the ref named by their comment, Ex: actions/checkout@v4. Pins without a
+// comment are left untouched with a warning, as their ref is unknown. It returns
+// the number of pins reverted, or that would be reverted in a dry run.
+func UnpinFile(path FilePath, dryRun bool) (int, error) {
+	content, err := ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("file error: %w", err)
+	}
+
+	pins, bare := unpinFindings(content)
+	if len(pins) == 0 && len(bare) == 0 {
+		return 0, nil
+	}
+
+	fmt.Printf("📌 Unpinning %s%s%s: \n", Cyan, path, Reset)
+	for _, line := range bare {
+		fmt.Printf("  - [%sLine %d%s] %s Warning: Left the pin untouched, as it has no '# <ref>' comment to revert to%s ⚠️\n", Gray, line, Reset, Yellow, Reset)
+	}
+
+	wf := Workflow{FilePath: string(path), Issues: pins}
+	n, err := rewriteFindings(wf, dryRun, func(issue Finding, rest string, loc string) (string, bool) {
+		fmt.Printf("  - [%s%s%s] %s Unpinned: '%s@%s' to '%s' %s\n", Gray, loc, Reset, Green, issue.Action, issue.FixSHA, issue.Version, Reset)
+		return unpinLine(issue, rest), true
+	})
+	if err != nil {
+		return 0, fmt.Errorf("file error: %w", err)
+	}
+
+	return n, nil
+}
+
+// UnpinRepository reverts the commented pins of every workflow in the repository
+// to their refs, Ex: to test against a moving tag for a while. It returns the
+// number of pins reverted, or that would be reverted in a dry run.
+func UnpinRepository(path FilePath, dryRun bool) (int, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return 0, fmt.Errorf("os: %w", err)
+	}
+
+	files, err := listWorkflowFiles(abs)
+	if err != nil {
+		return 0, fmt.Errorf("file error: %w", err)
+	}
+
+	total := 0
+	for _, f := range files {
+		n, err := UnpinFile(FilePath(f), dryRun)
+		if err != nil {
+			if errors.Is(err, syscall.EISDIR) {
+				continue
+			}
+			return total, err
+		}
+		total += n
+	}
+
+	if total == 0 {
+		fmt.Println("No pins to revert")
+	} else if dryRun {
+		fmt.Println("The displayed changes are not staged. Re-run 'scharf unpin' and omit the flag '--dry-run' to apply them.")
+	}
+	return total, nil
+}
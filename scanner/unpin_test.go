@@ -0,0 +1,82 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const unpinSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+
+func TestUnpinRepository(t *testing.T) {
+	tmp := t.TempDir()
+	workflowFile := writeWorkflow(t, tmp, strings.Join([]string{
+		"steps:",
+		"  - uses: actions/checkout@" + unpinSHA + " # v4",
+		"  - uses: github/codeql-action/init@" + unpinSHA + " # v3 - keep in sync",
+		"  - uses: actions/setup-go@" + unpinSHA,
+		"  - uses: actions/cache@v4",
+		"",
+	}, "\n"))
+	want := strings.Join([]string{
+		"steps:",
+		"  - uses: actions/checkout@v4",
+		"  - uses: github/codeql-action/init@v3 # keep in sync",
+		"  - uses: actions/setup-go@" + unpinSHA,
+		"  - uses: actions/cache@v4",
+		"",
+	}, "\n")
+
+	var n int
+	out := captureStdout(t, func() {
+		var err error
+		n, err = UnpinRepository(FilePath(tmp), false)
+		if err != nil {
+			t.Fatalf("UnpinRepository returned error: %v", err)
+		}
+	})
+
+	if n != 2 {
+		t.Fatalf("expected 2 pins reverted, got %d", n)
+	}
+	content, err := os.ReadFile(workflowFile)
+	if err != nil {
+		t.Fatalf("reading workflow: %v", err)
+	}
+	if string(content) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", content, want)
+	}
+	// A pin without comment has no known ref to revert to
+	if !strings.Contains(out, "Line 4") || !strings.Contains(out, "no '# <ref>' comment") {
+		t.Fatalf("expected a warning for the bare pin, got:\n%s", out)
+	}
+}
+
+func TestUnpinRepositoryDryRun(t *testing.T) {
+	tmp := t.TempDir()
+	original := "steps:\n  - uses: actions/checkout@" + unpinSHA + " # v4\n"
+	workflowFile := writeWorkflow(t, tmp, original)
+
+	var n int
+	captureStdout(t, func() {
+		var err error
+		n, err = UnpinRepository(FilePath(tmp), true)
+		if err != nil {
+			t.Fatalf("UnpinRepository returned error: %v", err)
+		}
+	})
+
+	content, err := os.ReadFile(workflowFile)
+	if err != nil {
+		t.Fatalf("reading workflow: %v", err)
+	}
+	if n != 1 || string(content) != original {
+		t.Fatalf("expected 1 pending change and an untouched file, got %d:\n%s", n, content)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// LatestReleaser looks up the latest published release of an action
+type LatestReleaser interface {
+	LatestRelease(action string) (*network.Release, error)
+}
+
+// UpdateFinding is an action whose latest release is newer than the version in use
+type UpdateFinding struct {
+	Action      string    `json:"action"`
+	Version     string    `json:"version"` // version in use, Ex: v4
+	Latest      string    `json:"latest"`  // tag of the latest release, Ex: v5.0.0
+	PublishedAt time.Time `json:"published_at"`
+}
+
+func (u UpdateFinding) String() string {
+	return fmt.Sprintf("%s %s → %s (released %s)", u.Action, u.Version, u.Latest, u.PublishedAt.Format(time.DateOnly))
+}
+
+// ParseSince parses the cutoff of an update check, given as a date (Ex: 2024-01-01)
+// or an RFC 3339 timestamp
+func ParseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q. Ex: 2024-01-01", s)
+	}
+
+	return t, nil
+}
+
+// isCurrentVersion reports whether version already tracks latest. A major or
+// minor tag like v4 moves along with its latest patch release v4.2.1.
+func isCurrentVersion(version string, latest string) bool {
+	return version == latest || strings.HasPrefix(latest, version+".")
+}
+
+// versionsInUse returns the owner/repo and version of every mutable reference in
+// content, and of every Scharf-formatted pin by its commented version. Releases
+// belong to the repository, so sub-action paths are dropped.
+func versionsInUse(content []byte) [][2]string {
+	var refs [][2]string
+	matches, _ := ScanContentWithPosition(content, findRegex)
+	for _, m := range matches {
+		if isInLocalReference(content, m.StartOffset) || isPinnedReference(content, m.StartOffset) {
+			continue
+		}
+		action, version, _ := strings.Cut(m.Text, "@")
+		repo, _ := splitActionPath(action)
+		refs = append(refs, [2]string{repo, version})
+	}
+	for _, pin := range CollectPinnedRefs(content) {
+		repo, _ := splitActionPath(pin.Action)
+		refs = append(refs, [2]string{repo, pin.Version})
+	}
+
+	return refs
+}
+
+// CheckUpdates lists the actions of every workflow in the repository whose latest
+// release is newer than the version in use and was published after since. A zero
+// since reports every available update. Branch references are skipped, and actions
+// whose latest release can't be looked up, Ex: as they publish none, are warned about.
+func CheckUpdates(path FilePath, lr LatestReleaser, since time.Time) ([]UpdateFinding, []Warning, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("os: %w", err)
+	}
+
+	files, err := listWorkflowFiles(abs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("file error: %w", err)
+	}
+
+	var updates []UpdateFinding
+	var warnings []Warning
+	releases := map[string]*network.Release{}
+	seen := map[[2]string]bool{}
+	for _, f := range files {
+		content, err := ReadFile(FilePath(f))
+		if err != nil {
+			if errors.Is(err, syscall.EISDIR) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("file error: %w", err)
+		}
+
+		for _, ref := range versionsInUse(content) {
+			action, version := ref[0], ref[1]
+			if seen[ref] || isBranchRef(version) {
+				continue
+			}
+			seen[ref] = true
+
+			release, ok := releases[action]
+			if !ok {
+				release, err = lr.LatestRelease(action)
+				if err != nil {
+					warnings = append(warnings, Warning{
+						Kind:    WarningUpdateCheck,
+						File:    f,
+						Action:  action,
+						Message: fmt.Sprintf("Could not check %s for updates: %s", action, err.Error()),
+					})
+				}
+				releases[action] = release
+			}
+			if release == nil || isCurrentVersion(version, release.TagName) || !release.PublishedAt.After(since) {
+				continue
+			}
+
+			updates = append(updates, UpdateFinding{
+				Action:      action,
+				Version:     version,
+				Latest:      release.TagName,
+				PublishedAt: release.PublishedAt,
+			})
+		}
+	}
+
+	return updates, warnings, nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cybrota/scharf/network"
+)
+
+// fakeReleaser answers the latest release of known actions
+type fakeReleaser map[string]*network.Release
+
+func (f fakeReleaser) LatestRelease(action string) (*network.Release, error) {
+	if r, ok := f[action]; ok {
+		return r, nil
+	}
+	return nil, errors.New("latest release is not found")
+}
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := ParseSince(s)
+	if err != nil {
+		t.Fatalf("ParseSince(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestCheckUpdatesFiltersByReleaseDate(t *testing.T) {
+	tmp := t.TempDir()
+	writeWorkflow(t, tmp, "steps:\n"+
+		"  - uses: actions/checkout@v4\n"+
+		"  - uses: actions/setup-go@v4\n"+
+		"  - uses: actions/cache@0c45773b623bea8c8e75f6c82b208c3cf94ea4f9 # v3\n"+
+		"  - uses: actions/upload-artifact@v4\n"+
+		"  - uses: docker/build-push-action@main\n"+
+		"  - uses: cybrota/unreleased@v1\n")
+
+	lr := fakeReleaser{
+		"actions/checkout":         {TagName: "v5.0.0", PublishedAt: date(t, "2025-08-11")},
+		"actions/setup-go":         {TagName: "v5.0.0", PublishedAt: date(t, "2023-12-05")},
+		"actions/cache":            {TagName: "v4.2.0", PublishedAt: date(t, "2024-12-05")},
+		"actions/upload-artifact":  {TagName: "v4.6.2", PublishedAt: date(t, "2025-03-19")},
+		"docker/build-push-action": {TagName: "v6.0.0", PublishedAt: date(t, "2025-01-01")},
+	}
+
+	updates, warnings, err := CheckUpdates(FilePath(tmp), lr, date(t, "2024-01-01"))
+	if err != nil {
+		t.Fatalf("CheckUpdates returned error: %v", err)
+	}
+
+	// setup-go was released before the cutoff, upload-artifact@v4 already tracks
+	// v4.6.2 and branch references are skipped
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %d: %+v", len(updates), updates)
+	}
+	if updates[0].Action != "actions/checkout" || updates[0].Version != "v4" || updates[0].Latest != "v5.0.0" {
+		t.Errorf("unexpected first update: %+v", updates[0])
+	}
+	if updates[1].Action != "actions/cache" || updates[1].Version != "v3" {
+		t.Errorf("expected the pinned cache action by its commented version, got %+v", updates[1])
+	}
+	if got := updates[0].String(); got != "actions/checkout v4 → v5.0.0 (released 2025-08-11)" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if len(warnings) != 1 || warnings[0].Action != "cybrota/unreleased" || warnings[0].Kind != WarningUpdateCheck {
+		t.Fatalf("expected a warning for the action without releases, got %+v", warnings)
+	}
+
+	// Without a cutoff, every available update is reported
+	updates, _, err = CheckUpdates(FilePath(tmp), lr, time.Time{})
+	if err != nil {
+		t.Fatalf("CheckUpdates returned error: %v", err)
+	}
+	if len(updates) != 3 {
+		t.Fatalf("expected 3 updates without a cutoff, got %d: %+v", len(updates), updates)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	if got := date(t, "2024-01-01"); got.Year() != 2024 || got.YearDay() != 1 {
+		t.Fatalf("unexpected date: %v", got)
+	}
+	if _, err := ParseSince("2024-01-01T10:00:00Z"); err != nil {
+		t.Fatalf("expected RFC 3339 timestamps to parse: %v", err)
+	}
+	if _, err := ParseSince("last week"); err == nil {
+		t.Fatal("expected an error for an invalid date")
+	}
+}
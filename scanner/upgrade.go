@@ -32,8 +32,8 @@ type upgradeResolver interface {
 	ListTags(action string) ([]network.BranchOrTag, error)
 }
 
-var newUpgradeResolver = func() upgradeResolver {
-	return network.NewSHAResolver()
+var newUpgradeResolver = func(res *network.SHAResolver) upgradeResolver {
+	return res
 }
 
 // PinnedRef is a strict Scharf-formatted pinned action reference.
@@ -88,20 +88,23 @@ func CollectPinnedRefs(content []byte) []Finding {
 		}
 
 		findings = append(findings, Finding{
-			Line:     m.Line,
-			Column:   m.Col,
-			Action:   parsed.Action,
-			Version:  parsed.Version,
-			FixSHA:   parsed.SHA,
-			Original: m.Text,
+			Line:        m.Line,
+			Column:      m.Col,
+			StartOffset: m.StartOffset,
+			EndOffset:   m.EndOffset,
+			Action:      parsed.Action,
+			Version:     parsed.Version,
+			FixSHA:      parsed.SHA,
+			Original:    m.Text,
 		})
 	}
 
 	return findings
 }
 
-// UpgradePinnedSHAs upgrades Scharf-formatted pinned SHAs in workflow files.
-func UpgradePinnedSHAs(path FilePath, cooldownHours int, isDryRun bool) error {
+// UpgradePinnedSHAs upgrades Scharf-formatted pinned SHAs in workflow files,
+// looking up the next versions with res.
+func UpgradePinnedSHAs(path FilePath, res *network.SHAResolver, cooldownHours int, isDryRun bool) error {
 	abs, err := filepath.Abs(filepath.Join(string(path)))
 	if err != nil {
 		return fmt.Errorf("os: %w", err)
@@ -111,16 +114,14 @@ func UpgradePinnedSHAs(path FilePath, cooldownHours int, isDryRun bool) error {
 		return fmt.Errorf("The directory: %s is not a Git repository", abs)
 	}
 
-	loc := filepath.Join(abs, ".github", "workflows")
-	fileNames, err := ListFiles(FilePath(loc))
+	workflowPaths, err := listWorkflowFiles(abs)
 	if err != nil {
 		return fmt.Errorf("file error: %w", err)
 	}
 
-	resolver := newUpgradeResolver()
+	resolver := newUpgradeResolver(res)
 
-	for _, fileName := range fileNames {
-		workflowPath := filepath.Join(loc, string(*fileName))
+	for _, workflowPath := range workflowPaths {
 		content, err := ReadFile(FilePath(workflowPath))
 		if err != nil {
 			if errors.Is(err, syscall.EISDIR) {
@@ -0,0 +1,90 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cybrota/scharf/git"
+	"github.com/cybrota/scharf/network"
+)
+
+// VerifyWorkflow checks that every Scharf-formatted pin (owner/repo@<sha> # <version>)
+// in content still matches what its commented tag resolves to. A mismatch means the
+// tag was force-moved after pinning, which may be a tampering attempt.
+func VerifyWorkflow(res network.Resolver, content []byte, filePath string) *Workflow {
+	var issues []Finding
+	for _, pin := range CollectPinnedRefs(content) {
+		tagRef := fmt.Sprintf("%s@%s", pin.Action, pin.Version)
+		pinnedSHA := pin.FixSHA
+
+		currentSHA, err := res.Resolve(tagRef)
+		if err != nil {
+			pin.Description = fmt.Sprintf("Could not verify pin of `%s`: %s", tagRef, err.Error())
+			pin.FixMsg = fmt.Sprintf("Check that %s still exists. Try 'scharf list %s' to see available versions.", pin.Version, pin.Action)
+			pin.FixSHA = SHA256NotAvailable
+			pin.Severity = SeverityMedium
+			issues = append(issues, pin)
+			continue
+		}
+		if currentSHA == pinnedSHA {
+			continue
+		}
+
+		pin.Description = fmt.Sprintf("Tag moved: `%s` now points to %s, but %s is pinned", tagRef, currentSHA, pinnedSHA)
+		pin.FixMsg = fmt.Sprintf("Review the changes between %s and %s before re-pinning `%s`", pinnedSHA, currentSHA, pin.Action)
+		pin.FixSHA = currentSHA
+		pin.Severity = SeverityHigh
+		issues = append(issues, pin)
+	}
+
+	return &Workflow{
+		Name:     filePath,
+		FilePath: filePath,
+		Issues:   issues,
+	}
+}
+
+// VerifyRepository verifies the pinned SHAs of every workflow in a Git repository.
+// res should bypass any cache, as a cached SHA hides a moved tag.
+func VerifyRepository(path FilePath, res network.Resolver) (*[]Workflow, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	if !git.IsGitRepo(abs) {
+		return nil, fmt.Errorf("The directory: %s is not a Git repository", abs)
+	}
+
+	files, err := listWorkflowFiles(abs)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	fmt.Printf("No of workflows: %s%d%s\n\n", Blue, len(files), Reset)
+
+	var wfs []Workflow
+	for _, f := range files {
+		content, err := ReadFile(FilePath(f))
+		if err != nil {
+			if errors.Is(err, syscall.EISDIR) {
+				continue
+			}
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+
+		if wf := VerifyWorkflow(res, content, f); len(wf.Issues) > 0 {
+			wfs = append(wfs, *wf)
+		}
+	}
+
+	return &wfs, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// tagResolver resolves action@version references from a fixed table
+type tagResolver map[string]string
+
+func (r tagResolver) Resolve(action string) (string, error) {
+	if sha, ok := r[action]; ok {
+		return sha, nil
+	}
+	return "", errors.New("given version is not found")
+}
+
+func TestVerifyRepository(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, strings.Join([]string{
+		"steps:",
+		"  - uses: actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v4",
+		"  - uses: actions/setup-go@bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb # v5",
+		"  - uses: actions/cache@cccccccccccccccccccccccccccccccccccccccc # v3",
+		"  - uses: actions/upload-artifact@v4",
+	}, "\n"))
+
+	res := tagResolver{
+		"actions/checkout@v4": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		// v5 was force-moved after pinning
+		"actions/setup-go@v5": "dddddddddddddddddddddddddddddddddddddddd",
+	}
+
+	var wfs *[]Workflow
+	captureStdout(t, func() {
+		var err error
+		wfs, err = VerifyRepository(FilePath(tmp), res)
+		if err != nil {
+			t.Fatalf("VerifyRepository returned error: %v", err)
+		}
+	})
+
+	if len(*wfs) != 1 || len((*wfs)[0].Issues) != 2 {
+		t.Fatalf("expected 2 findings in 1 workflow, got %+v", *wfs)
+	}
+
+	moved := (*wfs)[0].Issues[0]
+	if moved.Action != "actions/setup-go" || moved.Severity != SeverityHigh || moved.FixSHA != "dddddddddddddddddddddddddddddddddddddddd" {
+		t.Errorf("unexpected moved tag finding: %+v", moved)
+	}
+	if !strings.Contains(moved.Description, "Tag moved") || moved.Line != 3 {
+		t.Errorf("unexpected moved tag description or line: %+v", moved)
+	}
+
+	missing := (*wfs)[0].Issues[1]
+	if missing.Action != "actions/cache" || missing.FixSHA != SHA256NotAvailable {
+		t.Errorf("unexpected unverifiable finding: %+v", missing)
+	}
+}
+
+func TestVerifyRepository_AllMatch(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, "steps:\n  - uses: actions/checkout@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa # v4\n")
+
+	var wfs *[]Workflow
+	captureStdout(t, func() {
+		var err error
+		wfs, err = VerifyRepository(FilePath(tmp), tagResolver{"actions/checkout@v4": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"})
+		if err != nil {
+			t.Fatalf("VerifyRepository returned error: %v", err)
+		}
+	})
+
+	if len(*wfs) != 0 {
+		t.Fatalf("expected no findings, got %+v", *wfs)
+	}
+}
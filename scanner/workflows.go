@@ -0,0 +1,172 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultWorkflowDir is where GitHub looks for workflows, relative to the repository root
+const DefaultWorkflowDir = ".github/workflows"
+
+// WorkflowDirEnv holds a colon-separated list of workflow directories,
+// so CI can set unusual layouts once instead of on every invocation.
+const WorkflowDirEnv = "SCHARF_WORKFLOW_DIR"
+
+// workflowDirs is set from the --workflow-dir flag and wins over the env var
+var workflowDirs []string
+
+// SetWorkflowDirs overrides the workflow directories scanned in each repository
+func SetWorkflowDirs(dirs []string) {
+	workflowDirs = dirs
+}
+
+// WorkflowDirs returns the workflow directories to scan, relative to the repository root.
+// Precedence: --workflow-dir flag, then SCHARF_WORKFLOW_DIR, then .github/workflows.
+func WorkflowDirs() []string {
+	if len(workflowDirs) > 0 {
+		return workflowDirs
+	}
+
+	var dirs []string
+	for _, d := range strings.Split(os.Getenv(WorkflowDirEnv), ":") {
+		if d = strings.TrimSpace(d); d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	if len(dirs) > 0 {
+		return dirs
+	}
+
+	return []string{DefaultWorkflowDir}
+}
+
+// hasWorkflowDir reports whether any configured workflow directory exists under root
+func hasWorkflowDir(root string) bool {
+	for _, d := range WorkflowDirs() {
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(d)))
+		if err == nil && info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NoWorkflowsError lists the directories searched when no workflow file was found,
+// so users who pointed at the wrong directory (Ex: in a monorepo) notice it
+type NoWorkflowsError struct {
+	Searched []string
+}
+
+func (e *NoWorkflowsError) Error() string {
+	return fmt.Sprintf("no workflow files found. Looked in: %s", strings.Join(e.Searched, ", "))
+}
+
+// listWorkflowFiles returns the paths of entries in every configured workflow
+// directory under root. Missing directories are skipped, but it is an error
+// when no workflow file is found at all.
+func listWorkflowFiles(root string) ([]string, error) {
+	var files []string
+	var searched []string
+	for _, d := range WorkflowDirs() {
+		loc := filepath.Join(root, filepath.FromSlash(d))
+		searched = append(searched, loc)
+		fileNames, err := ListFiles(FilePath(loc))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, fileName := range fileNames {
+			files = append(files, filepath.Join(loc, string(*fileName)))
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, &NoWorkflowsError{Searched: searched}
+	}
+
+	return files, nil
+}
+
+// actionMetadataFiles are the names of the metadata file of an action
+var actionMetadataFiles = map[string]bool{"action.yml": true, "action.yaml": true}
+
+// skippedActionDirs hold no actions of the repository itself
+var skippedActionDirs = map[string]bool{".git": true, "node_modules": true}
+
+// listActionFiles returns the paths of action metadata files (action.yml or
+// action.yaml) at root and in its subdirectories. Composite actions declare their
+// own steps, which may use other actions.
+func listActionFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skippedActionDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if actionMetadataFiles[d.Name()] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+
+	return files, nil
+}
+
+// listAuditFiles returns the workflow files and the action metadata files of the
+// repository at root. It is only an error when neither is found.
+func listAuditFiles(root string) ([]string, []string, error) {
+	actions, err := listActionFiles(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workflows, err := listWorkflowFiles(root)
+	if err != nil {
+		var nwe *NoWorkflowsError
+		if !errors.As(err, &nwe) || len(actions) == 0 {
+			return nil, nil, err
+		}
+	}
+
+	return workflows, actions, nil
+}
+
+// CountWorkflowFiles returns the number of workflow files found in a repository
+func CountWorkflowFiles(path FilePath) (int, error) {
+	abs, err := filepath.Abs(string(path))
+	if err != nil {
+		return 0, fmt.Errorf("os: %w", err)
+	}
+
+	files, err := listWorkflowFiles(abs)
+	if err != nil {
+		var nwe *NoWorkflowsError
+		if errors.As(err, &nwe) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return len(files), nil
+}
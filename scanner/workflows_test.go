@@ -0,0 +1,195 @@
+// Copyright (c) 2025 Naren Yellavula & Cybrota contributors
+// Apache License, Version 2.0
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFileAt(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+}
+
+func TestWorkflowDirsPrecedence(t *testing.T) {
+	t.Cleanup(func() { SetWorkflowDirs(nil) })
+
+	t.Setenv(WorkflowDirEnv, "")
+	if got := WorkflowDirs(); !reflect.DeepEqual(got, []string{DefaultWorkflowDir}) {
+		t.Errorf("WorkflowDirs() = %v; want default", got)
+	}
+
+	t.Setenv(WorkflowDirEnv, "ci/workflows:: deploy/workflows ")
+	if got := WorkflowDirs(); !reflect.DeepEqual(got, []string{"ci/workflows", "deploy/workflows"}) {
+		t.Errorf("WorkflowDirs() = %v; want env directories", got)
+	}
+
+	SetWorkflowDirs([]string{"flag/workflows"})
+	if got := WorkflowDirs(); !reflect.DeepEqual(got, []string{"flag/workflows"}) {
+		t.Errorf("WorkflowDirs() = %v; want flag directories", got)
+	}
+}
+
+func TestAuditRepositoryScansEnvWorkflowDirs(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeFileAt(t, filepath.Join(tmp, "ci", "workflows", "a.yml"), "uses: actions/checkout@v4\n")
+	writeFileAt(t, filepath.Join(tmp, "deploy", "workflows", "b.yml"), "uses: actions/setup-go@v5\n")
+	// The default directory must be ignored once the env var is set
+	writeWorkflow(t, tmp, "uses: actions/cache@v3\n")
+
+	t.Setenv(WorkflowDirEnv, "ci/workflows:deploy/workflows:missing/workflows")
+
+	var wfs *[]Workflow
+	captureStdout(t, func() {
+		var err error
+		wfs, err = AuditRepository(FilePath(tmp), staticResolver{sha: "sha"})
+		if err != nil {
+			t.Fatalf("AuditRepository returned error: %v", err)
+		}
+	})
+
+	var originals []string
+	for _, wf := range *wfs {
+		for _, f := range wf.Issues {
+			originals = append(originals, f.Original)
+		}
+	}
+	if !reflect.DeepEqual(originals, []string{"actions/checkout@v4", "actions/setup-go@v5"}) {
+		t.Fatalf("unexpected findings: %v", originals)
+	}
+}
+
+func TestAuditRepositoryErrorsWhenNoWorkflowDirExists(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	t.Setenv(WorkflowDirEnv, "missing/workflows")
+
+	captureStdout(t, func() {
+		if _, err := AuditRepository(FilePath(tmp), staticResolver{sha: "sha"}); err == nil {
+			t.Fatal("expected error when no workflow directory exists, got nil")
+		}
+	})
+}
+
+func TestAuditRepositoryNoWorkflowsMessageNamesSearchedPath(t *testing.T) {
+	for name, setup := range map[string]func(string){
+		"missing directory": func(string) {},
+		"empty directory": func(tmp string) {
+			os.MkdirAll(filepath.Join(tmp, ".github", "workflows"), 0o755)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tmp := t.TempDir()
+			initGitRepo(t, tmp)
+			setup(tmp)
+
+			var err error
+			captureStdout(t, func() {
+				_, err = AuditRepository(FilePath(tmp), staticResolver{sha: "sha"})
+			})
+			if err == nil {
+				t.Fatal("expected error when no workflows exist, got nil")
+			}
+
+			var nwe *NoWorkflowsError
+			if !errors.As(err, &nwe) {
+				t.Fatalf("expected NoWorkflowsError, got %T: %v", err, err)
+			}
+			searched := filepath.Join(tmp, ".github", "workflows")
+			if !strings.Contains(err.Error(), searched) {
+				t.Fatalf("expected searched path %q in message, got: %v", searched, err)
+			}
+		})
+	}
+}
+
+func TestCountWorkflowFiles(t *testing.T) {
+	tmp := t.TempDir()
+	if got, err := CountWorkflowFiles(FilePath(tmp)); err != nil || got != 0 {
+		t.Fatalf("CountWorkflowFiles() = (%d, %v); want (0, nil)", got, err)
+	}
+
+	writeWorkflow(t, tmp, "uses: actions/checkout@v4\n")
+	if got, err := CountWorkflowFiles(FilePath(tmp)); err != nil || got != 1 {
+		t.Fatalf("CountWorkflowFiles() = (%d, %v); want (1, nil)", got, err)
+	}
+}
+
+const compositeAction = `name: setup
+runs:
+  using: composite
+  steps:
+    # - uses: actions/cache@v3
+    - uses: actions/setup-node@v4
+    - run: 'echo "uses: docker/login-action@v3"'
+      shell: bash
+`
+
+func TestAuditRepositoryScansCompositeActions(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeWorkflow(t, tmp, "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n")
+	rootAction := filepath.Join(tmp, "action.yml")
+	nestedAction := filepath.Join(tmp, ".github", "actions", "setup", "action.yaml")
+	writeFileAt(t, rootAction, compositeAction)
+	writeFileAt(t, nestedAction, compositeAction)
+	// Vendored dependencies are not the repository's own actions
+	writeFileAt(t, filepath.Join(tmp, "node_modules", "dep", "action.yml"), compositeAction)
+
+	var report *AuditReport
+	captureStdout(t, func() {
+		var err error
+		report, err = AuditRepositoryReport(FilePath(tmp), staticResolver{sha: "sha"}, AuditOptions{})
+		if err != nil {
+			t.Fatalf("AuditRepositoryReport returned error: %v", err)
+		}
+	})
+
+	if report.WorkflowFiles != 1 || report.ActionFiles != 2 {
+		t.Fatalf("scanned %d workflows and %d action files; want 1 and 2", report.WorkflowFiles, report.ActionFiles)
+	}
+	found := map[string][]string{}
+	for _, wf := range report.Workflows {
+		for _, f := range wf.Issues {
+			found[wf.FilePath] = append(found[wf.FilePath], f.Original)
+		}
+	}
+	for _, p := range []string{rootAction, nestedAction} {
+		if got := found[p]; len(got) != 1 || got[0] != "actions/setup-node@v4" {
+			t.Errorf("findings of %s = %v; want only the unpinned step dependency", p, got)
+		}
+	}
+}
+
+func TestAuditRepositoryWithOnlyActionFiles(t *testing.T) {
+	tmp := t.TempDir()
+	initGitRepo(t, tmp)
+	writeFileAt(t, filepath.Join(tmp, "action.yml"), compositeAction)
+
+	var report *AuditReport
+	captureStdout(t, func() {
+		var err error
+		report, err = AuditRepositoryReport(FilePath(tmp), staticResolver{sha: "sha"}, AuditOptions{})
+		if err != nil {
+			t.Fatalf("expected an action repository without workflows to be audited, got: %v", err)
+		}
+	})
+	if len(report.Workflows) != 1 || len(report.Workflows[0].Issues) != 1 {
+		t.Fatalf("unexpected report: %+v", report.Workflows)
+	}
+}